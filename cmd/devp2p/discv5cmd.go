@@ -38,6 +38,8 @@ var (
 			discv5CrawlCommand,
 			discv5TestCommand,
 			discv5ListenCommand,
+			discv5TopicRegisterCommand,
+			discv5TopicQueryCommand,
 		},
 	}
 	discv5PingCommand = &cli.Command{
@@ -77,6 +79,20 @@ var (
 		Action: discv5Listen,
 		Flags:  discoveryNodeFlags,
 	}
+	discv5TopicRegisterCommand = &cli.Command{
+		Name:      "topic-register",
+		Usage:     "Runs a node advertising itself for a topic",
+		ArgsUsage: "<topic>",
+		Action:    discv5TopicRegister,
+		Flags:     discoveryNodeFlags,
+	}
+	discv5TopicQueryCommand = &cli.Command{
+		Name:      "topic-query",
+		Usage:     "Finds nodes advertising a topic",
+		ArgsUsage: "<topic>",
+		Action:    discv5TopicQuery,
+		Flags:     discoveryNodeFlags,
+	}
 )
 
 func discv5Ping(ctx *cli.Context) error {
@@ -138,6 +154,33 @@ func discv5Listen(ctx *cli.Context) error {
 	select {}
 }
 
+func discv5TopicRegister(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need topic as argument")
+	}
+	disc, _ := startV5(ctx)
+	defer disc.Close()
+
+	topic := discover.NewTopic(ctx.Args().First())
+	disc.RegisterTopic(topic)
+	fmt.Println(disc.Self())
+	fmt.Println("Advertising topic", topic)
+	select {}
+}
+
+func discv5TopicQuery(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return errors.New("need topic as argument")
+	}
+	disc, _ := startV5(ctx)
+	defer disc.Close()
+
+	for _, n := range disc.TopicQuery(discover.NewTopic(ctx.Args().First())) {
+		fmt.Println(n)
+	}
+	return nil
+}
+
 // startV5 starts an ephemeral discovery v5 node.
 func startV5(ctx *cli.Context) (*discover.UDPv5, discover.Config) {
 	ln, config := makeDiscoveryConfig(ctx)
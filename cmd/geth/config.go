@@ -211,6 +211,10 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	// Configure log filter RPC API.
 	filterSystem := utils.RegisterFilterAPI(stack, backend, &cfg.Eth)
 
+	// Configure raw database write access if requested.
+	if ctx.Bool(utils.DBWriteAPIFlag.Name) {
+		utils.RegisterDBWriteAPI(stack, backend)
+	}
 	// Configure GraphQL if requested.
 	if ctx.IsSet(utils.GraphQLEnabledFlag.Name) {
 		utils.RegisterGraphQLService(stack, backend, filterSystem, &cfg.Node)
@@ -145,7 +145,9 @@ corruption if it is aborted during execution'!`,
 			utils.SyncModeFlag,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
 		Description: `This command deletes the specified database key from the database.
-WARNING: This is a low-level operation which may cause database corruption!`,
+WARNING: This is a low-level operation which may cause database corruption!
+When used with --remotedb, the change is applied to the running remote node, which
+needs to be started with --rpc.dbwrite.`,
 	}
 	dbPutCmd = &cli.Command{
 		Action:    dbPut,
@@ -156,7 +158,9 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.SyncModeFlag,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
 		Description: `This command sets a given database key to the given value.
-WARNING: This is a low-level operation which may cause database corruption!`,
+WARNING: This is a low-level operation which may cause database corruption!
+When used with --remotedb, the change is applied to the running remote node, which
+needs to be started with --rpc.dbwrite.`,
 	}
 	dbGetSlotsCmd = &cli.Command{
 		Action:    dbDumpTrie,
@@ -186,7 +190,9 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		Flags: flags.Merge([]cli.Flag{
 			utils.SyncModeFlag,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
-		Description: "The import command imports the specific chain data from an RLP encoded stream.",
+		Description: `The import command imports the specific chain data from an RLP encoded stream.
+When used with --remotedb, the change is applied to the running remote node, which
+needs to be started with --rpc.dbwrite.`,
 	}
 	dbExportCmd = &cli.Command{
 		Action:    exportChaindata,
@@ -97,7 +97,7 @@ func TestCustomGenesis(t *testing.T) {
 	}
 }
 
-// TestCustomBackend that the backend selection and detection (leveldb vs pebble) works properly.
+// TestCustomBackend that the backend selection and detection (leveldb vs pebble vs bolt) works properly.
 func TestCustomBackend(t *testing.T) {
 	t.Parallel()
 	// Test pebble, but only on 64-bit platforms
@@ -173,6 +173,15 @@ func TestCustomBackend(t *testing.T) {
 			initArgs:   []string{"--db.engine", "pebble"},
 			execExpect: "0x0000000000001338",
 		},
+		{ // Explicit bolt
+			initArgs:   []string{"--db.engine", "bolt"},
+			execArgs:   []string{"--db.engine", "bolt"},
+			execExpect: "0x0000000000001338",
+		},
+		{ // Explicit bolt, then auto-discover
+			initArgs:   []string{"--db.engine", "bolt"},
+			execExpect: "0x0000000000001338",
+		},
 		{ // Can't start pebble on top of leveldb
 			initArgs:   []string{"--db.engine", "leveldb"},
 			execArgs:   []string{"--db.engine", "pebble"},
@@ -185,7 +194,7 @@ func TestCustomBackend(t *testing.T) {
 		},
 		{ // Reject invalid backend choice
 			initArgs:   []string{"--db.engine", "mssql"},
-			initExpect: `Fatal: Invalid choice for db.engine 'mssql', allowed 'leveldb' or 'pebble'`,
+			initExpect: `Fatal: Invalid choice for db.engine 'mssql', allowed 'bolt', 'leveldb', 'pebble'`,
 			// Since the init fails, this will return the (default) mainnet genesis
 			// block nonce
 			execExpect: `0x0000000000000042`,
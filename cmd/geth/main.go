@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/internal/debug"
 	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/internal/telemetry"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
@@ -88,6 +89,7 @@ var (
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag, // deprecated
 		utils.TransactionHistoryFlag,
+		utils.LogHistoryFlag,
 		utils.StateHistoryFlag,
 		utils.LightServeFlag,    // deprecated
 		utils.LightIngressFlag,  // deprecated
@@ -113,8 +115,10 @@ var (
 		utils.CryptoKZGFlag,
 		utils.ListenPortFlag,
 		utils.DiscoveryPortFlag,
+		utils.QUICPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.TxPropagationFlag,
 		utils.MiningEnabledFlag, // deprecated
 		utils.MinerGasLimitFlag,
 		utils.MinerGasPriceFlag,
@@ -174,6 +178,8 @@ var (
 		utils.GraphQLVirtualHostsFlag,
 		utils.HTTPApiFlag,
 		utils.HTTPPathPrefixFlag,
+		utils.HTTPConnectFlag,
+		utils.HTTPTraceStreamFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
@@ -187,8 +193,17 @@ var (
 		utils.RPCGlobalEVMTimeoutFlag,
 		utils.RPCGlobalTxFeeCapFlag,
 		utils.AllowUnprotectedTxs,
+		utils.DBWriteAPIFlag,
 		utils.BatchRequestLimit,
 		utils.BatchResponseMaxSize,
+		utils.RPCRateLimitFlag,
+		utils.RPCRateLimitKeyHeaderFlag,
+		utils.RPCRateLimitKeyFileFlag,
+		utils.RPCResponseCacheFlag,
+		utils.RPCPolicyFlag,
+		utils.RPCAuditLogFlag,
+		utils.RPCAuditNamespacesFlag,
+		utils.RPCAuditMaxSizeFlag,
 	}
 
 	metricsFlags = []cli.Flag{
@@ -206,6 +221,10 @@ var (
 		utils.MetricsInfluxDBTokenFlag,
 		utils.MetricsInfluxDBBucketFlag,
 		utils.MetricsInfluxDBOrganizationFlag,
+		utils.TracingEnabledFlag,
+		utils.TracingEndpointFlag,
+		utils.TracingServiceNameFlag,
+		utils.TracingSampleRatioFlag,
 	}
 )
 
@@ -246,6 +265,8 @@ func init() {
 		snapshotCommand,
 		// See verkle.go
 		verkleCommand,
+		// See witnesscmd.go
+		witnessCommand,
 	}
 	if logTestCommand != nil {
 		app.Commands = append(app.Commands, logTestCommand)
@@ -330,6 +351,7 @@ func prepare(ctx *cli.Context) {
 
 	// Start metrics export if enabled
 	utils.SetupMetrics(ctx)
+	utils.SetupTracing(ctx)
 
 	// Start system runtime metrics collection
 	go metrics.CollectProcessMetrics(3 * time.Second)
@@ -344,6 +366,8 @@ func geth(ctx *cli.Context) error {
 	}
 
 	prepare(ctx)
+	defer telemetry.Disable()
+
 	stack := makeFullNode(ctx)
 	defer stack.Close()
 
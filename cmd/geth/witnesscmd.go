@@ -0,0 +1,157 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	witnessStateRootFlag = &cli.StringFlag{
+		Name:  "stateroot",
+		Usage: "Expected post state root of the block, verified if set",
+	}
+	witnessReceiptRootFlag = &cli.StringFlag{
+		Name:  "receiptroot",
+		Usage: "Expected receipt root of the block, verified if set",
+	}
+
+	witnessCommand = &cli.Command{
+		Name:  "witness",
+		Usage: "A set of commands operating on execution witnesses",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "verify",
+				Usage:     "Statelessly execute a block from its execution witness",
+				ArgsUsage: "<witness file>",
+				Action:    verifyWitness,
+				Flags: flags.Merge(utils.NetworkFlags, utils.DatabaseFlags,
+					[]cli.Flag{witnessStateRootFlag, witnessReceiptRootFlag}),
+				Description: `
+geth witness verify [--stateroot <root>] [--receiptroot <root>] <witness file>
+
+This command executes the block contained in an execution witness, as returned
+by debug_executionWitness (JSON) or debug_getRawExecutionWitness (RLP, either
+binary or hex encoded), without access to any state. The computed post state
+and receipt roots are printed and, if the corresponding flags are set, checked
+against the expected ones.
+
+The chain configuration is taken from the selected network preset, or from the
+database in the data directory. Mainnet is assumed if neither is available.
+`,
+			},
+		},
+	}
+)
+
+// verifyWitness statelessly executes the block contained in a witness file.
+func verifyWitness(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	blob, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	witness, err := decodeWitness(blob)
+	if err != nil {
+		return fmt.Errorf("failed to decode witness: %v", err)
+	}
+	config := witnessChainConfig(ctx)
+
+	receiptRoot, stateRoot, err := core.ExecuteStateless(config, witness)
+	if err != nil {
+		return fmt.Errorf("stateless execution failed: %v", err)
+	}
+	log.Info("Executed block statelessly", "number", witness.Block.Number(), "txs", len(witness.Block.Transactions()),
+		"stateroot", stateRoot, "receiptroot", receiptRoot)
+
+	if ctx.IsSet(witnessStateRootFlag.Name) {
+		if want := common.HexToHash(ctx.String(witnessStateRootFlag.Name)); want != stateRoot {
+			return fmt.Errorf("state root mismatch: have %x, want %x", stateRoot, want)
+		}
+	}
+	if ctx.IsSet(witnessReceiptRootFlag.Name) {
+		if want := common.HexToHash(ctx.String(witnessReceiptRootFlag.Name)); want != receiptRoot {
+			return fmt.Errorf("receipt root mismatch: have %x, want %x", receiptRoot, want)
+		}
+	}
+	return nil
+}
+
+// decodeWitness parses a witness in either of the encodings served over RPC.
+func decodeWitness(blob []byte) (*stateless.Witness, error) {
+	blob = bytes.TrimSpace(blob)
+	if len(blob) == 0 {
+		return nil, errors.New("empty witness")
+	}
+	witness := new(stateless.Witness)
+	switch {
+	case blob[0] == '{':
+		if err := json.Unmarshal(blob, witness); err != nil {
+			return nil, err
+		}
+	case blob[0] == '"' || bytes.HasPrefix(blob, []byte("0x")):
+		raw := common.FromHex(string(bytes.Trim(blob, `"`)))
+		if err := rlp.DecodeBytes(raw, witness); err != nil {
+			return nil, err
+		}
+	default:
+		if err := rlp.DecodeBytes(blob, witness); err != nil {
+			return nil, err
+		}
+	}
+	return witness, nil
+}
+
+// witnessChainConfig resolves the chain configuration to execute the witness
+// with.
+func witnessChainConfig(ctx *cli.Context) *params.ChainConfig {
+	if utils.IsNetworkPreset(ctx) {
+		return utils.MakeGenesis(ctx).Config
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db, err := stack.OpenDatabase("chaindata", 0, 0, "", true)
+	if err != nil {
+		log.Warn("No chain database found, assuming mainnet", "err", err)
+		return params.MainnetChainConfig
+	}
+	defer db.Close()
+
+	if config := rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0)); config != nil {
+		return config
+	}
+	log.Warn("No chain config found in database, assuming mainnet")
+	return params.MainnetChainConfig
+}
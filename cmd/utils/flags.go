@@ -31,6 +31,7 @@ import (
 	"os"
 	"path/filepath"
 	godebug "runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -60,6 +61,7 @@ import (
 	"github.com/ethereum/go-ethereum/graphql"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/internal/telemetry"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/metrics/exp"
@@ -100,9 +102,14 @@ var (
 		Usage:    "URL for remote database",
 		Category: flags.LoggingCategory,
 	}
+	RemoteDBJWTSecretFlag = &cli.StringFlag{
+		Name:     "remotedb.jwtsecret",
+		Usage:    "Path to the JWT secret to authenticate with the remote database (needed for writes over HTTP/WS)",
+		Category: flags.LoggingCategory,
+	}
 	DBEngineFlag = &cli.StringFlag{
 		Name:     "db.engine",
-		Usage:    "Backing database implementation to use ('pebble' or 'leveldb')",
+		Usage:    fmt.Sprintf("Backing database implementation to use (%s)", quoteEngines()),
 		Value:    node.DefaultConfig.DBEngine,
 		Category: flags.EthCategory,
 	}
@@ -279,6 +286,12 @@ var (
 		Value:    ethconfig.Defaults.TransactionHistory,
 		Category: flags.StateCategory,
 	}
+	LogHistoryFlag = &cli.Uint64Flag{
+		Name:     "history.logs",
+		Usage:    "Number of recent blocks to maintain log index for (default = entire chain)",
+		Value:    ethconfig.Defaults.LogHistory,
+		Category: flags.StateCategory,
+	}
 	// Beacon client light sync settings
 	BeaconApiFlag = &cli.StringSliceFlag{
 		Name:     "beacon.api",
@@ -666,6 +679,16 @@ var (
 		Value:    "",
 		Category: flags.APICategory,
 	}
+	HTTPConnectFlag = &cli.BoolFlag{
+		Name:     "http.connect",
+		Usage:    "Enable the binary Connect transport on the HTTP-RPC servers (supports HTTP/2 and subscriptions)",
+		Category: flags.APICategory,
+	}
+	HTTPTraceStreamFlag = &cli.BoolFlag{
+		Name:     "http.tracestream",
+		Usage:    "Serve streaming chain traces on the HTTP-RPC server (requires the debug API, bypasses RPC rate limits, method policies and audit logging)",
+		Category: flags.APICategory,
+	}
 	GraphQLEnabledFlag = &cli.BoolFlag{
 		Name:     "graphql",
 		Usage:    "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -745,11 +768,58 @@ var (
 		Value:    node.DefaultConfig.BatchResponseMaxSize,
 		Category: flags.APICategory,
 	}
+	RPCRateLimitFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit",
+		Usage:    "Comma separated per client method call limits on the public HTTP and WS endpoints (e.g. 'eth_getLogs=10:20,debug_trace*=1:2' for rate per second and burst)",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitKeyHeaderFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.keyheader",
+		Usage:    "HTTP header carrying the API key to identify clients by for rate limiting, requires --rpc.ratelimit.keyfile (default: identify by IP address)",
+		Category: flags.APICategory,
+	}
+	RPCRateLimitKeyFileFlag = &cli.StringFlag{
+		Name:     "rpc.ratelimit.keyfile",
+		Usage:    "File containing the API keys accepted in the rate limit key header, one per line",
+		Category: flags.APICategory,
+	}
+	RPCResponseCacheFlag = &cli.IntFlag{
+		Name:     "rpc.responsecache",
+		Usage:    "Megabytes of memory allocated to caching RPC results derived from finalized blocks (0 = disabled)",
+		Category: flags.APICategory,
+	}
+	RPCPolicyFlag = &flags.DirectoryFlag{
+		Name:     "rpc.policy",
+		Usage:    "Path of the JSON file restricting the methods and parameters allowed on the HTTP and WebSocket endpoints",
+		Category: flags.APICategory,
+	}
+	RPCAuditLogFlag = &flags.DirectoryFlag{
+		Name:     "rpc.audit",
+		Usage:    "Path of the append-only log recording the calls of privileged RPC methods (relative to the instance directory)",
+		Category: flags.APICategory,
+	}
+	RPCAuditNamespacesFlag = &cli.StringFlag{
+		Name:     "rpc.audit.namespaces",
+		Usage:    "Comma separated list of namespaces and methods recorded in the RPC audit log",
+		Value:    strings.Join(node.DefaultAuditNamespaces, ","),
+		Category: flags.APICategory,
+	}
+	RPCAuditMaxSizeFlag = &cli.IntFlag{
+		Name:     "rpc.audit.maxsize",
+		Usage:    "Size in megabytes at which the RPC audit log is rotated",
+		Value:    node.DefaultConfig.RPCAuditMaxSize,
+		Category: flags.APICategory,
+	}
 	EnablePersonal = &cli.BoolFlag{
 		Name:     "rpc.enabledeprecatedpersonal",
 		Usage:    "Enables the (deprecated) personal namespace",
 		Category: flags.APICategory,
 	}
+	DBWriteAPIFlag = &cli.BoolFlag{
+		Name:     "rpc.dbwrite",
+		Usage:    "Enables the raw database write methods in the debug namespace (IPC and authenticated endpoints only)",
+		Category: flags.APICategory,
+	}
 
 	// Network Settings
 	MaxPeersFlag = &cli.IntFlag{
@@ -764,6 +834,12 @@ var (
 		Value:    node.DefaultConfig.P2P.MaxPendingPeers,
 		Category: flags.NetworkingCategory,
 	}
+	TxPropagationFlag = &cli.IntFlag{
+		Name:     "txpropagation",
+		Usage:    "Number of recent transactions to record the network propagation of (0 = disabled)",
+		Value:    ethconfig.Defaults.TxPropagation,
+		Category: flags.NetworkingCategory,
+	}
 	ListenPortFlag = &cli.IntFlag{
 		Name:     "port",
 		Usage:    "Network listening port",
@@ -827,6 +903,11 @@ var (
 		Value:    30303,
 		Category: flags.NetworkingCategory,
 	}
+	QUICPortFlag = &cli.IntFlag{
+		Name:     "quic.port",
+		Usage:    "Experimental: UDP port for RLPx connections over QUIC (disabled if not set)",
+		Category: flags.NetworkingCategory,
+	}
 
 	// Console
 	JSpathFlag = &flags.DirectoryFlag{
@@ -956,6 +1037,31 @@ Please note that --` + MetricsHTTPFlag.Name + ` must be set to start the server.
 		Value:    metrics.DefaultConfig.InfluxDBOrganization,
 		Category: flags.MetricsCategory,
 	}
+
+	// Tracing flags
+	TracingEnabledFlag = &cli.BoolFlag{
+		Name:     "tracing",
+		Usage:    "Enable tracing of RPC requests, exported to an OpenTelemetry collector",
+		Category: flags.MetricsCategory,
+	}
+	TracingEndpointFlag = &cli.StringFlag{
+		Name:     "tracing.endpoint",
+		Usage:    "OTLP/HTTP traces endpoint of the OpenTelemetry collector",
+		Value:    telemetry.DefaultConfig.Endpoint,
+		Category: flags.MetricsCategory,
+	}
+	TracingServiceNameFlag = &cli.StringFlag{
+		Name:     "tracing.service",
+		Usage:    "Service name reported to the OpenTelemetry collector",
+		Value:    telemetry.DefaultConfig.ServiceName,
+		Category: flags.MetricsCategory,
+	}
+	TracingSampleRatioFlag = &cli.Float64Flag{
+		Name:     "tracing.sampleratio",
+		Usage:    "Fraction of the traces started by the node to record (traces propagated by callers follow the caller's decision)",
+		Value:    telemetry.DefaultConfig.SampleRatio,
+		Category: flags.MetricsCategory,
+	}
 )
 
 var (
@@ -972,6 +1078,7 @@ var (
 		DataDirFlag,
 		AncientFlag,
 		RemoteDBFlag,
+		RemoteDBJWTSecretFlag,
 		DBEngineFlag,
 		StateSchemeFlag,
 		HttpHeaderFlag,
@@ -1102,6 +1209,9 @@ func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.IsSet(DiscoveryPortFlag.Name) {
 		cfg.DiscAddr = fmt.Sprintf(":%d", ctx.Int(DiscoveryPortFlag.Name))
 	}
+	if ctx.IsSet(QUICPortFlag.Name) {
+		cfg.QUICAddr = fmt.Sprintf(":%d", ctx.Int(QUICPortFlag.Name))
+	}
 }
 
 // setNAT creates a port mapper from command line flags.
@@ -1170,6 +1280,12 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(HTTPPathPrefixFlag.Name) {
 		cfg.HTTPPathPrefix = ctx.String(HTTPPathPrefixFlag.Name)
 	}
+	if ctx.IsSet(HTTPConnectFlag.Name) {
+		cfg.HTTPConnect = ctx.Bool(HTTPConnectFlag.Name)
+	}
+	if ctx.IsSet(HTTPTraceStreamFlag.Name) {
+		cfg.HTTPTraceStream = ctx.Bool(HTTPTraceStreamFlag.Name)
+	}
 	if ctx.IsSet(AllowUnprotectedTxs.Name) {
 		cfg.AllowUnprotectedTxs = ctx.Bool(AllowUnprotectedTxs.Name)
 	}
@@ -1181,6 +1297,73 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.IsSet(BatchResponseMaxSize.Name) {
 		cfg.BatchResponseMaxSize = ctx.Int(BatchResponseMaxSize.Name)
 	}
+
+	if ctx.IsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCRateLimits = parseRPCRateLimits(ctx.String(RPCRateLimitFlag.Name))
+	}
+	if ctx.IsSet(RPCRateLimitKeyHeaderFlag.Name) {
+		cfg.RPCRateLimitKeyHeader = ctx.String(RPCRateLimitKeyHeaderFlag.Name)
+	}
+	if ctx.IsSet(RPCRateLimitKeyFileFlag.Name) {
+		cfg.RPCRateLimitKeys = readRPCRateLimitKeys(ctx.String(RPCRateLimitKeyFileFlag.Name))
+	}
+	if ctx.IsSet(RPCResponseCacheFlag.Name) {
+		cfg.RPCResponseCacheSize = ctx.Int(RPCResponseCacheFlag.Name) * 1024 * 1024
+	}
+	if ctx.IsSet(RPCPolicyFlag.Name) {
+		cfg.RPCPolicyFile = ctx.String(RPCPolicyFlag.Name)
+	}
+	if ctx.IsSet(RPCAuditLogFlag.Name) {
+		cfg.RPCAuditLog = ctx.String(RPCAuditLogFlag.Name)
+	}
+	if ctx.IsSet(RPCAuditNamespacesFlag.Name) {
+		cfg.RPCAuditNamespaces = SplitAndTrim(ctx.String(RPCAuditNamespacesFlag.Name))
+	}
+	if ctx.IsSet(RPCAuditMaxSizeFlag.Name) {
+		cfg.RPCAuditMaxSize = ctx.Int(RPCAuditMaxSizeFlag.Name)
+	}
+}
+
+// parseRPCRateLimits parses a comma separated list of method=rate:burst entries
+// into RPC rate limits.
+func parseRPCRateLimits(spec string) []rpc.RateLimit {
+	var limits []rpc.RateLimit
+	for _, entry := range SplitAndTrim(spec) {
+		method, limit, ok := strings.Cut(entry, "=")
+		if !ok {
+			Fatalf("Invalid rate limit entry: %s", entry)
+		}
+		rateStr, burstStr, ok := strings.Cut(limit, ":")
+		if !ok {
+			Fatalf("Invalid rate limit entry: %s", entry)
+		}
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			Fatalf("Invalid rate limit rate %s: %v", rateStr, err)
+		}
+		burst, err := strconv.Atoi(burstStr)
+		if err != nil {
+			Fatalf("Invalid rate limit burst %s: %v", burstStr, err)
+		}
+		limits = append(limits, rpc.RateLimit{Method: method, Rate: rate, Burst: burst})
+	}
+	return limits
+}
+
+// readRPCRateLimitKeys reads the API keys accepted for rate limiting from a file,
+// one per line. Empty lines are ignored.
+func readRPCRateLimitKeys(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		Fatalf("Failed to read rate limit key file: %v", err)
+	}
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if key := strings.TrimSpace(line); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
 
 // setGraphQL creates the GraphQL listener interface string from the set
@@ -1433,8 +1616,8 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 	if ctx.IsSet(DBEngineFlag.Name) {
 		dbEngine := ctx.String(DBEngineFlag.Name)
-		if dbEngine != "leveldb" && dbEngine != "pebble" {
-			Fatalf("Invalid choice for db.engine '%s', allowed 'leveldb' or 'pebble'", dbEngine)
+		if _, ok := ethdb.LookupEngine(dbEngine); !ok {
+			Fatalf("Invalid choice for db.engine '%s', allowed %s", dbEngine, quoteEngines())
 		}
 		log.Info(fmt.Sprintf("Using %s as db engine", dbEngine))
 		cfg.DBEngine = dbEngine
@@ -1448,6 +1631,16 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// quoteEngines returns the names of all registered database engines in a human
+// readable list.
+func quoteEngines() string {
+	names := ethdb.Engines()
+	for i, name := range names {
+		names[i] = "'" + name + "'"
+	}
+	return strings.Join(names, ", ")
+}
+
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
 	// Skip enabling smartcards if no path is set
 	path := ctx.String(SmartCardDaemonPathFlag.Name)
@@ -1685,6 +1878,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.Uint64(NetworkIdFlag.Name)
 	}
+	if ctx.IsSet(TxPropagationFlag.Name) {
+		cfg.TxPropagation = ctx.Int(TxPropagationFlag.Name)
+	}
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheDatabaseFlag.Name) {
 		cfg.DatabaseCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheDatabaseFlag.Name) / 100
 	}
@@ -1726,6 +1922,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		log.Warn("The flag --txlookuplimit is deprecated and will be removed, please use --history.transactions")
 		cfg.TransactionHistory = ctx.Uint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.IsSet(LogHistoryFlag.Name) {
+		cfg.LogHistory = ctx.Uint64(LogHistoryFlag.Name)
+	}
 	if ctx.String(GCModeFlag.Name) == "archive" && cfg.TransactionHistory != 0 {
 		cfg.TransactionHistory = 0
 		log.Warn("Disabled transaction unindexing for archive node")
@@ -1940,6 +2139,12 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 		Fatalf("Failed to register the Ethereum service: %v", err)
 	}
 	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+
+	// Serve streaming chain traces over plain HTTP if explicitly enabled and the
+	// tracers are exposed there
+	if stack.Config().HTTPTraceStream && slices.Contains(stack.Config().HTTPModules, "debug") {
+		tracers.RegisterStreamHandler(stack, backend.APIBackend)
+	}
 	return backend.APIBackend, backend
 }
 
@@ -1970,6 +2175,34 @@ func RegisterFilterAPI(stack *node.Node, backend ethapi.Backend, ethcfg *ethconf
 	return filterSystem
 }
 
+// RegisterDBWriteAPI adds the raw database write methods to the debug namespace.
+// The API requires authentication, thus only being reachable over IPC and the
+// JWT-guarded endpoints.
+func RegisterDBWriteAPI(stack *node.Node, backend ethapi.Backend) {
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace:     "debug",
+		Service:       ethapi.NewDBWriteAPI(backend),
+		Authenticated: true,
+	}})
+	log.Warn("Enabled raw database write access via RPC")
+}
+
+// loadJWTSecret reads a hex encoded 32 byte JWT secret from the given file.
+// Contrary to node.ObtainJWTSecret, a missing secret is not generated.
+func loadJWTSecret(fileName string) ([32]byte, error) {
+	var secret [32]byte
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return secret, err
+	}
+	blob := common.FromHex(strings.TrimSpace(string(data)))
+	if len(blob) != len(secret) {
+		return secret, fmt.Errorf("invalid JWT secret length: have %d, want %d", len(blob), len(secret))
+	}
+	copy(secret[:], blob)
+	return secret, nil
+}
+
 // RegisterFullSyncTester adds the full-sync tester service into node.
 func RegisterFullSyncTester(stack *node.Node, eth *eth.Ethereum, target common.Hash) {
 	catalyst.RegisterFullSyncTester(stack, eth, target)
@@ -2037,6 +2270,22 @@ func SetupMetrics(ctx *cli.Context) {
 	}
 }
 
+// SetupTracing enables the tracing of RPC requests if requested by the flags.
+func SetupTracing(ctx *cli.Context) {
+	if !ctx.Bool(TracingEnabledFlag.Name) {
+		return
+	}
+	config := telemetry.Config{
+		Endpoint:    ctx.String(TracingEndpointFlag.Name),
+		ServiceName: ctx.String(TracingServiceNameFlag.Name),
+		SampleRatio: ctx.Float64(TracingSampleRatioFlag.Name),
+	}
+	if err := telemetry.Enable(config); err != nil {
+		Fatalf("Failed to enable tracing: %v", err)
+	}
+	log.Info("Enabling RPC tracing", "endpoint", config.Endpoint, "ratio", config.SampleRatio)
+}
+
 func SplitTagsFlag(tagsFlag string) map[string]string {
 	tags := strings.Split(tagsFlag, ",")
 	tagsMap := map[string]string{}
@@ -2064,12 +2313,24 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.
 	)
 	switch {
 	case ctx.IsSet(RemoteDBFlag.Name):
-		log.Info("Using remote db", "url", ctx.String(RemoteDBFlag.Name), "headers", len(ctx.StringSlice(HttpHeaderFlag.Name)))
-		client, err := DialRPCWithHeaders(ctx.String(RemoteDBFlag.Name), ctx.StringSlice(HttpHeaderFlag.Name))
+		log.Info("Using remote db", "url", ctx.String(RemoteDBFlag.Name), "headers", len(ctx.StringSlice(HttpHeaderFlag.Name)), "readonly", readonly)
+		var opts []rpc.ClientOption
+		if ctx.IsSet(RemoteDBJWTSecretFlag.Name) {
+			secret, err := loadJWTSecret(ctx.String(RemoteDBJWTSecretFlag.Name))
+			if err != nil {
+				Fatalf("Could not load remote db JWT secret: %v", err)
+			}
+			opts = append(opts, rpc.WithHTTPAuth(node.NewJWTAuth(secret)))
+		}
+		client, err := DialRPCWithHeaders(ctx.String(RemoteDBFlag.Name), ctx.StringSlice(HttpHeaderFlag.Name), opts...)
 		if err != nil {
 			break
 		}
-		chainDb = remotedb.New(client)
+		if readonly {
+			chainDb = remotedb.New(client)
+		} else {
+			chainDb = remotedb.NewReadWrite(client)
+		}
 	case ctx.String(SyncModeFlag.Name) == "light":
 		chainDb, err = stack.OpenDatabase("lightchaindata", cache, handles, "", readonly)
 	default:
@@ -2103,7 +2364,7 @@ func IsNetworkPreset(ctx *cli.Context) bool {
 	return false
 }
 
-func DialRPCWithHeaders(endpoint string, headers []string) (*rpc.Client, error) {
+func DialRPCWithHeaders(endpoint string, headers []string, opts ...rpc.ClientOption) (*rpc.Client, error) {
 	if endpoint == "" {
 		return nil, errors.New("endpoint must be specified")
 	}
@@ -2112,7 +2373,6 @@ func DialRPCWithHeaders(endpoint string, headers []string) (*rpc.Client, error)
 		// these prefixes.
 		endpoint = endpoint[4:]
 	}
-	var opts []rpc.ClientOption
 	if len(headers) > 0 {
 		customHeaders := make(http.Header)
 		for _, h := range headers {
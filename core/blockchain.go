@@ -1997,6 +1997,44 @@ func (bc *BlockChain) processBlock(block *types.Block, statedb *state.StateDB, s
 	return &blockProcessingResult{usedGas: usedGas, procTime: proctime, status: status}, nil
 }
 
+// GenerateWitness re-executes an already imported block on top of its parent
+// state and returns the stateless witness collected during the execution. The
+// witness is cross validated before being returned.
+func (bc *BlockChain) GenerateWitness(block *types.Block) (*stateless.Witness, error) {
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	statedb, err := state.New(parent.Root, bc.stateCache, bc.snaps)
+	if err != nil {
+		return nil, err
+	}
+	witness, err := stateless.NewWitness(bc, block)
+	if err != nil {
+		return nil, err
+	}
+	statedb.StartPrefetcher("witness", witness)
+	defer statedb.StopPrefetcher()
+
+	// Disable tracing for the re-execution, it's not part of block import.
+	vmCfg := bc.vmConfig
+	vmCfg.Tracer = nil
+
+	receipts, _, usedGas, err := bc.processor.Process(block, statedb, vmCfg)
+	if err != nil {
+		return nil, err
+	}
+	// Validating the state hashes the tries, pulling the touched nodes into
+	// the witness.
+	if err := bc.validator.ValidateState(block, statedb, receipts, usedGas, false); err != nil {
+		return nil, err
+	}
+	if err := bc.validator.ValidateWitness(witness, block.ReceiptHash(), block.Root()); err != nil {
+		return nil, fmt.Errorf("cross verification failed: %v", err)
+	}
+	return witness, nil
+}
+
 // insertSideChain is called when an import batch hits upon a pruned ancestor
 // error, which happens when a sidechain with a sufficiently old fork-block is
 // found.
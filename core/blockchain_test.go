@@ -33,12 +33,14 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers/logger"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/holiman/uint256"
 )
@@ -4220,3 +4222,72 @@ func TestEIP3651(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+// Tests that a witness generated for an already imported block can be used to
+// statelessly re-execute it, both directly and after an RLP round trip.
+func TestGenerateWitness(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		// Stores the hash of the previous block, keyed by the current number.
+		contract = common.HexToAddress("0x000000000000000000000000000000000000c0de")
+		code     = []byte{
+			byte(vm.PUSH1), 0x1, byte(vm.NUMBER), byte(vm.SUB), byte(vm.BLOCKHASH),
+			byte(vm.NUMBER), byte(vm.SSTORE), byte(vm.STOP),
+		}
+		gspec = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address:  {Balance: big.NewInt(params.Ether)},
+				contract: {Code: code},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 3, func(i int, b *BlockGen) {
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{
+			Nonce:    uint64(i),
+			GasPrice: b.header.BaseFee,
+			Gas:      100000,
+			To:       &contract,
+		})
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert into chain: %v", err)
+	}
+	block := blocks[len(blocks)-1]
+	witness, err := chain.GenerateWitness(block)
+	if err != nil {
+		t.Fatalf("failed to generate witness: %v", err)
+	}
+	if len(witness.Codes) != 1 {
+		t.Errorf("witness code count mismatch: have %d, want 1", len(witness.Codes))
+	}
+	blob, err := rlp.EncodeToBytes(witness)
+	if err != nil {
+		t.Fatalf("failed to encode witness: %v", err)
+	}
+	decoded := new(stateless.Witness)
+	if err := rlp.DecodeBytes(blob, decoded); err != nil {
+		t.Fatalf("failed to decode witness: %v", err)
+	}
+	for i, w := range []*stateless.Witness{witness, decoded} {
+		receiptRoot, stateRoot, err := ExecuteStateless(gspec.Config, w)
+		if err != nil {
+			t.Fatalf("witness %d: stateless execution failed: %v", i, err)
+		}
+		if receiptRoot != block.ReceiptHash() {
+			t.Errorf("witness %d: receipt root mismatch: have %x, want %x", i, receiptRoot, block.ReceiptHash())
+		}
+		if stateRoot != block.Root() {
+			t.Errorf("witness %d: state root mismatch: have %x, want %x", i, stateRoot, block.Root())
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// logIndexThrottling is the time to wait between processing two consecutive
+	// log index sections. It's useful during chain upgrades to prevent disk overload.
+	logIndexThrottling = 100 * time.Millisecond
+)
+
+// LogIndexer implements a core.ChainIndexer, building up an inverted index from
+// log addresses and topics to the positions of the logs referencing them. Unlike
+// the bloombits, the index is exact and permits filtering logs across long block
+// ranges without false positives.
+type LogIndexer struct {
+	size    uint64         // section size to generate the log index for
+	history uint64         // number of recent blocks to keep indexed, 0 for the entire chain
+	db      ethdb.Database // database instance to write index data and metadata into
+	batch   ethdb.Batch    // batch accumulating the postings of the current section
+	section uint64         // Section is the section number being processed currently
+	skip    bool           // Flag whether the section is below the history cutoff
+}
+
+// NewLogIndexer returns a chain indexer that generates the log index for the
+// canonical chain for fast logs filtering. If history is non-zero, only logs of
+// the most recent history blocks are retained in the index.
+func NewLogIndexer(db ethdb.Database, size, confirms, history uint64) *ChainIndexer {
+	backend := &LogIndexer{
+		db:      db,
+		size:    size,
+		history: history,
+	}
+	table := rawdb.NewTable(db, string(rawdb.LogIndexPrefix))
+
+	return NewChainIndexer(db, table, backend, size, confirms, logIndexThrottling, "logindex")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new log index section.
+// Any postings left behind by a previous run of the same section (interrupted
+// or reorged) are dropped.
+func (l *LogIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	l.section, l.batch = section, l.db.NewBatch()
+	l.skip = (section+1)*l.size <= l.cutoff()
+
+	rawdb.DeleteLogIndex(l.db, section, section+1)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the logs of a new header
+// into the index.
+func (l *LogIndexer) Process(ctx context.Context, header *types.Header) error {
+	if l.skip || header.Bloom == (types.Bloom{}) {
+		return nil
+	}
+	var (
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+	)
+	logs := rawdb.ReadLogs(l.db, hash, number)
+	if logs == nil {
+		return fmt.Errorf("receipts of block #%d [%x..] not found", number, hash[:4])
+	}
+	var index uint32
+	for txIndex, txLogs := range logs {
+		for _, log := range txLogs {
+			entry := rawdb.LogIndexEntry{Number: number, TxIndex: uint32(txIndex), LogIndex: index}
+
+			rawdb.WriteLogIndexEntry(l.batch, l.section, 0, log.Address.Bytes(), entry)
+			for i, topic := range log.Topics {
+				rawdb.WriteLogIndexEntry(l.batch, l.section, byte(i+1), topic.Bytes(), entry)
+			}
+			index++
+		}
+	}
+	// The section is only marked valid after commit, it's safe to flush early
+	if l.batch.ValueSize() >= ethdb.IdealBatchSize {
+		if err := l.batch.Write(); err != nil {
+			return err
+		}
+		l.batch.Reset()
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, finalizing the log index section
+// and unindexing any sections which fell below the history cutoff.
+func (l *LogIndexer) Commit() error {
+	// Track the first indexed block, sections below the history cutoff are
+	// unindexed right after by moving the tail forward.
+	if tail := rawdb.ReadLogIndexTail(l.db); tail == nil || (!l.skip && *tail > l.section*l.size) {
+		rawdb.WriteLogIndexTail(l.batch, l.section*l.size)
+	}
+	if err := l.batch.Write(); err != nil {
+		return err
+	}
+	return l.Prune(l.cutoff())
+}
+
+// Prune implements core.ChainIndexerBackend, deleting all log index sections
+// entirely below the given threshold.
+func (l *LogIndexer) Prune(threshold uint64) error {
+	tail := rawdb.ReadLogIndexTail(l.db)
+	if tail == nil {
+		return nil
+	}
+	limit := threshold / l.size
+	if limit*l.size <= *tail {
+		return nil
+	}
+	// Move the tail first, so that filters don't look up deleted sections
+	rawdb.WriteLogIndexTail(l.db, limit*l.size)
+	rawdb.DeleteLogIndex(l.db, *tail/l.size, limit)
+
+	log.Debug("Unindexed logs", "from", *tail, "to", limit*l.size)
+	return nil
+}
+
+// cutoff returns the number of the first block which needs to be kept in the
+// log index, based on the configured history and the current chain head.
+func (l *LogIndexer) cutoff() uint64 {
+	if l.history == 0 {
+		return 0
+	}
+	number := rawdb.ReadHeaderNumber(l.db, rawdb.ReadHeadHeaderHash(l.db))
+	if number == nil || *number+1 <= l.history {
+		return 0
+	}
+	return *number + 1 - l.history
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the log indexer generates postings for log addresses and topics,
+// replaces the postings of reprocessed (reorged) sections and unindexes the
+// sections falling below the history cutoff.
+func TestLogIndexer(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		indexer = &LogIndexer{db: db, size: 4, history: 9}
+		addr1   = common.Address{0x01}
+		addr2   = common.Address{0x02}
+		topic   = common.Hash{0xaa}
+	)
+	// makeHeader creates a header with the given logs and stores its receipts
+	makeHeader := func(number uint64, logs ...*types.Log) *types.Header {
+		receipt := &types.Receipt{Logs: logs}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Bloom: receipt.Bloom}
+		rawdb.WriteReceipts(db, header.Hash(), number, types.Receipts{receipt})
+		return header
+	}
+	process := func(section uint64, headers ...*types.Header) {
+		if err := indexer.Reset(context.Background(), section, common.Hash{}); err != nil {
+			t.Fatalf("section %d: reset failed: %v", section, err)
+		}
+		for _, header := range headers {
+			if err := indexer.Process(context.Background(), header); err != nil {
+				t.Fatalf("section %d: process failed: %v", section, err)
+			}
+		}
+		if err := indexer.Commit(); err != nil {
+			t.Fatalf("section %d: commit failed: %v", section, err)
+		}
+	}
+	process(0,
+		makeHeader(0),
+		makeHeader(1, &types.Log{Address: addr1, Topics: []common.Hash{topic}}),
+		makeHeader(2),
+		makeHeader(3, &types.Log{Address: addr2}, &types.Log{Address: addr1}),
+	)
+	if have, want := rawdb.ReadLogIndexEntries(db, 0, 0, addr1.Bytes()), []rawdb.LogIndexEntry{{Number: 1}, {Number: 3, LogIndex: 1}}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("address postings mismatch: have %v, want %v", have, want)
+	}
+	if have, want := rawdb.ReadLogIndexEntries(db, 0, 1, topic.Bytes()), []rawdb.LogIndexEntry{{Number: 1}}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("topic postings mismatch: have %v, want %v", have, want)
+	}
+	if tail := rawdb.ReadLogIndexTail(db); tail == nil || *tail != 0 {
+		t.Fatalf("log index tail mismatch: have %v, want 0", tail)
+	}
+	// Reprocess the section with a different chain, stale postings must be gone
+	process(0, makeHeader(0), makeHeader(1), makeHeader(2, &types.Log{Address: addr2}), makeHeader(3))
+	if have := rawdb.ReadLogIndexEntries(db, 0, 0, addr1.Bytes()); len(have) != 0 {
+		t.Fatalf("stale postings left after reorg: %v", have)
+	}
+	if have, want := rawdb.ReadLogIndexEntries(db, 0, 0, addr2.Bytes()), []rawdb.LogIndexEntry{{Number: 2}}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("reorged postings mismatch: have %v, want %v", have, want)
+	}
+	// Advance the chain head beyond the history, the first section must be unindexed
+	head := makeHeader(13)
+	rawdb.WriteHeader(db, head)
+	rawdb.WriteHeadHeaderHash(db, head.Hash())
+
+	process(1, makeHeader(4), makeHeader(5, &types.Log{Address: addr1}), makeHeader(6), makeHeader(7))
+	if have := rawdb.ReadLogIndexEntries(db, 0, 0, addr2.Bytes()); len(have) != 0 {
+		t.Fatalf("postings left below history cutoff: %v", have)
+	}
+	if tail := rawdb.ReadLogIndexTail(db); tail == nil || *tail != 4 {
+		t.Fatalf("log index tail mismatch: have %v, want 4", tail)
+	}
+	if have, want := rawdb.ReadLogIndexEntries(db, 1, 0, addr1.Bytes()), []rawdb.LogIndexEntry{{Number: 5}}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("address postings mismatch: have %v, want %v", have, want)
+	}
+}
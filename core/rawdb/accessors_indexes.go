@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -145,6 +146,95 @@ func ReadReceipt(db ethdb.Reader, hash common.Hash, config *params.ChainConfig)
 	return nil, common.Hash{}, 0, 0
 }
 
+// LogIndexEntry is a posting of the log index, locating a log which references
+// the indexed address or topic.
+type LogIndexEntry struct {
+	Number   uint64 // Number of the block containing the log
+	TxIndex  uint32 // Index of the transaction emitting the log within the block
+	LogIndex uint32 // Index of the log within the block
+}
+
+// ReadLogIndexEntries retrieves all postings of the given log field and value
+// (address or topic) within the given section, ordered by log position.
+func ReadLogIndexEntries(db ethdb.Iteratee, section uint64, field byte, value []byte) []LogIndexEntry {
+	prefix := logIndexValueKey(section, field, value)
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	var entries []LogIndexEntry
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+16 {
+			continue
+		}
+		entries = append(entries, LogIndexEntry{
+			Number:   binary.BigEndian.Uint64(key[len(prefix):]),
+			TxIndex:  binary.BigEndian.Uint32(key[len(prefix)+8:]),
+			LogIndex: binary.BigEndian.Uint32(key[len(prefix)+12:]),
+		})
+	}
+	if it.Error() != nil {
+		log.Error("Failed to iterate log index", "section", section, "err", it.Error())
+	}
+	return entries
+}
+
+// WriteLogIndexEntry stores a posting of the log index for the given log field
+// and value within the given section. Field 0 denotes the log address, field
+// i+1 the i-th log topic.
+func WriteLogIndexEntry(db ethdb.KeyValueWriter, section uint64, field byte, value []byte, entry LogIndexEntry) {
+	if err := db.Put(logIndexKey(section, field, value, entry.Number, entry.TxIndex, entry.LogIndex), nil); err != nil {
+		log.Crit("Failed to store log index entry", "err", err)
+	}
+}
+
+// DeleteLogIndex removes all log index postings belonging to the given section
+// range.
+func DeleteLogIndex(db ethdb.Database, from uint64, to uint64) {
+	start, end := logIndexSectionKey(from), logIndexSectionKey(to)
+	it := db.NewIterator(nil, start)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		if bytes.Compare(it.Key(), end) >= 0 {
+			break
+		}
+		batch.Delete(it.Key())
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to delete log index", "err", err)
+			}
+			batch.Reset()
+		}
+	}
+	if it.Error() != nil {
+		log.Crit("Failed to delete log index", "err", it.Error())
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete log index", "err", err)
+	}
+}
+
+// ReadLogIndexTail retrieves the number of the oldest block whose logs have
+// been indexed.
+func ReadLogIndexTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(logIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteLogIndexTail stores the number of the oldest block whose logs have been
+// indexed into the database.
+func WriteLogIndexTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(logIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the log index tail", "err", err)
+	}
+}
+
 // ReadBloomBits retrieves the compressed bloom bit vector belonging to the given
 // section and bit index from the.
 func ReadBloomBits(db ethdb.KeyValueReader, bit uint, section uint64, head common.Hash) ([]byte, error) {
@@ -79,6 +79,9 @@ var (
 	// txIndexTailKey tracks the oldest block whose transactions have been indexed.
 	txIndexTailKey = []byte("TransactionIndexTail")
 
+	// logIndexTailKey tracks the oldest block whose logs have been indexed.
+	logIndexTailKey = []byte("LogIndexTail")
+
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	// This flag is deprecated, it's kept to avoid reporting errors when inspect
 	// database.
@@ -107,6 +110,7 @@ var (
 
 	txLookupPrefix        = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix       = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+	logIndexPrefix        = []byte("g") // logIndexPrefix + section (uint64 big endian) + field + value + num (uint64 big endian) + txIndex + logIndex -> nil
 	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
@@ -131,6 +135,9 @@ var (
 	// BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 	BloomBitsIndexPrefix = []byte("iB")
 
+	// LogIndexPrefix is the data table of the log indexer to track its progress
+	LogIndexPrefix = []byte("iL")
+
 	ChtPrefix           = []byte("chtRootV2-") // ChtPrefix + chtNum (uint64 big endian) -> trie root hash
 	ChtTablePrefix      = []byte("cht-")
 	ChtIndexTablePrefix = []byte("chtIndexV2-")
@@ -233,6 +240,27 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// logIndexSectionKey = logIndexPrefix + section (uint64 big endian)
+func logIndexSectionKey(section uint64) []byte {
+	return append(append([]byte{}, logIndexPrefix...), encodeBlockNumber(section)...)
+}
+
+// logIndexValueKey = logIndexPrefix + section (uint64 big endian) + field + value
+func logIndexValueKey(section uint64, field byte, value []byte) []byte {
+	return append(append(logIndexSectionKey(section), field), value...)
+}
+
+// logIndexKey = logIndexPrefix + section (uint64 big endian) + field + value + num (uint64 big endian) + txIndex (uint32 big endian) + logIndex (uint32 big endian)
+func logIndexKey(section uint64, field byte, value []byte, number uint64, txIndex, logIndex uint32) []byte {
+	key := append(logIndexValueKey(section, field, value), make([]byte, 16)...)
+
+	binary.BigEndian.PutUint64(key[len(key)-16:], number)
+	binary.BigEndian.PutUint32(key[len(key)-8:], txIndex)
+	binary.BigEndian.PutUint32(key[len(key)-4:], logIndex)
+
+	return key
+}
+
 // skeletonHeaderKey = skeletonHeaderPrefix + num (uint64 big endian)
 func skeletonHeaderKey(number uint64) []byte {
 	return append(skeletonHeaderPrefix, encodeBlockNumber(number)...)
@@ -334,6 +334,11 @@ func New(config Config, chain BlockChain) *BlobPool {
 	}
 }
 
+// Name implements txpool.SubPool, returning the identifier of the blob pool.
+func (p *BlobPool) Name() string {
+	return "blobpool"
+}
+
 // Filter returns whether the given transaction can be consumed by the blob pool.
 func (p *BlobPool) Filter(tx *types.Transaction) bool {
 	return tx.Type() == types.BlobTxType
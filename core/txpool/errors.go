@@ -60,4 +60,8 @@ var (
 	// input transaction of non-blob type when a blob transaction from this sender
 	// remains pending (and vice-versa).
 	ErrAlreadyReserved = errors.New("address already reserved")
+
+	// ErrPrivateNotSupported is returned if a transaction is submitted privately,
+	// but the subpool handling it cannot drop it individually on expiry.
+	ErrPrivateNotSupported = errors.New("private transactions not supported for type")
 )
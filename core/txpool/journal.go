@@ -0,0 +1,250 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// errJournalClosed is returned if a transaction is attempted to be inserted into
+// a journal which was already closed.
+var errJournalClosed = errors.New("journal closed")
+
+// JournalEntry is a locally submitted transaction tracked by the journal.
+type JournalEntry struct {
+	Time uint64             // Unix timestamp of the original submission
+	Tx   *types.Transaction // Transaction submitted, including any blob sidecar
+}
+
+// JournalReplay is the outcome of re-adding a journaled transaction into the
+// pool on startup.
+type JournalReplay struct {
+	Hash    common.Hash // Hash of the journaled transaction
+	Time    uint64      // Unix timestamp of the original submission
+	Subpool string      // Name of the subpool handling the transaction, empty if none
+	Error   error       // Validation error of the subpool, nil if re-admitted
+}
+
+// Journal is an append-only log of locally submitted transactions, shared by
+// all the subpools, with the aim of allowing non-executed ones to survive node
+// restarts in their original submission order.
+//
+// Every insertion is synced to disk before returning. If the node crashes mid-
+// write, the torn record is discarded on the next load. Stale entries are only
+// ever removed by rotation, which atomically replaces the entire file.
+type Journal struct {
+	path    string                   // Filesystem path to store the transactions at
+	file    *os.File                 // Output stream to append new transactions into
+	entries []*JournalEntry          // Live journal entries in submission order
+	known   map[common.Hash]struct{} // Hashes of the live entries to avoid duplicates
+	lock    sync.Mutex
+}
+
+// OpenJournal loads a transaction journal from disk, creating it if it doesn't
+// exist yet, and opens it for appending new transactions.
+func OpenJournal(path string) (*Journal, error) {
+	journal := &Journal{
+		path:  path,
+		known: make(map[common.Hash]struct{}),
+	}
+	blob, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	// Parse all the entries, stopping at the first torn or corrupted one
+	var offset int
+	for offset < len(blob) {
+		_, _, rest, err := rlp.Split(blob[offset:])
+		if err != nil {
+			log.Warn("Discarding corrupted transaction journal tail", "offset", offset, "size", len(blob), "err", err)
+			break
+		}
+		size := len(blob) - offset - len(rest)
+
+		entry := new(JournalEntry)
+		if err := rlp.DecodeBytes(blob[offset:offset+size], entry); err != nil {
+			// Journals written by the legacy pool contain bare transactions
+			tx := new(types.Transaction)
+			if rlp.DecodeBytes(blob[offset:offset+size], tx) != nil {
+				log.Warn("Discarding corrupted transaction journal tail", "offset", offset, "size", len(blob), "err", err)
+				break
+			}
+			entry.Tx = tx
+		}
+		offset += size
+
+		if _, ok := journal.known[entry.Tx.Hash()]; !ok {
+			journal.known[entry.Tx.Hash()] = struct{}{}
+			journal.entries = append(journal.entries, entry)
+		}
+	}
+	journal.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// Drop any unparsable tail so that new entries remain readable
+	if offset < len(blob) {
+		if err := journal.file.Truncate(int64(offset)); err != nil {
+			journal.file.Close()
+			return nil, err
+		}
+	}
+	if _, err := journal.file.Seek(int64(offset), 0); err != nil {
+		journal.file.Close()
+		return nil, err
+	}
+	return journal, nil
+}
+
+// Entries returns the live journal entries in submission order.
+func (j *Journal) Entries() []*JournalEntry {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	entries := make([]*JournalEntry, len(j.entries))
+	copy(entries, j.entries)
+	return entries
+}
+
+// Insert appends the given transactions to the journal, skipping any already
+// tracked ones. The journal is synced to disk before returning.
+func (j *Journal) Insert(txs []*types.Transaction) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return errJournalClosed
+	}
+	var (
+		now   = uint64(time.Now().Unix())
+		added []*JournalEntry
+		blob  []byte
+	)
+	for _, tx := range txs {
+		if _, ok := j.known[tx.Hash()]; ok {
+			continue
+		}
+		entry := &JournalEntry{Time: now, Tx: tx}
+		enc, err := rlp.EncodeToBytes(entry)
+		if err != nil {
+			return err
+		}
+		blob = append(blob, enc...)
+		added = append(added, entry)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	if _, err := j.file.Write(blob); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	for _, entry := range added {
+		j.known[entry.Tx.Hash()] = struct{}{}
+		j.entries = append(j.entries, entry)
+	}
+	return nil
+}
+
+// Rotate regenerates the journal, retaining only the entries accepted by the
+// keep filter, in their original order. The new journal is written out on the
+// side and moved into place atomically.
+func (j *Journal) Rotate(keep func(tx *types.Transaction) bool) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return errJournalClosed
+	}
+	var (
+		entries []*JournalEntry
+		blob    []byte
+	)
+	for _, entry := range j.entries {
+		if !keep(entry.Tx) {
+			continue
+		}
+		enc, err := rlp.EncodeToBytes(entry)
+		if err != nil {
+			return err
+		}
+		blob = append(blob, enc...)
+		entries = append(entries, entry)
+	}
+	replacement, err := os.OpenFile(j.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := replacement.Write(blob); err != nil {
+		replacement.Close()
+		return err
+	}
+	if err := replacement.Sync(); err != nil {
+		replacement.Close()
+		return err
+	}
+	replacement.Close()
+
+	// Replace the live journal with the newly generated one
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	j.file = nil
+
+	if err := os.Rename(j.path+".new", j.path); err != nil {
+		return err
+	}
+	if j.file, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return err
+	}
+	logger := log.Info
+	if len(entries) == 0 {
+		logger = log.Debug
+	}
+	logger("Regenerated local transaction journal", "transactions", len(entries), "dropped", len(j.entries)-len(entries))
+
+	j.entries = entries
+	j.known = make(map[common.Hash]struct{}, len(entries))
+	for _, entry := range entries {
+		j.known[entry.Tx.Hash()] = struct{}{}
+	}
+	return nil
+}
+
+// Close flushes the transaction journal contents to disk and closes the file.
+func (j *Journal) Close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// makeJournalTxs creates a batch of signed transactions with increasing nonces.
+func makeJournalTxs(t *testing.T, n int) []*types.Transaction {
+	key, _ := crypto.GenerateKey()
+	signer := types.HomesteadSigner{}
+
+	txs := make([]*types.Transaction, n)
+	for i := 0; i < n; i++ {
+		tx, err := types.SignTx(types.NewTransaction(uint64(i), common.Address{}, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		txs[i] = tx
+	}
+	return txs
+}
+
+// checkJournalEntries ensures the journal contains exactly the given transactions
+// in the given order.
+func checkJournalEntries(t *testing.T, journal *Journal, txs []*types.Transaction) {
+	t.Helper()
+
+	entries := journal.Entries()
+	if len(entries) != len(txs) {
+		t.Fatalf("journal entry count mismatch: have %d, want %d", len(entries), len(txs))
+	}
+	for i, entry := range entries {
+		if entry.Tx.Hash() != txs[i].Hash() {
+			t.Errorf("entry %d: hash mismatch: have %x, want %x", i, entry.Tx.Hash(), txs[i].Hash())
+		}
+	}
+}
+
+// Tests that journaled transactions survive reopening in their submission order,
+// and that duplicates are not journaled twice.
+func TestJournalReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.rlp")
+	txs := makeJournalTxs(t, 4)
+
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	if err := journal.Insert([]*types.Transaction{txs[2], txs[0]}); err != nil {
+		t.Fatalf("failed to insert transactions: %v", err)
+	}
+	if err := journal.Insert([]*types.Transaction{txs[0], txs[3], txs[1]}); err != nil {
+		t.Fatalf("failed to insert transactions: %v", err)
+	}
+	journal.Close()
+
+	if err := journal.Insert(txs[:1]); err != errJournalClosed {
+		t.Fatalf("insert into closed journal error mismatch: have %v, want %v", err, errJournalClosed)
+	}
+	journal, err = OpenJournal(path)
+	if err != nil {
+		t.Fatalf("failed to reopen journal: %v", err)
+	}
+	defer journal.Close()
+
+	checkJournalEntries(t, journal, []*types.Transaction{txs[2], txs[0], txs[3], txs[1]})
+}
+
+// Tests that rotating the journal drops the filtered transactions but retains
+// the order of the remaining ones, also across restarts.
+func TestJournalRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.rlp")
+	txs := makeJournalTxs(t, 4)
+
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	if err := journal.Insert(txs); err != nil {
+		t.Fatalf("failed to insert transactions: %v", err)
+	}
+	drop := txs[1].Hash()
+	if err := journal.Rotate(func(tx *types.Transaction) bool { return tx.Hash() != drop }); err != nil {
+		t.Fatalf("failed to rotate journal: %v", err)
+	}
+	want := []*types.Transaction{txs[0], txs[2], txs[3]}
+	checkJournalEntries(t, journal, want)
+
+	// Ensure the dropped transaction can be journaled again after rotation
+	if err := journal.Insert(txs[1:2]); err != nil {
+		t.Fatalf("failed to insert transaction: %v", err)
+	}
+	want = append(want, txs[1])
+	checkJournalEntries(t, journal, want)
+	journal.Close()
+
+	journal, err = OpenJournal(path)
+	if err != nil {
+		t.Fatalf("failed to reopen journal: %v", err)
+	}
+	defer journal.Close()
+
+	checkJournalEntries(t, journal, want)
+}
+
+// Tests that a torn write at the end of the journal is discarded on load, and
+// that subsequent insertions remain readable.
+func TestJournalTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.rlp")
+	txs := makeJournalTxs(t, 3)
+
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	if err := journal.Insert(txs[:2]); err != nil {
+		t.Fatalf("failed to insert transactions: %v", err)
+	}
+	journal.Close()
+
+	// Simulate a crash in the middle of appending the last transaction
+	blob, err := rlp.EncodeToBytes(&JournalEntry{Time: 1, Tx: txs[2]})
+	if err != nil {
+		t.Fatalf("failed to encode journal entry: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("failed to open journal file: %v", err)
+	}
+	file.Write(blob[:len(blob)/2])
+	file.Close()
+
+	journal, err = OpenJournal(path)
+	if err != nil {
+		t.Fatalf("failed to reopen journal: %v", err)
+	}
+	checkJournalEntries(t, journal, txs[:2])
+
+	if err := journal.Insert(txs[2:]); err != nil {
+		t.Fatalf("failed to insert transaction: %v", err)
+	}
+	journal.Close()
+
+	journal, err = OpenJournal(path)
+	if err != nil {
+		t.Fatalf("failed to reopen journal: %v", err)
+	}
+	defer journal.Close()
+
+	checkJournalEntries(t, journal, txs)
+}
+
+// Tests that journals written by the legacy pool, containing bare transactions,
+// can still be loaded.
+func TestJournalLegacyFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transactions.rlp")
+	txs := makeJournalTxs(t, 2)
+
+	var blob []byte
+	for _, tx := range txs {
+		enc, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			t.Fatalf("failed to encode transaction: %v", err)
+		}
+		blob = append(blob, enc...)
+	}
+	if err := os.WriteFile(path, blob, 0644); err != nil {
+		t.Fatalf("failed to write legacy journal: %v", err)
+	}
+	journal, err := OpenJournal(path)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	defer journal.Close()
+
+	checkJournalEntries(t, journal, txs)
+}
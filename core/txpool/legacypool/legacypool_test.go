@@ -23,7 +23,7 @@ import (
 	"fmt"
 	"math/big"
 	"math/rand"
-	"os"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -690,6 +690,48 @@ func TestDropping(t *testing.T) {
 	}
 }
 
+// Tests that explicitly removing a pending transaction evicts it, postponing any
+// subsequent ones of the same account into the future queue.
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, account, big.NewInt(1000000))
+
+	txs := []*types.Transaction{
+		transaction(0, 100000, key),
+		transaction(1, 100000, key),
+		transaction(2, 100000, key),
+	}
+	for i, err := range pool.addRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if !pool.Remove(txs[1].Hash()) {
+		t.Fatalf("failed to remove pooled transaction")
+	}
+	if pool.Remove(txs[1].Hash()) {
+		t.Fatalf("removed transaction reported as pooled")
+	}
+	if pool.Has(txs[1].Hash()) {
+		t.Errorf("removed transaction still pooled")
+	}
+	pending, queued := pool.Stats()
+	if pending != 1 {
+		t.Errorf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if queued != 1 {
+		t.Errorf("queued transactions mismatched: have %d, want %d", queued, 1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that if a transaction is dropped from the current pending pool (e.g. out
 // of fund), all consecutive (still valid, but not executable) transactions are
 // postponed back into the future queue to prevent broadcasting them.
@@ -2350,67 +2392,66 @@ func TestJournalingNoLocals(t *testing.T) { testJournaling(t, true) }
 func testJournaling(t *testing.T, nolocals bool) {
 	t.Parallel()
 
-	// Create a temporary file for the journal
-	file, err := os.CreateTemp("", "")
-	if err != nil {
-		t.Fatalf("failed to create temporary journal: %v", err)
-	}
-	journal := file.Name()
-	defer os.Remove(journal)
-
-	// Clean up the temporary file, we only need the path for now
-	file.Close()
-	os.Remove(journal)
-
-	// Create the original pool to inject transaction into the journal
-	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
-	blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
-
-	config := testTxPoolConfig
+	var (
+		journal    = filepath.Join(t.TempDir(), "transactions.rlp")
+		statedb, _ = state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		config     = testTxPoolConfig
+	)
 	config.NoLocals = nolocals
-	config.Journal = journal
-	config.Rejournal = time.Second
 
-	pool := New(config, blockchain)
-	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	// newPool creates a transaction pool on top of a legacy pool, loading the
+	// journal the same way as the node does.
+	newPool := func() (*txpool.TxPool, *LegacyPool) {
+		blockchain := newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
+		legacy := New(config, blockchain)
+		pool, err := txpool.New(config.PriceLimit, blockchain, []txpool.SubPool{legacy})
+		if err != nil {
+			t.Fatalf("failed to create transaction pool: %v", err)
+		}
+		if !nolocals {
+			if err := pool.LoadJournal(journal, time.Second); err != nil {
+				t.Fatalf("failed to load journal: %v", err)
+			}
+		}
+		return pool, legacy
+	}
+	pool, legacy := newPool()
 
 	// Create two test accounts to ensure remotes expire but locals do not
 	local, _ := crypto.GenerateKey()
 	remote, _ := crypto.GenerateKey()
+	localAddr := crypto.PubkeyToAddress(local.PublicKey)
 
-	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
-	testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+	testAddBalance(legacy, localAddr, big.NewInt(1000000000))
+	testAddBalance(legacy, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
 
-	// Add three local and a remote transactions and ensure they are queued up
-	if err := pool.addLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
-		t.Fatalf("failed to add local transaction: %v", err)
+	// Add three local transactions out of nonce order, a remote one from the local
+	// account and one from the remote account, and ensure they are pending
+	for _, nonce := range []uint64{2, 0, 1} {
+		if err := pool.Add([]*types.Transaction{pricedTransaction(nonce, 100000, big.NewInt(1), local)}, true, true)[0]; err != nil {
+			t.Fatalf("failed to add local transaction %d: %v", nonce, err)
+		}
 	}
-	if err := pool.addLocal(pricedTransaction(1, 100000, big.NewInt(1), local)); err != nil {
-		t.Fatalf("failed to add local transaction: %v", err)
+	if err := pool.Add([]*types.Transaction{pricedTransaction(3, 100000, big.NewInt(1), local)}, false, true)[0]; err != nil {
+		t.Fatalf("failed to add remote transaction of local account: %v", err)
 	}
-	if err := pool.addLocal(pricedTransaction(2, 100000, big.NewInt(1), local)); err != nil {
-		t.Fatalf("failed to add local transaction: %v", err)
-	}
-	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), remote)); err != nil {
+	if err := pool.Add([]*types.Transaction{pricedTransaction(0, 100000, big.NewInt(1), remote)}, false, true)[0]; err != nil {
 		t.Fatalf("failed to add remote transaction: %v", err)
 	}
 	pending, queued := pool.Stats()
-	if pending != 4 {
-		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 4)
+	if pending != 5 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 5)
 	}
 	if queued != 0 {
 		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
 	}
-	if err := validatePoolInternals(pool); err != nil {
+	if err := validatePoolInternals(legacy); err != nil {
 		t.Fatalf("pool internal state corrupted: %v", err)
 	}
 	// Terminate the old pool, bump the local nonce, create a new pool and ensure relevant transaction survive
 	pool.Close()
-	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
-	blockchain = newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
-
-	pool = New(config, blockchain)
-	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	statedb.SetNonce(localAddr, 1)
+	pool, legacy = newPool()
 
 	pending, queued = pool.Stats()
 	if queued != 0 {
@@ -2421,23 +2462,41 @@ func testJournaling(t *testing.T, nolocals bool) {
 			t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 0)
 		}
 	} else {
-		if pending != 2 {
-			t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+		if pending != 3 {
+			t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 3)
+		}
+		// The journaled transactions were replayed in submission order, ensure
+		// they were nonetheless promoted in nonce order
+		for i, ltx := range pool.Pending(txpool.PendingFilter{})[localAddr] {
+			if nonce := ltx.Resolve().Nonce(); nonce != uint64(i+1) {
+				t.Fatalf("pending transaction %d: nonce mismatch: have %d, want %d", i, nonce, i+1)
+			}
+		}
+		// Ensure the replay report covers all the journaled transactions
+		replays := pool.JournalReplays()
+		if len(replays) != 4 {
+			t.Fatalf("journal replay count mismatch: have %d, want %d", len(replays), 4)
+		}
+		for _, replay := range replays {
+			if replay.Subpool != "legacypool" {
+				t.Errorf("replay %x: subpool mismatch: have %q, want %q", replay.Hash, replay.Subpool, "legacypool")
+			}
+			if mined := replay.Hash == pricedTransaction(0, 100000, big.NewInt(1), local).Hash(); mined != errors.Is(replay.Error, core.ErrNonceTooLow) {
+				t.Errorf("replay %x: unexpected error: %v", replay.Hash, replay.Error)
+			}
 		}
 	}
-	if err := validatePoolInternals(pool); err != nil {
+	if err := validatePoolInternals(legacy); err != nil {
 		t.Fatalf("pool internal state corrupted: %v", err)
 	}
 	// Bump the nonce temporarily and ensure the newly invalidated transaction is removed
-	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 2)
-	<-pool.requestReset(nil, nil)
-	time.Sleep(2 * config.Rejournal)
+	statedb.SetNonce(localAddr, 2)
+	<-legacy.requestReset(nil, nil)
+	time.Sleep(2 * time.Second)
 	pool.Close()
 
-	statedb.SetNonce(crypto.PubkeyToAddress(local.PublicKey), 1)
-	blockchain = newTestBlockChain(params.TestChainConfig, 1000000, statedb, new(event.Feed))
-	pool = New(config, blockchain)
-	pool.Init(config.PriceLimit, blockchain.CurrentBlock(), makeAddressReserver())
+	statedb.SetNonce(localAddr, 1)
+	pool, legacy = newPool()
 
 	pending, queued = pool.Stats()
 	if pending != 0 {
@@ -2448,11 +2507,11 @@ func testJournaling(t *testing.T, nolocals bool) {
 			t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
 		}
 	} else {
-		if queued != 1 {
-			t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 1)
+		if queued != 2 {
+			t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 2)
 		}
 	}
-	if err := validatePoolInternals(pool); err != nil {
+	if err := validatePoolInternals(legacy); err != nil {
 		t.Fatalf("pool internal state corrupted: %v", err)
 	}
 	pool.Close()
@@ -0,0 +1,108 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// privateTx is a locally submitted transaction which is only made available for
+// local block building and must not be gossiped to the network.
+type privateTx struct {
+	tx      *types.Transaction // Transaction withheld from the network
+	expiry  uint64             // Last block number the transaction may be included in while private
+	publish bool               // Whether to make the transaction public after expiry
+}
+
+// privateSet is the set of private transactions tracked by the pool.
+type privateSet struct {
+	txs  map[common.Hash]*privateTx
+	lock sync.RWMutex
+}
+
+// newPrivateSet creates an empty private transaction set.
+func newPrivateSet() *privateSet {
+	return &privateSet{
+		txs: make(map[common.Hash]*privateTx),
+	}
+}
+
+// add marks a transaction as private until the given block number. If the hash
+// is already tracked, false is returned and the old expiry is left untouched.
+func (s *privateSet) add(tx *types.Transaction, expiry uint64, publish bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.txs[tx.Hash()]; ok {
+		return false
+	}
+	s.txs[tx.Hash()] = &privateTx{tx: tx, expiry: expiry, publish: publish}
+	return true
+}
+
+// remove drops a transaction from the private set.
+func (s *privateSet) remove(hash common.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.txs, hash)
+}
+
+// contains returns whether a transaction is private.
+func (s *privateSet) contains(hash common.Hash) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	_, ok := s.txs[hash]
+	return ok
+}
+
+// expire removes all the private transactions which can no longer be included
+// in a block on top of the given head number, returning them split by whether
+// they need publishing or dropping.
+func (s *privateSet) expire(number uint64) (publish []*types.Transaction, drop []*types.Transaction) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for hash, ptx := range s.txs {
+		if number < ptx.expiry {
+			continue
+		}
+		delete(s.txs, hash)
+		if ptx.publish {
+			publish = append(publish, ptx.tx)
+		} else {
+			drop = append(drop, ptx.tx)
+		}
+	}
+	return publish, drop
+}
+
+// prune removes all the private transactions not accepted by the keep filter.
+func (s *privateSet) prune(keep func(hash common.Hash) bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for hash := range s.txs {
+		if !keep(hash) {
+			delete(s.txs, hash)
+		}
+	}
+}
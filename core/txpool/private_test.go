@@ -0,0 +1,64 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that private transactions are released on expiry, split by whether they
+// need to be published or dropped.
+func TestPrivateSetExpiry(t *testing.T) {
+	var (
+		txs = makeJournalTxs(t, 4)
+		set = newPrivateSet()
+	)
+	set.add(txs[0], 10, false)
+	set.add(txs[1], 10, true)
+	set.add(txs[2], 20, false)
+	set.add(txs[3], 20, true)
+
+	if set.add(txs[0], 30, true) {
+		t.Fatalf("duplicate private transaction accepted")
+	}
+	// Nothing should be released until the head reaches the expiry block
+	if publish, drop := set.expire(9); len(publish) != 0 || len(drop) != 0 {
+		t.Fatalf("premature expiry: published %d, dropped %d", len(publish), len(drop))
+	}
+	publish, drop := set.expire(10)
+	if len(publish) != 1 || publish[0].Hash() != txs[1].Hash() {
+		t.Errorf("published transactions mismatch: have %v, want %x", publish, txs[1].Hash())
+	}
+	if len(drop) != 1 || drop[0].Hash() != txs[0].Hash() {
+		t.Errorf("dropped transactions mismatch: have %v, want %x", drop, txs[0].Hash())
+	}
+	for i, tx := range txs {
+		if have, want := set.contains(tx.Hash()), i >= 2; have != want {
+			t.Errorf("tx %d: private status mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	// Transactions leaving the pool should be forgotten without expiry
+	set.prune(func(hash common.Hash) bool { return hash != txs[2].Hash() })
+	if set.contains(txs[2].Hash()) {
+		t.Errorf("pruned transaction still private")
+	}
+	if publish, drop := set.expire(20); len(publish) != 1 || len(drop) != 0 {
+		t.Errorf("final expiry mismatch: published %d, dropped %d", len(publish), len(drop))
+	}
+}
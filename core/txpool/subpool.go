@@ -91,6 +91,10 @@ type PendingFilter struct {
 // production, this interface defines the common methods that allow the primary
 // transaction pool to manage the subpools.
 type SubPool interface {
+	// Name returns a short, human readable identifier of the subpool, used when
+	// reporting on the transactions it handled.
+	Name() string
+
 	// Filter is a selector used to decide whether a transaction would be added
 	// to this particular subpool.
 	Filter(tx *types.Transaction) bool
@@ -163,3 +167,12 @@ type SubPool interface {
 	// identified by their hashes.
 	Status(hash common.Hash) TxStatus
 }
+
+// Remover is an optional interface of subpools supporting the eviction of single
+// transactions on request, needed to drop expired private transactions.
+type Remover interface {
+	// Remove evicts a transaction from the pool, returning whether it was found.
+	// Any subsequent transactions of the same account are kept, but might become
+	// non-executable.
+	Remove(hash common.Hash) bool
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -75,6 +76,14 @@ type TxPool struct {
 	term chan struct{}           // Termination channel to detect a closed pool
 
 	sync chan chan error // Testing / simulator channel to block until internal reset is done
+
+	journal     *Journal         // Journal of local transactions across all subpools (optional)
+	replays     []*JournalReplay // Outcome of re-adding the journaled transactions on startup
+	journalQuit chan struct{}    // Quit channel to tear down the journal rotator
+	journalDone chan struct{}    // Termination channel of the journal rotator
+
+	private     *privateSet // Local transactions withheld from the network
+	publishFeed event.Feed  // Feed of private transactions made public on expiry
 }
 
 // New creates a new transaction pool to gather, sort and filter inbound
@@ -91,6 +100,7 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 		quit:         make(chan chan error),
 		term:         make(chan struct{}),
 		sync:         make(chan chan error),
+		private:      newPrivateSet(),
 	}
 	for i, subpool := range subpools {
 		if err := subpool.Init(gasTip, head, pool.reserver(i, subpool)); err != nil {
@@ -104,6 +114,93 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 	return pool, nil
 }
 
+// LoadJournal opens the local transaction journal at the given path, re-adds all
+// the transactions tracked by it into the subpools and starts regenerating it
+// periodically to drop the ones no longer pooled. From then on, all successfully
+// added local transactions are journaled.
+//
+// Note, the journal needs to be loaded before the pool is used.
+func (p *TxPool) LoadJournal(path string, rejournal time.Duration) error {
+	if p.journal != nil {
+		return errors.New("journal already loaded")
+	}
+	if rejournal < time.Second {
+		log.Warn("Sanitizing invalid txpool journal time", "provided", rejournal, "updated", time.Second)
+		rejournal = time.Second
+	}
+	journal, err := OpenJournal(path)
+	if err != nil {
+		return err
+	}
+	// Re-add the journaled transactions in their original order, tracking the
+	// outcome of each to allow inspecting what survived the restart
+	var (
+		entries = journal.Entries()
+		txs     = make([]*types.Transaction, len(entries))
+	)
+	for i, entry := range entries {
+		txs[i] = entry.Tx
+	}
+	errs, splits := p.add(txs, true, true)
+
+	var readmitted int
+	p.replays = make([]*JournalReplay, len(entries))
+	for i, entry := range entries {
+		replay := &JournalReplay{
+			Hash:  entry.Tx.Hash(),
+			Time:  entry.Time,
+			Error: errs[i],
+		}
+		if splits[i] != -1 {
+			replay.Subpool = p.subpools[splits[i]].Name()
+		}
+		if errors.Is(replay.Error, ErrAlreadyKnown) {
+			replay.Error = nil
+		}
+		if replay.Error == nil {
+			readmitted++
+		}
+		p.replays[i] = replay
+	}
+	log.Info("Loaded local transaction journal", "transactions", len(entries), "readmitted", readmitted, "dropped", len(entries)-readmitted)
+
+	if err := journal.Rotate(p.journaled); err != nil {
+		log.Warn("Failed to rotate transaction journal", "err", err)
+	}
+	p.journal = journal
+	p.journalQuit = make(chan struct{})
+	p.journalDone = make(chan struct{})
+
+	go p.journalLoop(rejournal)
+	return nil
+}
+
+// journaled is the journal rotation filter, retaining the transactions which are
+// still tracked by any of the subpools.
+func (p *TxPool) journaled(tx *types.Transaction) bool {
+	return p.Has(tx.Hash())
+}
+
+// journalLoop periodically regenerates the local transaction journal, until the
+// pool is closed.
+func (p *TxPool) journalLoop(rejournal time.Duration) {
+	defer close(p.journalDone)
+
+	ticker := time.NewTicker(rejournal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.journal.Rotate(p.journaled); err != nil {
+				log.Warn("Failed to rotate local tx journal", "err", err)
+			}
+		case <-p.journalQuit:
+			return
+		}
+	}
+}
+
 // reserver is a method to create an address reservation callback to exclusively
 // assign/deassign addresses to/from subpools. This can ensure that at any point
 // in time, only a single subpool is able to manage an account, avoiding cross
@@ -160,6 +257,15 @@ func (p *TxPool) Close() error {
 	if err := <-errc; err != nil {
 		errs = append(errs, err)
 	}
+	// Terminate the journal rotator and flush the journal
+	if p.journal != nil {
+		close(p.journalQuit)
+		<-p.journalDone
+
+		if err := p.journal.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	// Terminate each subpool
 	for _, subpool := range p.subpools {
 		if err := subpool.Close(); err != nil {
@@ -250,6 +356,9 @@ func (p *TxPool) loop(head *types.Header, chain BlockChain) {
 			oldHead = head
 			<-resetBusy
 
+			// Release any private transactions no longer needing to be withheld
+			p.expirePrivate(head)
+
 			// If someone is waiting for a reset to finish, notify them, unless
 			// the forced op is still pending. In that case, wait another round
 			// of resets.
@@ -308,7 +417,61 @@ func (p *TxPool) Get(hash common.Hash) *types.Transaction {
 // Add enqueues a batch of transactions into the pool if they are valid. Due
 // to the large transaction churn, add may postpone fully integrating the tx
 // to a later point to batch multiple ones together.
+//
+// If the transaction journal is enabled, the successfully added local ones and
+// the ones sent from local accounts are recorded into it.
 func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
+	errs, _ := p.add(txs, local, sync)
+
+	if p.journal != nil {
+		p.journalAdded(txs, errs, local)
+	}
+	return errs
+}
+
+// journalAdded records the successfully added transactions into the journal if
+// they are local or sent from a local account.
+func (p *TxPool) journalAdded(txs []*types.Transaction, errs []error, local bool) {
+	// Remote transactions are only journaled if sent from a local account, avoid
+	// recovering their senders if there are none
+	var locals map[common.Address]struct{}
+	if !local {
+		accounts := p.Locals()
+		if len(accounts) == 0 {
+			return
+		}
+		locals = make(map[common.Address]struct{}, len(accounts))
+		for _, addr := range accounts {
+			locals[addr] = struct{}{}
+		}
+	}
+	var added []*types.Transaction
+	for i, err := range errs {
+		if err != nil {
+			continue
+		}
+		if !local {
+			// Subpools cache the sender with the same signer for protected transactions
+			from, err := types.Sender(types.LatestSignerForChainID(txs[i].ChainId()), txs[i])
+			if err != nil {
+				continue
+			}
+			if _, ok := locals[from]; !ok {
+				continue
+			}
+		}
+		added = append(added, txs[i])
+	}
+	if len(added) > 0 {
+		if err := p.journal.Insert(added); err != nil {
+			log.Warn("Failed to journal local transactions", "err", err)
+		}
+	}
+}
+
+// add enqueues a batch of transactions into the subpools, returning the errors
+// and the index of the subpool handling each, or -1 if none does.
+func (p *TxPool) add(txs []*types.Transaction, local bool, sync bool) ([]error, []int) {
 	// Split the input transactions between the subpools. It shouldn't really
 	// happen that we receive merged batches, but better graceful than strange
 	// errors.
@@ -348,9 +511,87 @@ func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
 		errs[i] = errsets[split][0]
 		errsets[split] = errsets[split][1:]
 	}
+	return errs, splits
+}
+
+// AddPrivate enqueues a batch of local transactions into the pool, which are to
+// be used for local block building, but never announced or propagated to remote
+// peers. After the expiry block is passed, the transactions still pooled are
+// either dropped, or published to the network if requested so.
+//
+// Private transactions are not journaled, since on restart they would be added
+// back as public ones.
+func (p *TxPool) AddPrivate(txs []*types.Transaction, expiry uint64, publish bool, sync bool) []error {
+	var (
+		errs    = make([]error, len(txs))
+		private = make([]*types.Transaction, 0, len(txs))
+		indices = make([]int, 0, len(txs))
+	)
+	for i, tx := range txs {
+		if !p.removable(tx) {
+			errs[i] = ErrPrivateNotSupported
+			continue
+		}
+		// Mark the transaction private before insertion to ensure it's never
+		// announced, even if the add event races with the tracking
+		if !p.private.add(tx, expiry, publish) {
+			errs[i] = ErrAlreadyKnown
+			continue
+		}
+		private = append(private, tx)
+		indices = append(indices, i)
+	}
+	adderrs, _ := p.add(private, true, sync)
+	for i, err := range adderrs {
+		if err != nil {
+			p.private.remove(private[i].Hash())
+		}
+		errs[indices[i]] = err
+	}
 	return errs
 }
 
+// removable returns whether the subpool accepting the transaction supports the
+// removal of individual transactions, needed to drop expired private ones.
+func (p *TxPool) removable(tx *types.Transaction) bool {
+	for _, subpool := range p.subpools {
+		if subpool.Filter(tx) {
+			_, ok := subpool.(Remover)
+			return ok
+		}
+	}
+	return false
+}
+
+// IsPrivate returns whether a pooled transaction is private, meaning it must not
+// be propagated to the network.
+func (p *TxPool) IsPrivate(hash common.Hash) bool {
+	return p.private.contains(hash)
+}
+
+// expirePrivate forgets the private transactions which left the pool and releases
+// the ones whose expiry block has been reached by the given head, either
+// publishing or dropping them from the pool.
+func (p *TxPool) expirePrivate(head *types.Header) {
+	p.private.prune(p.Has)
+
+	publish, drop := p.private.expire(head.Number.Uint64())
+	for _, tx := range drop {
+		for _, subpool := range p.subpools {
+			if remover, ok := subpool.(Remover); ok && remover.Remove(tx.Hash()) {
+				break
+			}
+		}
+	}
+	if len(drop) > 0 {
+		log.Debug("Dropped expired private transactions", "count", len(drop))
+	}
+	if len(publish) > 0 {
+		log.Debug("Published expired private transactions", "count", len(publish))
+		p.publishFeed.Send(core.NewTxsEvent{Txs: publish})
+	}
+}
+
 // Pending retrieves all currently processable transactions, grouped by origin
 // account and sorted by nonce.
 //
@@ -369,10 +610,11 @@ func (p *TxPool) Pending(filter PendingFilter) map[common.Address][]*LazyTransac
 // SubscribeTransactions registers a subscription for new transaction events,
 // supporting feeding only newly seen or also resurrected transactions.
 func (p *TxPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription {
-	subs := make([]event.Subscription, len(p.subpools))
+	subs := make([]event.Subscription, len(p.subpools), len(p.subpools)+1)
 	for i, subpool := range p.subpools {
 		subs[i] = subpool.SubscribeTransactions(ch, reorgs)
 	}
+	subs = append(subs, p.publishFeed.Subscribe(ch))
 	return p.subs.Track(event.JoinSubscriptions(subs...))
 }
 
@@ -453,6 +695,21 @@ func (p *TxPool) Locals() []common.Address {
 	return flat
 }
 
+// JournalEntries retrieves the transactions currently tracked by the local
+// transaction journal in submission order, or nil if journaling is disabled.
+func (p *TxPool) JournalEntries() []*JournalEntry {
+	if p.journal == nil {
+		return nil
+	}
+	return p.journal.Entries()
+}
+
+// JournalReplays retrieves the outcome of re-adding the journaled transactions
+// into the pool on startup, or nil if journaling is disabled.
+func (p *TxPool) JournalReplays() []*JournalReplay {
+	return p.replays
+}
+
 // Status returns the known status (unknown/pending/queued) of a transaction
 // identified by its hash.
 func (p *TxPool) Status(hash common.Hash) TxStatus {
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/telemetry"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -147,6 +148,9 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 }
 
 func (b *EthAPIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	_, span := telemetry.StartSpan(ctx, "eth.backend.BlockByHash")
+	defer span.End()
+
 	return b.eth.blockchain.GetBlockByHash(hash), nil
 }
 
@@ -155,6 +159,9 @@ func (b *EthAPIBackend) GetBody(ctx context.Context, hash common.Hash, number rp
 	if number < 0 || hash == (common.Hash{}) {
 		return nil, errors.New("invalid arguments; expect hash and no special block numbers")
 	}
+	_, span := telemetry.StartSpan(ctx, "eth.backend.GetBody")
+	defer span.End()
+
 	if body := b.eth.blockchain.GetBody(hash); body != nil {
 		return body, nil
 	}
@@ -203,7 +210,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.B
 	if header == nil {
 		return nil, nil, errors.New("header not found")
 	}
-	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	stateDb, err := b.stateAt(ctx, header.Root)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -225,7 +232,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
 			return nil, nil, errors.New("hash is not currently canonical")
 		}
-		stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+		stateDb, err := b.stateAt(ctx, header.Root)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -234,11 +241,27 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 	return nil, nil, errors.New("invalid arguments; neither block nor hash specified")
 }
 
+// stateAt opens the state with the given root, tracing the time it takes.
+func (b *EthAPIBackend) stateAt(ctx context.Context, root common.Hash) (*state.StateDB, error) {
+	_, span := telemetry.StartSpan(ctx, "eth.backend.StateAt")
+	defer span.End()
+
+	statedb, err := b.eth.BlockChain().StateAt(root)
+	span.RecordError(err)
+	return statedb, err
+}
+
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	_, span := telemetry.StartSpan(ctx, "eth.backend.GetReceipts")
+	defer span.End()
+
 	return b.eth.blockchain.GetReceiptsByHash(hash), nil
 }
 
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash, number uint64) ([][]*types.Log, error) {
+	_, span := telemetry.StartSpan(ctx, "eth.backend.GetLogs")
+	defer span.End()
+
 	return rawdb.ReadLogs(b.eth.chainDb, hash, number), nil
 }
 
@@ -287,12 +310,16 @@ func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.eth.txPool.Add([]*types.Transaction{signedTx}, true, false)[0]
 }
 
+func (b *EthAPIBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction, expiry uint64, publish bool) error {
+	return b.eth.txPool.AddPrivate([]*types.Transaction{signedTx}, expiry, publish, false)[0]
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending := b.eth.txPool.Pending(txpool.PendingFilter{})
 	var txs types.Transactions
 	for _, batch := range pending {
 		for _, lazy := range batch {
-			if tx := lazy.Resolve(); tx != nil {
+			if tx := lazy.Resolve(); tx != nil && !b.eth.txPool.IsPrivate(tx.Hash()) {
 				txs = append(txs, tx)
 			}
 		}
@@ -315,6 +342,9 @@ func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction
 // indexing is already finished. The transaction is not existent from the perspective
 // of node.
 func (b *EthAPIBackend) GetTransaction(ctx context.Context, txHash common.Hash) (bool, *types.Transaction, common.Hash, uint64, uint64, error) {
+	_, span := telemetry.StartSpan(ctx, "eth.backend.GetTransaction")
+	defer span.End()
+
 	lookup, tx, err := b.eth.blockchain.GetTransactionLookup(txHash)
 	if err != nil {
 		return false, nil, common.Hash{}, 0, 0, err
@@ -334,19 +364,74 @@ func (b *EthAPIBackend) Stats() (runnable int, blocked int) {
 }
 
 func (b *EthAPIBackend) TxPoolContent() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
-	return b.eth.txPool.Content()
+	pending, queued := b.eth.txPool.Content()
+	return b.publicContent(pending), b.publicContent(queued)
 }
 
 func (b *EthAPIBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
-	return b.eth.txPool.ContentFrom(addr)
+	pending, queued := b.eth.txPool.ContentFrom(addr)
+	return b.publicTxs(pending), b.publicTxs(queued)
+}
+
+// publicContent strips the private transactions from a set of pooled transactions
+// grouped by account, dropping the accounts left without any.
+func (b *EthAPIBackend) publicContent(content map[common.Address][]*types.Transaction) map[common.Address][]*types.Transaction {
+	for addr, txs := range content {
+		if txs = b.publicTxs(txs); len(txs) == 0 {
+			delete(content, addr)
+		} else {
+			content[addr] = txs
+		}
+	}
+	return content
+}
+
+// publicTxs returns the transactions of a batch which are not private. The batch
+// itself is not modified, as it may be shared with other users.
+func (b *EthAPIBackend) publicTxs(txs []*types.Transaction) []*types.Transaction {
+	public := make([]*types.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if !b.eth.txPool.IsPrivate(tx.Hash()) {
+			public = append(public, tx)
+		}
+	}
+	return public
+}
+
+func (b *EthAPIBackend) TxPoolJournal() ([]*txpool.JournalEntry, []*txpool.JournalReplay) {
+	return b.eth.txPool.JournalEntries(), b.eth.txPool.JournalReplays()
 }
 
 func (b *EthAPIBackend) TxPool() *txpool.TxPool {
 	return b.eth.txPool
 }
 
+// SubscribeNewTxsEvent subscribes to the transactions entering the pool, leaving
+// out the private ones until they are published.
 func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
-	return b.eth.txPool.SubscribeTransactions(ch, true)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		txsCh := make(chan core.NewTxsEvent, cap(ch))
+		sub := b.eth.txPool.SubscribeTransactions(txsCh, true)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-txsCh:
+				if ev.Txs = b.publicTxs(ev.Txs); len(ev.Txs) == 0 {
+					continue
+				}
+				select {
+				case ch <- ev:
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
 }
 
 func (b *EthAPIBackend) SyncProgress() ethereum.SyncProgress {
@@ -410,6 +495,15 @@ func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, sections
 }
 
+func (b *EthAPIBackend) LogIndexStatus() (uint64, uint64, uint64) {
+	sections, _, _ := b.eth.logIndexer.Sections()
+	var tail uint64
+	if number := rawdb.ReadLogIndexTail(b.eth.chainDb); number != nil {
+		tail = *number
+	}
+	return params.LogIndexBlocks, sections, tail
+}
+
 func (b *EthAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	for i := 0; i < bloomFilterThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.eth.bloomRequests)
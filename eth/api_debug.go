@@ -26,8 +26,10 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -443,3 +445,38 @@ func (api *DebugAPI) GetTrieFlushInterval() (string, error) {
 	}
 	return api.eth.blockchain.GetTrieFlushInterval().String(), nil
 }
+
+// ExecutionWitness re-executes the given block and returns the witness needed
+// to statelessly verify it: the touched trie nodes, contract codes and the
+// ancestor headers accessed via BLOCKHASH.
+func (api *DebugAPI) ExecutionWitness(ctx context.Context, number rpc.BlockNumber) (*stateless.Witness, error) {
+	block, err := api.eth.APIBackend.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return api.eth.blockchain.GenerateWitness(block)
+}
+
+// GetRawExecutionWitness returns the RLP encoded execution witness of the
+// given block.
+func (api *DebugAPI) GetRawExecutionWitness(ctx context.Context, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	witness, err := api.ExecutionWitness(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(witness)
+}
+
+// TxPropagation returns when the given transaction was first seen and when each
+// peer announced or delivered it. It returns null if the transaction hasn't been
+// seen from the network recently.
+func (api *DebugAPI) TxPropagation(hash common.Hash) (*fetcher.TxPropagation, error) {
+	recorder := api.eth.handler.txPropagation
+	if recorder == nil {
+		return nil, errors.New("transaction propagation recording is disabled")
+	}
+	return recorder.Propagation(hash), nil
+}
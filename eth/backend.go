@@ -82,6 +82,7 @@ type Ethereum struct {
 	bloomRequests     chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
+	logIndexer        *core.ChainIndexer // Log indexer operating during block imports
 
 	APIBackend *EthAPIBackend
 
@@ -96,6 +97,8 @@ type Ethereum struct {
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and etherbase)
 
 	shutdownTracker *shutdowncheck.ShutdownTracker // Tracks if and when the node has shutdown ungracefully
+
+	rpcCacheInvalidator *rpcCacheInvalidator // Drops cached RPC responses on finality rollbacks, if caching is enabled
 }
 
 // New creates a new Ethereum object (including the initialisation of the common Ethereum object),
@@ -159,6 +162,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		gasPrice:          config.Miner.GasPrice,
 		bloomRequests:     make(chan chan *bloombits.Retrieval),
 		bloomIndexer:      core.NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
+		logIndexer:        core.NewLogIndexer(chainDb, params.LogIndexBlocks, params.LogIndexConfirms, config.LogHistory),
 		p2pServer:         stack.Server(),
 		discmix:           enode.NewFairMix(0),
 		shutdownTracker:   shutdowncheck.NewShutdownTracker(chainDb),
@@ -225,6 +229,14 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		return nil, err
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	eth.logIndexer.Start(eth.blockchain)
+
+	if cache := stack.RPCResponseCache(); cache != nil {
+		eth.rpcCacheInvalidator = newRPCCacheInvalidator(eth.blockchain, cache)
+	}
+	for _, policy := range stack.RPCPolicies() {
+		policy.SetHeadFunc(func() uint64 { return eth.blockchain.CurrentBlock().Number.Uint64() })
+	}
 
 	if config.BlobPool.Datadir != "" {
 		config.BlobPool.Datadir = stack.ResolvePath(config.BlobPool.Datadir)
@@ -240,6 +252,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	if !config.TxPool.NoLocals && config.TxPool.Journal != "" {
+		if err := eth.txPool.LoadJournal(config.TxPool.Journal, config.TxPool.Rejournal); err != nil {
+			log.Warn("Failed to load transaction journal", "err", err)
+		}
+	}
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
 	if eth.handler, err = newHandler(&handlerConfig{
@@ -252,6 +269,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		BloomCache:     uint64(cacheLimit),
 		EventMux:       eth.eventMux,
 		RequiredBlocks: config.RequiredBlocks,
+		TxPropagation:  config.TxPropagation,
 	}); err != nil {
 		return nil, err
 	}
@@ -309,6 +327,10 @@ func (s *Ethereum) APIs() []rpc.API {
 		{
 			Namespace: "miner",
 			Service:   NewMinerAPI(s),
+		}, {
+			Namespace:     "eth",
+			Service:       NewBundleAPI(s),
+			Authenticated: true,
 		}, {
 			Namespace: "eth",
 			Service:   downloader.NewDownloaderAPI(s.handler.downloader, s.blockchain, s.eventMux),
@@ -343,6 +365,7 @@ func (s *Ethereum) Synced() bool                       { return s.handler.synced
 func (s *Ethereum) SetSynced()                         { s.handler.enableSyncedFeatures() }
 func (s *Ethereum) ArchiveMode() bool                  { return s.config.NoPruning }
 func (s *Ethereum) BloomIndexer() *core.ChainIndexer   { return s.bloomIndexer }
+func (s *Ethereum) LogIndexer() *core.ChainIndexer     { return s.logIndexer }
 
 // Protocols returns all the currently configured
 // network protocols to start.
@@ -365,6 +388,11 @@ func (s *Ethereum) Start() error {
 	// Regularly update shutdown marker
 	s.shutdownTracker.Start()
 
+	// Keep the RPC response cache consistent with the chain
+	if s.rpcCacheInvalidator != nil {
+		s.rpcCacheInvalidator.start()
+	}
+
 	// Start the networking layer
 	s.handler.Start(s.p2pServer.MaxPeers)
 	return nil
@@ -411,8 +439,12 @@ func (s *Ethereum) Stop() error {
 
 	// Then stop everything else.
 	s.bloomIndexer.Close()
+	s.logIndexer.Close()
 	close(s.closeBloomHandler)
 	s.txPool.Close()
+	if s.rpcCacheInvalidator != nil {
+		s.rpcCacheInvalidator.stop()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 
@@ -95,6 +95,7 @@ type Config struct {
 
 	TransactionHistory uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 	StateHistory       uint64 `toml:",omitempty"` // The maximum number of blocks from head whose state histories are reserved.
+	LogHistory         uint64 `toml:",omitempty"` // The maximum number of blocks from head whose logs are indexed (0 = entire chain).
 
 	// State scheme represents the scheme used to store ethereum states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
@@ -128,6 +129,10 @@ type Config struct {
 	TxPool   legacypool.Config
 	BlobPool blobpool.Config
 
+	// TxPropagation is the number of recent transactions to record the network
+	// propagation of (0 = disabled).
+	TxPropagation int `toml:",omitempty"`
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
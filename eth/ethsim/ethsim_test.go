@@ -0,0 +1,127 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethsim
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/p2p/netsim"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+)
+
+func newTestChain(n int) (*core.Genesis, []*types.Block) {
+	genesis := &core.Genesis{
+		Config:     params.AllDevChainProtocolChanges,
+		Alloc:      types.GenesisAlloc{testAddr: {Balance: testBalance}},
+		GasLimit:   30_000_000,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+		Difficulty: common.Big0,
+	}
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, beacon.NewFaker(), n, func(i int, g *core.BlockGen) {
+		g.OffsetTime(5)
+	})
+	return genesis, blocks
+}
+
+func newTestTransactions(n int, nonce uint64) []*types.Transaction {
+	signer := types.LatestSigner(params.AllDevChainProtocolChanges)
+	txs := make([]*types.Transaction, n)
+	for i := range txs {
+		txs[i] = types.MustSignNewTx(testKey, signer, &types.DynamicFeeTx{
+			ChainID:   params.AllDevChainProtocolChanges.ChainID,
+			Nonce:     nonce + uint64(i),
+			GasTipCap: big.NewInt(2 * params.GWei),
+			GasFeeCap: big.NewInt(10 * params.GWei),
+			Gas:       params.TxGas,
+			To:        &common.Address{1},
+			Value:     big.NewInt(1),
+		})
+	}
+	return txs
+}
+
+// Tests that blocks and transactions reach all nodes of a simulated network.
+func TestSyncAndPropagation(t *testing.T) {
+	const nodeCount = 4
+	genesis, blocks := newTestChain(10)
+	head := blocks[len(blocks)-1].Header()
+
+	network := netsim.New(netsim.Config{Seed: 1, Link: netsim.Link{Latency: 20 * time.Millisecond}})
+	defer network.Close()
+
+	var nodes []*Node
+	for i := 0; i < nodeCount; i++ {
+		n, err := NewNode(network, Config{Genesis: genesis, SyncMode: downloader.FullSync, Synced: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer n.Close()
+		nodes = append(nodes, n)
+	}
+	// All nodes are connected to each other, because the downloader drops peers
+	// which can't deliver the head. In a ring, a node could lose all its peers
+	// if its neighbours are still syncing.
+	txs := newTestTransactions(3, 0)
+	scenario := netsim.Scenario{
+		Name: "sync",
+		Steps: []netsim.Step{
+			{At: 0, Name: "connect", Action: netsim.ConnectRandom(nodeCount - 1)},
+			{At: time.Second, Name: "build", Action: InsertBlocks(nodes[0], blocks)},
+			{At: time.Second, Name: "sync", Action: SyncTo(nodes[1:], head)},
+			{At: 2 * time.Second, Name: "send", Action: SendTransactions(nodes[0], txs)},
+		},
+	}
+	if err := scenario.Run(network); err != nil {
+		t.Fatal(err)
+	}
+	metrics := network.Metrics()
+	ok := network.WaitFor(60*time.Second, 50*time.Millisecond, func() bool {
+		if metrics.Sync(head.Number.Uint64()).Nodes != nodeCount {
+			return false
+		}
+		for _, tx := range txs {
+			if metrics.Seen(tx.Hash()) != nodeCount {
+				return false
+			}
+		}
+		return true
+	})
+	if !ok {
+		for _, n := range nodes {
+			t.Logf("node %v: head %d", n.ID(), metrics.Head(n.ID()))
+		}
+		t.Fatal("network did not converge")
+	}
+	t.Logf("sync: %v", metrics.Sync(head.Number.Uint64()))
+	for _, tx := range txs {
+		t.Logf("tx %x: %v", tx.Hash().Bytes()[:4], metrics.Propagation(tx.Hash()))
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package ethsim runs eth/snap nodes on a simulated network.
+//
+// Nodes report the blocks and transactions they see to the network metrics, so
+// scenarios can measure chain sync and transaction propagation. The p2p servers
+// of the nodes run on the network clock, but the eth protocol handlers, fetchers
+// and downloader use the system clock. Measurements are therefore approximate,
+// and vary between runs: compare propagation changes over several runs rather
+// than by the exact timings of a single one.
+package ethsim
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/netsim"
+)
+
+// Config is the configuration of a simulated eth node.
+type Config struct {
+	Genesis  *core.Genesis
+	SyncMode downloader.SyncMode
+	MaxPeers int // defaults to 25
+
+	// Synced marks the node as synced when it starts. Nodes only accept
+	// transactions from the network when they are synced.
+	Synced bool
+}
+
+// Node is an eth/snap node on a simulated network.
+type Node struct {
+	*netsim.Node
+	Stack   *node.Node
+	Backend *eth.Ethereum
+
+	metrics *netsim.Metrics
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// NewNode creates and starts a node on the network.
+func NewNode(network *netsim.Network, config Config) (*Node, error) {
+	if config.Genesis == nil {
+		return nil, errors.New("genesis is required")
+	}
+	if config.MaxPeers == 0 {
+		config.MaxPeers = 25
+	}
+	p2pConfig := p2p.Config{MaxPeers: config.MaxPeers}
+	simnode := network.NewNode(&p2pConfig)
+
+	stack, err := node.New(&node.Config{
+		Name:   fmt.Sprintf("sim%d", len(network.Nodes())),
+		P2P:    p2pConfig,
+		Logger: log.Root().With("node", simnode.ID().TerminalString()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ethConfig := ethconfig.Defaults
+	ethConfig.Genesis = config.Genesis
+	ethConfig.NetworkId = config.Genesis.Config.ChainID.Uint64()
+	ethConfig.SyncMode = config.SyncMode
+	backend, err := eth.New(stack, &ethConfig)
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	if err := stack.Start(); err != nil {
+		stack.Close()
+		return nil, err
+	}
+	simnode.Attach(stack.Server())
+	if config.Synced {
+		backend.SetSynced()
+	}
+
+	n := &Node{
+		Node:    simnode,
+		Stack:   stack,
+		Backend: backend,
+		metrics: network.Metrics(),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go n.observe()
+	return n, nil
+}
+
+// Close stops the node. It is removed from the network first, because the
+// network clock doesn't advance while the node shuts down.
+func (n *Node) Close() error {
+	close(n.quit)
+	<-n.done
+	n.Node.Close()
+	n.Disconnect()
+	return n.Stack.Close()
+}
+
+// observe reports new chain heads and transactions to the network metrics.
+func (n *Node) observe() {
+	defer close(n.done)
+
+	var (
+		heads = make(chan core.ChainHeadEvent, 16)
+		txs   = make(chan core.NewTxsEvent, 16)
+	)
+	headSub := n.Backend.BlockChain().SubscribeChainHeadEvent(heads)
+	defer headSub.Unsubscribe()
+	txSub := n.Backend.TxPool().SubscribeTransactions(txs, false)
+	defer txSub.Unsubscribe()
+
+	n.metrics.ObserveHead(n.ID(), n.Backend.BlockChain().CurrentBlock().Number.Uint64())
+	for {
+		select {
+		case ev := <-heads:
+			n.metrics.ObserveHead(n.ID(), ev.Block.NumberU64())
+			n.metrics.Observe(n.ID(), ev.Block.Hash())
+		case ev := <-txs:
+			for _, tx := range ev.Txs {
+				n.metrics.Observe(n.ID(), tx.Hash())
+			}
+		case <-headSub.Err():
+			return
+		case <-txSub.Err():
+			return
+		case <-n.quit:
+			return
+		}
+	}
+}
+
+// InsertBlocks returns an action which imports blocks into the chain of a node,
+// like a block builder would.
+func InsertBlocks(n *Node, blocks []*types.Block) func(*netsim.Network) error {
+	return func(*netsim.Network) error {
+		_, err := n.Backend.BlockChain().InsertChain(blocks)
+		return err
+	}
+}
+
+// SyncTo returns an action which makes nodes sync to the given head, like
+// their consensus client would on a new head announcement.
+func SyncTo(nodes []*Node, head *types.Header) func(*netsim.Network) error {
+	return func(*netsim.Network) error {
+		for _, n := range nodes {
+			if n.Backend.BlockChain().HasHeader(head.Hash(), head.Number.Uint64()) {
+				continue
+			}
+			if err := n.Backend.Downloader().BeaconSync(n.Backend.SyncMode(), head, head); err != nil {
+				return fmt.Errorf("node %v: %w", n.ID(), err)
+			}
+		}
+		return nil
+	}
+}
+
+// SendTransactions returns an action which adds transactions to the pool of a
+// node, from where they are propagated to the network.
+func SendTransactions(n *Node, txs []*types.Transaction) func(*netsim.Network) error {
+	return func(*netsim.Network) error {
+		for i, err := range n.Backend.TxPool().Add(txs, true, false) {
+			if err != nil {
+				return fmt.Errorf("transaction %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+}
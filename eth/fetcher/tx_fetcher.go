@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
@@ -195,6 +196,9 @@ type TxFetcher struct {
 	fetchTxs func(string, []common.Hash) error  // Retrieves a set of txs from a remote peer
 	dropPeer func(string)                       // Drops a peer in case of announcement violation
 
+	onDelivery  func(peer string, added, invalid int) // Reports the outcome of transaction deliveries
+	propagation *TxPropagationRecorder                // Records transaction propagation, if enabled
+
 	step  chan struct{} // Notification channel when the fetcher loop iterates
 	clock mclock.Clock  // Time wrapper to simulate in tests
 	rand  *mrand.Rand   // Randomizer to use in tests instead of map range loops (soft-random)
@@ -239,6 +243,9 @@ func NewTxFetcherForTests(
 func (f *TxFetcher) Notify(peer string, types []byte, sizes []uint32, hashes []common.Hash) error {
 	// Keep track of all the announced transactions
 	txAnnounceInMeter.Mark(int64(len(hashes)))
+	if f.propagation != nil {
+		f.propagation.announced(peer, hashes)
+	}
 
 	// Skip any transaction announcements that we already know of, or that we've
 	// previously marked as cheap and discarded. This check is of course racy,
@@ -293,6 +300,21 @@ func (f *TxFetcher) isKnownUnderpriced(hash common.Hash) bool {
 	return ok
 }
 
+// SetDeliveryCallback sets the callback to run with the outcome of the transactions
+// delivered by a peer: the number added to the pool and the number which are
+// invalid regardless of the pool state. This method is not thread safe and should
+// be set only once on startup before any transactions are delivered.
+func (f *TxFetcher) SetDeliveryCallback(onDelivery func(peer string, added, invalid int)) {
+	f.onDelivery = onDelivery
+}
+
+// SetPropagationRecorder sets the recorder of the announcements and deliveries
+// of transactions. This method is not thread safe and should be set only once on
+// startup before any transactions are announced.
+func (f *TxFetcher) SetPropagationRecorder(recorder *TxPropagationRecorder) {
+	f.propagation = recorder
+}
+
 // Enqueue imports a batch of received transaction into the transaction pool
 // and the fetcher. This method may be called by both transaction broadcasts and
 // direct request replies. The differentiation is important so the fetcher can
@@ -312,12 +334,21 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 	}
 	// Keep track of all the propagated transactions
 	inMeter.Mark(int64(len(txs)))
+	if f.propagation != nil {
+		hashes := make([]common.Hash, len(txs))
+		for i, tx := range txs {
+			hashes[i] = tx.Hash()
+		}
+		f.propagation.delivered(peer, hashes, direct)
+	}
 
 	// Push all the transactions into the pool, tracking underpriced ones to avoid
 	// re-requesting them and dropping the peer in case of malicious transfers.
 	var (
 		added = make([]common.Hash, 0, len(txs))
 		metas = make([]txMetadata, 0, len(txs))
+
+		accepted, invalid int
 	)
 	// proceed in batches
 	for i := 0; i < len(txs); i += 128 {
@@ -341,7 +372,8 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 			}
 			// Track a few interesting failure types
 			switch {
-			case err == nil: // Noop, but need to handle to not count these
+			case err == nil:
+				accepted++
 
 			case errors.Is(err, txpool.ErrAlreadyKnown):
 				duplicate++
@@ -351,6 +383,9 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 
 			default:
 				otherreject++
+				if isInvalidTx(err) {
+					invalid++
+				}
 			}
 			added = append(added, batch[j].Hash())
 			metas = append(metas, txMetadata{
@@ -368,6 +403,9 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 			log.Debug("Peer delivering stale transactions", "peer", peer, "rejected", otherreject)
 		}
 	}
+	if f.onDelivery != nil {
+		f.onDelivery(peer, accepted, invalid)
+	}
 	select {
 	case f.cleanup <- &txDelivery{origin: peer, hashes: added, metas: metas, direct: direct}:
 		return nil
@@ -376,6 +414,23 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 	}
 }
 
+// isInvalidTx reports whether a transaction was rejected by the pool for being
+// malformed, as opposed to conflicting with the current pool or chain state. The
+// latter is common for honest peers relaying transactions which were just mined.
+func isInvalidTx(err error) bool {
+	switch {
+	case errors.Is(err, txpool.ErrInvalidSender),
+		errors.Is(err, txpool.ErrNegativeValue),
+		errors.Is(err, core.ErrIntrinsicGas),
+		errors.Is(err, core.ErrTipAboveFeeCap),
+		errors.Is(err, core.ErrTipVeryHigh),
+		errors.Is(err, core.ErrFeeCapVeryHigh):
+		return true
+	default:
+		return false
+	}
+}
+
 // Drop should be called when a peer disconnects. It cleans up all the internal
 // data structures of the given node.
 func (f *TxFetcher) Drop(peer string) error {
@@ -26,6 +26,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
@@ -2114,3 +2115,28 @@ func TestTransactionForgotten(t *testing.T) {
 		t.Fatal("transaction should be known underpriced")
 	}
 }
+
+// Tests that only the transactions which are invalid regardless of the pool state
+// are reported as such on delivery, not the stale ones.
+func TestTransactionFetcherDeliveryReport(t *testing.T) {
+	errs := []error{nil, core.ErrNonceTooLow, txpool.ErrInvalidSender, core.ErrIntrinsicGas}
+	fetcher := NewTxFetcher(
+		func(common.Hash) bool { return false },
+		func(txs []*types.Transaction) []error { return errs[:len(txs)] },
+		func(string, []common.Hash) error { return nil },
+		func(string) {},
+	)
+	var added, invalid int
+	fetcher.SetDeliveryCallback(func(peer string, a, i int) {
+		added, invalid = a, i
+	})
+	fetcher.Start()
+	defer fetcher.Stop()
+
+	if err := fetcher.Enqueue("A", testTxs[:len(errs)], false); err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 || invalid != 2 {
+		t.Errorf("delivery report mismatch: have %d added, %d invalid, want 1 added, 2 invalid", added, invalid)
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// txPropagationDelayHist tracks how long after a transaction was first seen
+	// each further peer announced or delivered it.
+	txPropagationDelayHist = metrics.NewRegisteredHistogram("eth/fetcher/transaction/propagation/delay", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+	// txPropagationFetchHist tracks the time between a peer announcing a
+	// transaction and the same peer delivering it.
+	txPropagationFetchHist = metrics.NewRegisteredHistogram("eth/fetcher/transaction/propagation/fetch", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
+// TxPropagation is the propagation record of a transaction: when it was first
+// seen, and when each peer announced or delivered it.
+type TxPropagation struct {
+	Hash      common.Hash         `json:"hash"`
+	FirstSeen time.Time           `json:"firstSeen"`
+	Origin    string              `json:"origin"` // Peer the transaction was first seen from
+	Peers     []TxPeerPropagation `json:"peers"`  // Peers in the order they have seen the transaction
+}
+
+// TxPeerPropagation is the record of a transaction from a single peer. Times
+// are only set if the peer announced or delivered the transaction.
+type TxPeerPropagation struct {
+	Peer      string     `json:"peer"`
+	Announced *time.Time `json:"announced,omitempty"`
+	Delivered *time.Time `json:"delivered,omitempty"`
+	Broadcast bool       `json:"broadcast"` // Whether the delivery was an unrequested broadcast
+}
+
+// txPropagationRecord is the internal propagation record of a transaction.
+type txPropagationRecord struct {
+	first  mclock.AbsTime
+	origin string
+	peers  map[string]*txPeerRecord
+}
+
+type txPeerRecord struct {
+	first     mclock.AbsTime // Time the peer first announced or delivered the tx
+	announced mclock.AbsTime
+	delivered mclock.AbsTime
+
+	hasAnnounced bool
+	hasDelivered bool
+	broadcast    bool
+}
+
+// TxPropagationRecorder records when transactions are first announced and
+// delivered by each peer. It keeps the records of a limited number of recent
+// transactions, dropping the oldest ones beyond that.
+type TxPropagationRecorder struct {
+	clock     mclock.Clock
+	start     mclock.AbsTime // Clock reading at creation, to convert to wall time
+	startTime time.Time
+	limit     int
+
+	lock    sync.Mutex
+	records map[common.Hash]*txPropagationRecord
+	order   []common.Hash // Recorded transactions, oldest first
+}
+
+// NewTxPropagationRecorder creates a recorder which keeps the propagation
+// records of up to limit transactions.
+func NewTxPropagationRecorder(clock mclock.Clock, limit int) *TxPropagationRecorder {
+	return &TxPropagationRecorder{
+		clock:     clock,
+		start:     clock.Now(),
+		startTime: time.Now(),
+		limit:     limit,
+		records:   make(map[common.Hash]*txPropagationRecord),
+	}
+}
+
+// announced records that a peer announced a batch of transactions.
+func (r *TxPropagationRecorder) announced(peer string, hashes []common.Hash) {
+	now := r.clock.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, hash := range hashes {
+		rec := r.peerRecord(hash, peer, now)
+		if !rec.hasAnnounced {
+			rec.announced, rec.hasAnnounced = now, true
+		}
+	}
+}
+
+// delivered records that a peer delivered a batch of transactions, either as
+// a direct reply or as a broadcast.
+func (r *TxPropagationRecorder) delivered(peer string, hashes []common.Hash, direct bool) {
+	now := r.clock.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, hash := range hashes {
+		rec := r.peerRecord(hash, peer, now)
+		if rec.hasDelivered {
+			continue
+		}
+		rec.delivered, rec.hasDelivered = now, true
+		rec.broadcast = !direct
+		if rec.hasAnnounced {
+			txPropagationFetchHist.Update(int64(now.Sub(rec.announced)))
+		}
+	}
+}
+
+// peerRecord returns the record of a transaction from a peer, creating it if
+// necessary. The caller must hold the lock.
+func (r *TxPropagationRecorder) peerRecord(hash common.Hash, peer string, now mclock.AbsTime) *txPeerRecord {
+	tx := r.records[hash]
+	if tx == nil {
+		if len(r.order) >= r.limit {
+			delete(r.records, r.order[0])
+			r.order = r.order[1:]
+		}
+		tx = &txPropagationRecord{first: now, origin: peer, peers: make(map[string]*txPeerRecord)}
+		r.records[hash] = tx
+		r.order = append(r.order, hash)
+	}
+	rec := tx.peers[peer]
+	if rec == nil {
+		rec = &txPeerRecord{first: now}
+		tx.peers[peer] = rec
+		txPropagationDelayHist.Update(int64(now.Sub(tx.first)))
+	}
+	return rec
+}
+
+// Propagation returns the propagation record of a transaction, or nil if the
+// transaction hasn't been recorded.
+func (r *TxPropagationRecorder) Propagation(hash common.Hash) *TxPropagation {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	tx := r.records[hash]
+	if tx == nil {
+		return nil
+	}
+	result := &TxPropagation{
+		Hash:      hash,
+		FirstSeen: r.wallTime(tx.first),
+		Origin:    tx.origin,
+		Peers:     make([]TxPeerPropagation, 0, len(tx.peers)),
+	}
+	ids := make([]string, 0, len(tx.peers))
+	for id := range tx.peers {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		return cmp.Or(cmp.Compare(tx.peers[a].first, tx.peers[b].first), cmp.Compare(a, b))
+	})
+	for _, id := range ids {
+		rec := tx.peers[id]
+		peer := TxPeerPropagation{Peer: id, Broadcast: rec.broadcast}
+		if rec.hasAnnounced {
+			t := r.wallTime(rec.announced)
+			peer.Announced = &t
+		}
+		if rec.hasDelivered {
+			t := r.wallTime(rec.delivered)
+			peer.Delivered = &t
+		}
+		result.Peers = append(result.Peers, peer)
+	}
+	return result
+}
+
+// wallTime converts a clock reading to wall time.
+func (r *TxPropagationRecorder) wallTime(t mclock.AbsTime) time.Time {
+	return r.startTime.Add(t.Sub(r.start))
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the announcements and deliveries of transactions are recorded
+// per peer, and that old records are dropped.
+func TestTransactionPropagationRecorder(t *testing.T) {
+	clock := new(mclock.Simulated)
+	fetcher := NewTxFetcherForTests(
+		func(common.Hash) bool { return false },
+		func(txs []*types.Transaction) []error { return make([]error, len(txs)) },
+		func(string, []common.Hash) error { return nil },
+		nil, clock, nil,
+	)
+	recorder := NewTxPropagationRecorder(clock, 3)
+	fetcher.SetPropagationRecorder(recorder)
+	fetcher.Start()
+	defer fetcher.Stop()
+
+	// Peer A announces the transaction, B broadcasts it and A delivers it later.
+	if err := fetcher.Notify("A", []byte{types.LegacyTxType}, []uint32{uint32(testTxs[0].Size())}, []common.Hash{testTxsHashes[0]}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Run(100 * time.Millisecond)
+	if err := fetcher.Enqueue("B", []*types.Transaction{testTxs[0]}, false); err != nil {
+		t.Fatal(err)
+	}
+	clock.Run(50 * time.Millisecond)
+	if err := fetcher.Enqueue("A", []*types.Transaction{testTxs[0]}, true); err != nil {
+		t.Fatal(err)
+	}
+	prop := recorder.Propagation(testTxsHashes[0])
+	if prop == nil {
+		t.Fatal("transaction not recorded")
+	}
+	if prop.Origin != "A" {
+		t.Errorf("wrong origin: have %q, want %q", prop.Origin, "A")
+	}
+	if len(prop.Peers) != 2 || prop.Peers[0].Peer != "A" || prop.Peers[1].Peer != "B" {
+		t.Fatalf("wrong peers: %+v", prop.Peers)
+	}
+	a, b := prop.Peers[0], prop.Peers[1]
+	if a.Announced == nil || !a.Announced.Equal(prop.FirstSeen) {
+		t.Errorf("wrong announce time of A: %v", a.Announced)
+	}
+	if a.Delivered == nil || a.Delivered.Sub(prop.FirstSeen) != 150*time.Millisecond || a.Broadcast {
+		t.Errorf("wrong delivery of A: %v, broadcast %v", a.Delivered, a.Broadcast)
+	}
+	if b.Announced != nil {
+		t.Errorf("B has announce time %v", b.Announced)
+	}
+	if b.Delivered == nil || b.Delivered.Sub(prop.FirstSeen) != 100*time.Millisecond || !b.Broadcast {
+		t.Errorf("wrong delivery of B: %v, broadcast %v", b.Delivered, b.Broadcast)
+	}
+
+	// Record more transactions than the limit, the oldest one should be dropped.
+	if err := fetcher.Enqueue("B", testTxs[1:], false); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Propagation(testTxsHashes[0]) != nil {
+		t.Error("oldest transaction not dropped")
+	}
+	for _, hash := range testTxsHashes[1:] {
+		if recorder.Propagation(hash) == nil {
+			t.Errorf("transaction %x not recorded", hash)
+		}
+	}
+}
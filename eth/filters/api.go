@@ -224,42 +224,71 @@ func (api *FilterAPI) NewBlockFilter() rpc.ID {
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *FilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+// At the safe and finalized commitment levels, a notification is sent each time a
+// block reaches the level.
+func (api *FilterAPI) NewHeads(ctx context.Context, crit *HeadsCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	level := rpc.LatestBlockNumber
+	if crit != nil {
+		var err error
+		if level, err = commitmentBlock(crit.Commitment); err != nil {
+			return nil, err
+		}
+	}
+	if level != rpc.LatestBlockNumber {
+		return api.committedHeads(ctx, notifier, level)
+	}
 
-	rpcSub := notifier.CreateSubscription()
-
+	var (
+		rpcSub     = notifier.CreateSubscription()
+		headers    = make(chan *types.Header)
+		headersSub = api.events.SubscribeNewHeads(headers)
+		replayDone = make(chan map[common.Hash]struct{}, 1)
+	)
 	go func() {
-		headers := make(chan *types.Header)
-		headersSub := api.events.SubscribeNewHeads(headers)
 		defer headersSub.Unsubscribe()
 
-		for {
-			select {
-			case h := <-headers:
-				notifier.Notify(rpcSub.ID, h)
-			case <-rpcSub.Err():
-				return
+		forwardAfterReplay(headers, replayDone, rpcSub.Err(), func(h *types.Header, replayed map[common.Hash]struct{}) {
+			if _, ok := replayed[h.Hash()]; !ok {
+				notifier.NotifyWithCursor(rpcSub.ID, h, headCursor(h))
 			}
-		}
+		})
 	}()
+	// Replay the missed heads if the client resumes the subscription. This is
+	// done after subscribing, so that no heads are lost in between.
+	replayed, err := api.replayHeads(ctx, notifier, rpcSub.ID, api.sys.backend.CurrentHeader())
+	if err != nil {
+		close(replayDone)
+		return nil, err
+	}
+	replayDone <- replayed
 
 	return rpcSub, nil
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
+// At the safe and finalized commitment levels, logs are delivered once their block
+// reaches the level.
 func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	level, err := commitmentBlock(crit.Commitment)
+	if err != nil {
+		return nil, err
+	}
+	if level != rpc.LatestBlockNumber {
+		return api.committedLogs(ctx, notifier, crit, level)
+	}
 
 	var (
 		rpcSub      = notifier.CreateSubscription()
 		matchedLogs = make(chan []*types.Log)
+		replayDone  = make(chan map[common.Hash]struct{}, 1)
 	)
 
 	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
@@ -269,18 +298,24 @@ func (api *FilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subsc
 
 	go func() {
 		defer logsSub.Unsubscribe()
-		for {
-			select {
-			case logs := <-matchedLogs:
-				for _, log := range logs {
-					log := log
-					notifier.Notify(rpcSub.ID, &log)
+
+		forwardAfterReplay(matchedLogs, replayDone, rpcSub.Err(), func(logs []*types.Log, replayed map[common.Hash]struct{}) {
+			for _, log := range logs {
+				if _, ok := replayed[log.BlockHash]; ok && !log.Removed {
+					continue
 				}
-			case <-rpcSub.Err(): // client send an unsubscribe request
-				return
+				notifyLog(notifier, rpcSub.ID, log)
 			}
-		}
+		})
 	}()
+	// Replay the missed logs if the client resumes the subscription. This is
+	// done after subscribing, so that no logs are lost in between.
+	replayed, err := api.replayLogs(ctx, notifier, rpcSub.ID, crit, api.sys.backend.CurrentHeader())
+	if err != nil {
+		close(replayDone)
+		return nil, err
+	}
+	replayDone <- replayed
 
 	return rpcSub, nil
 }
@@ -299,8 +334,21 @@ type FilterCriteria ethereum.FilterQuery
 // In case logs are removed (chain reorg) previously returned logs are returned
 // again but with the removed property set to true.
 //
+// At the safe and finalized commitment levels, logs are returned once their block
+// reaches the level.
+//
 // In case "fromBlock" > "toBlock" an error is returned.
 func (api *FilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
+	level, err := commitmentBlock(crit.Commitment)
+	if err != nil {
+		return "", err
+	}
+	if level != rpc.LatestBlockNumber {
+		if err := checkCommittedCriteria(crit); err != nil {
+			return "", err
+		}
+		return api.newCommittedFilter(crit, level), nil
+	}
 	logs := make(chan []*types.Log)
 	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), logs)
 	if err != nil {
@@ -354,6 +402,17 @@ func (api *FilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*type
 		if begin > 0 && end > 0 && begin > end {
 			return nil, errInvalidBlockRange
 		}
+		// Only return the logs of blocks at the commitment level
+		level, err := commitmentBlock(crit.Commitment)
+		if err != nil {
+			return nil, err
+		}
+		if begin == rpc.LatestBlockNumber.Int64() {
+			begin = level.Int64()
+		}
+		if end, err = api.commitmentEnd(ctx, end, level); err != nil {
+			return nil, err
+		}
 		// Construct the range filter
 		filter = api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics)
 	}
@@ -405,6 +464,17 @@ func (api *FilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*types.Lo
 		if f.crit.ToBlock != nil {
 			end = f.crit.ToBlock.Int64()
 		}
+		// Only return the logs of blocks at the commitment level
+		level, err := commitmentBlock(f.crit.Commitment)
+		if err != nil {
+			return nil, err
+		}
+		if begin == rpc.LatestBlockNumber.Int64() {
+			begin = level.Int64()
+		}
+		if end, err = api.commitmentEnd(ctx, end, level); err != nil {
+			return nil, err
+		}
 		// Construct the range filter
 		filter = api.sys.NewRangeFilter(begin, end, f.crit.Addresses, f.crit.Topics)
 	}
@@ -488,11 +558,12 @@ func returnLogs(logs []*types.Log) []*types.Log {
 // UnmarshalJSON sets *args fields with given data.
 func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
 	type input struct {
-		BlockHash *common.Hash     `json:"blockHash"`
-		FromBlock *rpc.BlockNumber `json:"fromBlock"`
-		ToBlock   *rpc.BlockNumber `json:"toBlock"`
-		Addresses interface{}      `json:"address"`
-		Topics    []interface{}    `json:"topics"`
+		BlockHash  *common.Hash     `json:"blockHash"`
+		FromBlock  *rpc.BlockNumber `json:"fromBlock"`
+		ToBlock    *rpc.BlockNumber `json:"toBlock"`
+		Addresses  interface{}      `json:"address"`
+		Topics     []interface{}    `json:"topics"`
+		Commitment string           `json:"commitment"`
 	}
 
 	var raw input
@@ -516,6 +587,11 @@ func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	if _, err := commitmentBlock(raw.Commitment); err != nil {
+		return err
+	}
+	args.Commitment = raw.Commitment
+
 	args.Addresses = []common.Address{}
 
 	if raw.Addresses != nil {
@@ -0,0 +1,330 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Log filters and subscriptions can choose the commitment level of the blocks
+// whose logs they receive. At the latest level, logs are delivered as blocks are
+// imported, and delivered again marked as removed if their block is reorged out.
+// At the safe and finalized levels, logs are only delivered once their block has
+// become safe or finalized. Head subscriptions at these levels notify each block
+// reaching the level, signaling the finality transitions.
+//
+// The commitment levels are checked whenever a new head is imported. Finalized
+// blocks can't be reorged, but the safe block may be: the logs of blocks which are
+// no longer safe are then delivered again, marked as removed.
+const (
+	CommitmentLatest    = "latest"
+	CommitmentSafe      = "safe"
+	CommitmentFinalized = "finalized"
+)
+
+var errInvalidCommitment = errors.New(`invalid commitment level, expected "latest", "safe" or "finalized"`)
+
+// commitmentBlock returns the block number tag of a commitment level.
+func commitmentBlock(commitment string) (rpc.BlockNumber, error) {
+	switch commitment {
+	case "", CommitmentLatest:
+		return rpc.LatestBlockNumber, nil
+	case CommitmentSafe:
+		return rpc.SafeBlockNumber, nil
+	case CommitmentFinalized:
+		return rpc.FinalizedBlockNumber, nil
+	default:
+		return 0, errInvalidCommitment
+	}
+}
+
+// HeadsCriteria holds the options of head subscriptions.
+type HeadsCriteria struct {
+	Commitment string `json:"commitment"` // Level of the notified blocks, latest by default
+}
+
+// commitmentFollower tracks the block of a safe or finalized commitment level,
+// reporting the chain segments reaching the level.
+type commitmentFollower struct {
+	api   *FilterAPI
+	level rpc.BlockNumber
+	last  *types.Header // Block of the level last reported, nil if unknown yet
+}
+
+// newCommitmentFollower creates a follower of the commitment level, starting at
+// its current block.
+func (api *FilterAPI) newCommitmentFollower(ctx context.Context, level rpc.BlockNumber) *commitmentFollower {
+	f := &commitmentFollower{api: api, level: level}
+	f.last = f.current(ctx)
+	return f
+}
+
+// current returns the block of the level, or nil if the node doesn't know it,
+// such as before the merge or while syncing.
+func (f *commitmentFollower) current(ctx context.Context) *types.Header {
+	header, err := f.api.sys.backend.HeaderByNumber(ctx, f.level)
+	if err != nil {
+		return nil
+	}
+	return header
+}
+
+// advance returns the chain segment which reached the level since the last call,
+// or nil if the level didn't change. The first block known for the level is not
+// reported, it only marks the start of the segments.
+//
+// Segments span at most the resume window of canonical blocks. If the level moved
+// further, the follower only advances by the window, and the rest of the blocks
+// are reported by the subsequent calls.
+func (f *commitmentFollower) advance(ctx context.Context) *resumePoint {
+	header := f.current(ctx)
+	if header == nil {
+		return nil
+	}
+	if f.last == nil {
+		f.last = header
+		return nil
+	}
+	if header.Hash() == f.last.Hash() {
+		return nil
+	}
+	window := f.api.sys.cfg.ResumeWindow
+	if number := f.last.Number.Uint64() + window; header.Number.Uint64() > number {
+		chunk, err := f.api.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil || chunk == nil {
+			return nil
+		}
+		header = chunk
+	}
+	// The walk is allowed twice the window, to fit the blocks of the last reported
+	// segment which were reorged out.
+	point, err := f.api.resumePoint(ctx, f.last.Hash(), header, 2*window)
+	if errors.Is(err, errCursorTooOld) {
+		// The reorg is too deep to follow, skip ahead without reporting it.
+		log.Warn("Commitment level reorged too deep", "level", f.level, "from", f.last.Number, "to", header.Number)
+		f.last = header
+		return nil
+	}
+	if err != nil {
+		// Retried on the next head, the level stays at the last reported block.
+		log.Debug("Failed to follow commitment level", "level", f.level, "from", f.last.Number, "to", header.Number, "err", err)
+		return nil
+	}
+	f.last = header
+	return point
+}
+
+// logs returns the logs matching the criteria of the next chain segment which
+// reached the level, or false if the level didn't advance.
+func (f *commitmentFollower) logs(ctx context.Context, crit FilterCriteria) ([]*types.Log, bool) {
+	last := f.last
+	point := f.advance(ctx)
+	if point == nil {
+		return nil, false
+	}
+	logs, err := f.api.segmentLogs(ctx, point, math.MaxUint, crit)
+	if err != nil {
+		log.Debug("Failed to retrieve logs of commitment level", "level", f.level, "err", err)
+		f.last = last
+		return nil, false
+	}
+	return logs, true
+}
+
+// commitmentEnd bounds the end of a log query to the block of the commitment level.
+func (api *FilterAPI) commitmentEnd(ctx context.Context, end int64, level rpc.BlockNumber) (int64, error) {
+	switch {
+	case level == rpc.LatestBlockNumber:
+		return end, nil
+	case end == rpc.LatestBlockNumber.Int64() || end == rpc.SafeBlockNumber.Int64():
+		// The safe block is at or above the finalized one.
+		return level.Int64(), nil
+	case end < 0:
+		return end, nil
+	}
+	header, err := api.sys.backend.HeaderByNumber(ctx, level)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, fmt.Errorf("%s header not found", level)
+	}
+	return min(end, header.Number.Int64()), nil
+}
+
+// checkCommittedCriteria validates the criteria of committed log filters, which
+// deliver the logs of new blocks only.
+func checkCommittedCriteria(crit FilterCriteria) error {
+	if len(crit.Topics) > maxTopics {
+		return errExceedMaxTopics
+	}
+	if crit.BlockHash != nil {
+		return errInvalidBlockRange
+	}
+	for _, number := range []*big.Int{crit.FromBlock, crit.ToBlock} {
+		if number != nil && number.Int64() == rpc.PendingBlockNumber.Int64() {
+			return errPendingLogsUnsupported
+		}
+	}
+	return nil
+}
+
+// subscribeHeadSignal subscribes to new heads, signaling them on a channel with a
+// single slot. Heads arriving while the receiver is busy following a commitment
+// level are coalesced into one signal. The heads are consumed as they arrive, so
+// a slow follower doesn't hold up the event system and its other subscriptions.
+func (api *FilterAPI) subscribeHeadSignal() (*Subscription, <-chan struct{}) {
+	var (
+		headers = make(chan *types.Header)
+		signal  = make(chan struct{}, 1)
+		sub     = api.events.SubscribeNewHeads(headers)
+	)
+	go func() {
+		for {
+			select {
+			case <-headers:
+				select {
+				case signal <- struct{}{}:
+				default:
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, signal
+}
+
+// committedHeads creates a subscription notifying the blocks reaching the safe or
+// finalized commitment level.
+func (api *FilterAPI) committedHeads(ctx context.Context, notifier *rpc.Notifier, level rpc.BlockNumber) (*rpc.Subscription, error) {
+	var (
+		rpcSub   = notifier.CreateSubscription()
+		follower = api.newCommitmentFollower(ctx, level)
+	)
+	// Replay the blocks which reached the level since the cursor, if the client
+	// resumes the subscription. The follower delivers the later ones.
+	if _, err := api.replayHeads(ctx, notifier, rpcSub.ID, follower.last); err != nil {
+		return nil, err
+	}
+	go func() {
+		headersSub, heads := api.subscribeHeadSignal()
+		defer headersSub.Unsubscribe()
+
+		for {
+			select {
+			case <-heads:
+				for point := follower.advance(context.Background()); point != nil; point = follower.advance(context.Background()) {
+					for _, header := range point.missed {
+						notifier.NotifyWithCursor(rpcSub.ID, header, headCursor(header))
+					}
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// committedLogs creates a subscription delivering the logs matching the criteria
+// once their block reaches the safe or finalized commitment level.
+func (api *FilterAPI) committedLogs(ctx context.Context, notifier *rpc.Notifier, crit FilterCriteria, level rpc.BlockNumber) (*rpc.Subscription, error) {
+	if err := checkCommittedCriteria(crit); err != nil {
+		return nil, err
+	}
+	var (
+		rpcSub   = notifier.CreateSubscription()
+		follower = api.newCommitmentFollower(ctx, level)
+	)
+	// Replay the logs of the blocks which reached the level since the cursor, if
+	// the client resumes the subscription. The follower delivers the later ones.
+	if _, err := api.replayLogs(ctx, notifier, rpcSub.ID, crit, follower.last); err != nil {
+		return nil, err
+	}
+	go func() {
+		headersSub, heads := api.subscribeHeadSignal()
+		defer headersSub.Unsubscribe()
+
+		for {
+			select {
+			case <-heads:
+				for {
+					logs, ok := follower.logs(context.Background(), crit)
+					if !ok {
+						break
+					}
+					for _, log := range filterLogs(logs, crit.FromBlock, crit.ToBlock, nil, nil) {
+						notifyLog(notifier, rpcSub.ID, log)
+					}
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// newCommittedFilter creates a log filter collecting the logs matching the criteria
+// once their block reaches the safe or finalized commitment level.
+func (api *FilterAPI) newCommittedFilter(crit FilterCriteria, level rpc.BlockNumber) rpc.ID {
+	var (
+		follower         = api.newCommitmentFollower(context.Background(), level)
+		headerSub, heads = api.subscribeHeadSignal()
+	)
+	api.filtersMu.Lock()
+	api.filters[headerSub.ID] = &filter{typ: LogsSubscription, crit: crit, deadline: time.NewTimer(api.timeout), logs: make([]*types.Log, 0), s: headerSub}
+	api.filtersMu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case <-heads:
+				for {
+					logs, ok := follower.logs(context.Background(), crit)
+					if !ok {
+						break
+					}
+					if logs = filterLogs(logs, crit.FromBlock, crit.ToBlock, nil, nil); len(logs) == 0 {
+						continue
+					}
+					api.filtersMu.Lock()
+					if f, found := api.filters[headerSub.ID]; found {
+						f.logs = append(f.logs, logs...)
+					}
+					api.filtersMu.Unlock()
+				}
+			case <-headerSub.Err():
+				api.filtersMu.Lock()
+				delete(api.filters, headerSub.ID)
+				api.filtersMu.Unlock()
+				return
+			}
+		}
+	}()
+	return headerSub.ID
+}
@@ -0,0 +1,244 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that log filters at the safe and finalized commitment levels only return
+// the logs of blocks which reached the level, and remove the logs of blocks which
+// are no longer safe.
+func TestCommittedLogFilter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{})
+		api          = NewFilterAPI(sys)
+		addr         = common.HexToAddress("0x1234")
+		gspec        = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		addLogs = func(i int, gen *core.BlockGen) {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr}, {Address: addr}}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(999, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+		}
+	)
+	gendb, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, addLogs)
+	fork, forkReceipts := core.GenerateChain(gspec.Config, chain[4], ethash.NewFaker(), gendb, 2, func(i int, gen *core.BlockGen) {
+		gen.SetExtra([]byte("fork"))
+		addLogs(i, gen)
+	})
+	insert := func(blocks []*types.Block, receipts []types.Receipts) {
+		for i, block := range blocks {
+			rawdb.WriteBlock(db, block)
+			rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+			rawdb.WriteHeadBlockHash(db, block.Hash())
+			rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+		}
+	}
+	// Start on the fork, with its first block safe and the second block finalized.
+	insert(chain[:5], receipts[:5])
+	insert(fork, forkReceipts)
+	backend.safe = fork[0].Header()
+	rawdb.WriteFinalizedBlockHash(db, chain[1].Hash())
+
+	crit := func(commitment string) FilterCriteria {
+		return FilterCriteria{Addresses: []common.Address{addr}, Commitment: commitment}
+	}
+	safeID, err := api.NewFilter(crit(CommitmentSafe))
+	if err != nil {
+		t.Fatalf("failed to create safe filter: %v", err)
+	}
+	finalizedID, err := api.NewFilter(crit(CommitmentFinalized))
+	if err != nil {
+		t.Fatalf("failed to create finalized filter: %v", err)
+	}
+	if _, err := api.NewFilter(crit("justified")); err != errInvalidCommitment {
+		t.Fatalf("invalid commitment error mismatch: have %v, want %v", err, errInvalidCommitment)
+	}
+	// Reorg to the canonical chain, advancing both levels.
+	insert(chain[5:], receipts[5:])
+	backend.safe = chain[7].Header()
+	rawdb.WriteFinalizedBlockHash(db, chain[3].Hash())
+	backend.chainFeed.Send(core.ChainEvent{Block: chain[9], Hash: chain[9].Hash()})
+
+	logSummary := func(block *types.Block, index int, removed bool) string {
+		return fmt.Sprintf("%d:%x:%d:%v", block.NumberU64(), block.Hash(), index, removed)
+	}
+	var wantSafe, wantFinalized []string
+	wantSafe = append(wantSafe, logSummary(fork[0], 1, true), logSummary(fork[0], 0, true))
+	for _, block := range chain[5:8] {
+		wantSafe = append(wantSafe, logSummary(block, 0, false), logSummary(block, 1, false))
+	}
+	for _, block := range chain[2:4] {
+		wantFinalized = append(wantFinalized, logSummary(block, 0, false), logSummary(block, 1, false))
+	}
+	for _, tt := range []struct {
+		name string
+		id   rpc.ID
+		want []string
+	}{
+		{"safe", safeID, wantSafe},
+		{"finalized", finalizedID, wantFinalized},
+	} {
+		var have []string
+		for deadline := time.Now().Add(5 * time.Second); len(have) < len(tt.want) && time.Now().Before(deadline); {
+			changes, err := api.GetFilterChanges(tt.id)
+			if err != nil {
+				t.Fatalf("%s: failed to get filter changes: %v", tt.name, err)
+			}
+			for _, log := range changes.([]*types.Log) {
+				block := rawdb.ReadBlock(db, log.BlockHash, log.BlockNumber)
+				have = append(have, logSummary(block, int(log.Index), log.Removed))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if fmt.Sprint(have) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: logs mismatch:\nhave %v\nwant %v", tt.name, have, tt.want)
+		}
+	}
+	// Check that queries are bounded to the commitment level.
+	logs, err := api.GetLogs(context.Background(), crit(CommitmentFinalized))
+	if err != nil {
+		t.Fatalf("failed to get finalized logs: %v", err)
+	}
+	if len(logs) != 2 || logs[0].BlockHash != chain[3].Hash() {
+		t.Errorf("latest finalized logs mismatch: have %d", len(logs))
+	}
+	query := crit(CommitmentSafe)
+	query.FromBlock, query.ToBlock = big.NewInt(1), big.NewInt(10)
+	if logs, err = api.GetLogs(context.Background(), query); err != nil {
+		t.Fatalf("failed to get safe logs: %v", err)
+	}
+	if last := logs[len(logs)-1].BlockNumber; len(logs) != 16 || last != 8 {
+		t.Errorf("safe logs mismatch: have %d up to block %d, want 16 up to block 8", len(logs), last)
+	}
+}
+
+// Tests that the commitment level is followed in chunks of the resume window if
+// it advances further at once.
+func TestCommitmentFollowerChunks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db           = rawdb.NewMemoryDatabase()
+		backend, sys = newTestFilterSystem(t, db, Config{ResumeWindow: 3})
+		api          = NewFilterAPI(sys)
+		gspec        = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, chain, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, nil)
+	for _, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+	}
+	backend.safe = chain[0].Header()
+	follower := api.newCommitmentFollower(context.Background(), rpc.SafeBlockNumber)
+
+	backend.safe = chain[9].Header()
+	var have []uint64
+	for point := follower.advance(context.Background()); point != nil; point = follower.advance(context.Background()) {
+		if len(point.missed) > 3 {
+			t.Fatalf("segment too long: %d blocks", len(point.missed))
+		}
+		for _, header := range point.missed {
+			have = append(have, header.Number.Uint64())
+		}
+	}
+	if want := []uint64{2, 3, 4, 5, 6, 7, 8, 9, 10}; fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Errorf("reported blocks mismatch: have %v, want %v", have, want)
+	}
+}
+
+// slowSafeBackend is a backend which blocks the retrieval of the safe block while
+// stalled.
+type slowSafeBackend struct {
+	*testBackend
+	stalled atomic.Bool
+	release chan struct{}
+}
+
+func (b *slowSafeBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.SafeBlockNumber && b.stalled.Load() {
+		<-b.release
+	}
+	return b.testBackend.HeaderByNumber(ctx, number)
+}
+
+// Tests that a commitment level follower which is slow to process a head doesn't
+// block the delivery of heads to other subscriptions.
+func TestCommittedFilterSlowBackend(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &slowSafeBackend{testBackend: &testBackend{db: db}, release: make(chan struct{})}
+		sys     = NewFilterSystem(backend, Config{})
+		api     = NewFilterAPI(sys)
+		gspec   = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, chain, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, nil)
+	backend.safe = chain[0].Header()
+	api.newCommittedFilter(FilterCriteria{}, rpc.SafeBlockNumber)
+
+	headers := make(chan *types.Header)
+	sub := api.events.SubscribeNewHeads(headers)
+	defer sub.Unsubscribe()
+	defer close(backend.release)
+
+	backend.stalled.Store(true)
+	go func() {
+		for _, block := range chain {
+			backend.chainFeed.Send(core.ChainEvent{Hash: block.Hash(), Block: block})
+		}
+	}()
+	for i, block := range chain {
+		select {
+		case header := <-headers:
+			if header.Hash() != block.Hash() {
+				t.Fatalf("head %d mismatch: have %x, want %x", i, header.Hash(), block.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("head %d not delivered while the committed filter is stalled", i)
+		}
+	}
+}
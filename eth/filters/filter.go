@@ -24,7 +24,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -174,22 +176,33 @@ func (f *Filter) rangeLogsAsync(ctx context.Context) (chan *types.Log, chan erro
 			close(logChan)
 		}()
 
-		// Gather all indexed logs, and finish with non indexed ones
+		// Gather all indexed logs, and finish with non indexed ones. Blocks
+		// below the history cutoff of the log index fall back to the bloombits.
 		var (
-			end            = uint64(f.end)
-			size, sections = f.sys.backend.BloomStatus()
-			err            error
+			end                  = uint64(f.end)
+			size, sections, tail = f.sys.backend.LogIndexStatus()
+			head                 = sections * size
 		)
-		if indexed := sections * size; indexed > uint64(f.begin) {
-			if indexed > end {
-				indexed = end + 1
+		if head <= tail || uint64(f.begin) < tail {
+			limit := end
+			if head > tail && tail-1 < limit {
+				limit = tail - 1
 			}
-			if err = f.indexedLogs(ctx, indexed-1, logChan); err != nil {
+			if err := f.bloomLogs(ctx, limit, logChan); err != nil {
+				errChan <- err
+				return
+			}
+		}
+		if head > tail && uint64(f.begin) < head && f.begin <= f.end && f.logIndexable() {
+			limit := end
+			if head-1 < limit {
+				limit = head - 1
+			}
+			if err := f.logIndexLogs(ctx, size, limit, logChan); err != nil {
 				errChan <- err
 				return
 			}
 		}
-
 		if err := f.unindexedLogs(ctx, end, logChan); err != nil {
 			errChan <- err
 			return
@@ -201,6 +214,115 @@ func (f *Filter) rangeLogsAsync(ctx context.Context) (chan *types.Log, chan erro
 	return logChan, errChan
 }
 
+// bloomLogs returns the logs matching the filter criteria up to the given block,
+// based on the bloom bits where available and raw block iteration otherwise.
+func (f *Filter) bloomLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
+	size, sections := f.sys.backend.BloomStatus()
+	if indexed := sections * size; indexed > uint64(f.begin) {
+		if indexed > end {
+			indexed = end + 1
+		}
+		if err := f.indexedLogs(ctx, indexed-1, logChan); err != nil {
+			return err
+		}
+	}
+	return f.unindexedLogs(ctx, end, logChan)
+}
+
+// logIndexable reports whether the filter has any address or topic criteria to
+// look up in the log index. Wildcard filters match every log, so they are served
+// by raw block iteration instead.
+func (f *Filter) logIndexable() bool {
+	if len(f.addresses) > 0 {
+		return true
+	}
+	for _, sub := range f.topics {
+		if len(sub) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// logIndexLogs returns the logs matching the filter criteria based on the log
+// index maintained locally.
+func (f *Filter) logIndexLogs(ctx context.Context, size uint64, end uint64, logChan chan *types.Log) error {
+	db := f.sys.backend.ChainDb()
+	for section := uint64(f.begin) / size; section <= end/size; section++ {
+		for _, number := range f.logIndexMatches(db, section) {
+			if number < uint64(f.begin) || number > end {
+				continue
+			}
+			header, err := f.sys.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if header == nil || err != nil {
+				return err
+			}
+			found, err := f.checkMatches(ctx, header)
+			if err != nil {
+				return err
+			}
+			for _, log := range found {
+				select {
+				case logChan <- log:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			f.begin = int64(number) + 1
+		}
+		f.begin = int64(min((section+1)*size, end+1))
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logIndexMatches looks up the postings of all filter criteria in the given log
+// index section and returns the sorted numbers of the blocks containing logs
+// which satisfy all of them.
+func (f *Filter) logIndexMatches(db ethdb.Iteratee, section uint64) []uint64 {
+	var (
+		matches map[rawdb.LogIndexEntry]struct{}
+		first   = true
+	)
+	match := func(field byte, values [][]byte) {
+		found := make(map[rawdb.LogIndexEntry]struct{})
+		for _, value := range values {
+			for _, entry := range rawdb.ReadLogIndexEntries(db, section, field, value) {
+				if _, ok := matches[entry]; first || ok {
+					found[entry] = struct{}{}
+				}
+			}
+		}
+		matches, first = found, false
+	}
+	if len(f.addresses) > 0 {
+		values := make([][]byte, len(f.addresses))
+		for i, address := range f.addresses {
+			values[i] = address.Bytes()
+		}
+		match(0, values)
+	}
+	for i, sub := range f.topics {
+		if len(sub) == 0 || (!first && len(matches) == 0) {
+			continue
+		}
+		values := make([][]byte, len(sub))
+		for j, topic := range sub {
+			values[j] = topic.Bytes()
+		}
+		match(byte(i+1), values)
+	}
+	numbers := make([]uint64, 0, len(matches))
+	for entry := range matches {
+		numbers = append(numbers, entry.Number)
+	}
+	slices.Sort(numbers)
+	return slices.Compact(numbers)
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64, logChan chan *types.Log) error {
@@ -42,6 +42,7 @@ import (
 type Config struct {
 	LogCacheSize int           // maximum number of cached blocks (default: 32)
 	Timeout      time.Duration // how long filters stay active (default: 5min)
+	ResumeWindow uint64        // maximum number of blocks replayed when resuming subscriptions (default: 128)
 }
 
 func (cfg Config) withDefaults() Config {
@@ -51,6 +52,9 @@ func (cfg Config) withDefaults() Config {
 	if cfg.LogCacheSize == 0 {
 		cfg.LogCacheSize = 32
 	}
+	if cfg.ResumeWindow == 0 {
+		cfg.ResumeWindow = 128
+	}
 	return cfg
 }
 
@@ -71,6 +75,10 @@ type Backend interface {
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
+
+	// LogIndexStatus returns the section size and the number of sections of the
+	// log index, along with the first block still covered by it.
+	LogIndexStatus() (uint64, uint64, uint64)
 }
 
 // FilterSystem holds resources shared by all filters.
@@ -42,12 +42,15 @@ import (
 type testBackend struct {
 	db              ethdb.Database
 	sections        uint64
+	logIndexer      *core.ChainIndexer
+	logIndexSize    uint64
 	txFeed          event.Feed
 	logsFeed        event.Feed
 	rmLogsFeed      event.Feed
 	chainFeed       event.Feed
 	pendingBlock    *types.Block
 	pendingReceipts types.Receipts
+	safe            *types.Header
 }
 
 func (b *testBackend) ChainConfig() *params.ChainConfig {
@@ -84,7 +87,10 @@ func (b *testBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumbe
 		}
 		num = *number
 	case rpc.SafeBlockNumber:
-		return nil, errors.New("safe block not found")
+		if b.safe == nil {
+			return nil, errors.New("safe block not found")
+		}
+		return b.safe, nil
 	default:
 		num = uint64(blockNr)
 		hash = rawdb.ReadCanonicalHash(b.db, num)
@@ -168,6 +174,18 @@ func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.Matc
 	}()
 }
 
+func (b *testBackend) LogIndexStatus() (uint64, uint64, uint64) {
+	if b.logIndexer == nil {
+		return b.logIndexSize, 0, 0
+	}
+	sections, _, _ := b.logIndexer.Sections()
+	var tail uint64
+	if number := rawdb.ReadLogIndexTail(b.db); number != nil {
+		tail = *number
+	}
+	return b.logIndexSize, sections, tail
+}
+
 func (b *testBackend) setPending(block *types.Block, receipts types.Receipts) {
 	b.pendingBlock = block
 	b.pendingReceipts = receipts
@@ -387,3 +387,122 @@ func TestFilters(t *testing.T) {
 		}
 	})
 }
+
+func TestLogIndexFilters(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		signer  = types.NewLondonSigner(big.NewInt(1))
+		loggers = []common.Address{{0xfe}, {0xff}}
+
+		// LOG2(0, 0, calldata[0:32], calldata[32:64])
+		code = []byte{
+			byte(vm.PUSH1), 0x20, byte(vm.CALLDATALOAD),
+			byte(vm.PUSH1), 0x00, byte(vm.CALLDATALOAD),
+			byte(vm.PUSH1), 0x00, byte(vm.PUSH1), 0x00,
+			byte(vm.LOG2), byte(vm.STOP),
+		}
+		topics = []common.Hash{
+			common.BytesToHash([]byte("topic1")),
+			common.BytesToHash([]byte("topic2")),
+			common.BytesToHash([]byte("topic3")),
+		}
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				addr:       {Balance: big.NewInt(0).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+				loggers[0]: {Balance: big.NewInt(0), Code: code},
+				loggers[1]: {Balance: big.NewInt(0), Code: code},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		db = rawdb.NewMemoryDatabase()
+	)
+	if _, err := gspec.Commit(db, triedb.NewDatabase(db, nil)); err != nil {
+		t.Fatal(err)
+	}
+	// Emit logs with varying emitters and topic combinations in every third block
+	var nonce uint64
+	chain, _ := core.GenerateChain(gspec.Config, gspec.ToBlock(), ethash.NewFaker(), db, 100, func(i int, gen *core.BlockGen) {
+		if i%3 != 0 {
+			return
+		}
+		for j := 0; j < 2; j++ {
+			data := append(topics[(i+j)%len(topics)].Bytes(), topics[(i/3+j)%len(topics)].Bytes()...)
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+				Nonce:    nonce,
+				GasPrice: gen.BaseFee(),
+				Gas:      30000,
+				To:       &loggers[(i/2+j)%len(loggers)],
+				Data:     data,
+			}), signer, key)
+			gen.AddTx(tx)
+			nonce++
+		}
+	})
+	var l uint64
+	bc, err := core.NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, &l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop()
+
+	if _, err := bc.InsertChain(chain); err != nil {
+		t.Fatal(err)
+	}
+	// Index all full sections of the chain, keeping only the recent history
+	indexer := core.NewLogIndexer(db, 8, 0, 60)
+	defer indexer.Close()
+	indexer.Start(bc)
+
+	for start := time.Now(); ; {
+		if sections, _, _ := indexer.Sections(); sections == 12 {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatal("log index not generated in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if tail := rawdb.ReadLogIndexTail(db); tail == nil || *tail != 40 {
+		t.Fatalf("log index tail mismatch: have %v, want 40", tail)
+	}
+	var (
+		backend, indexed = newTestFilterSystem(t, db, Config{})
+		_, unindexed     = newTestFilterSystem(t, db, Config{})
+	)
+	backend.logIndexer, backend.logIndexSize = indexer, 8
+
+	for i, tc := range []struct {
+		begin, end int64
+		addresses  []common.Address
+		topics     [][]common.Hash
+	}{
+		{0, 100, []common.Address{loggers[0]}, nil},
+		{0, 100, nil, [][]common.Hash{{topics[0]}}},
+		{0, 100, nil, [][]common.Hash{nil, {topics[1]}}},
+		{0, 100, loggers, [][]common.Hash{{topics[0], topics[2]}, {topics[1]}}},
+		{30, 70, []common.Address{loggers[1]}, [][]common.Hash{{topics[1]}}},
+		{45, 50, nil, [][]common.Hash{{topics[1]}}},
+		{90, 100, []common.Address{loggers[0]}, nil},
+		{0, 100, []common.Address{{0x01}}, nil},
+		{0, 100, nil, [][]common.Hash{{topics[0]}, {topics[0]}, {topics[0]}}},
+		{0, 100, nil, nil},
+	} {
+		want, err := unindexed.NewRangeFilter(tc.begin, tc.end, tc.addresses, tc.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: unindexed filter failed: %v", i, err)
+		}
+		have, err := indexed.NewRangeFilter(tc.begin, tc.end, tc.addresses, tc.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: indexed filter failed: %v", i, err)
+		}
+		haveJSON, _ := json.Marshal(have)
+		wantJSON, _ := json.Marshal(want)
+		if string(haveJSON) != string(wantJSON) {
+			t.Fatalf("test %d: logs mismatch\nhave: %s\nwant: %s", i, haveJSON, wantJSON)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Subscriptions to new heads and logs can be resumed by clients after losing their
+// connection. Every notification carries a cursor, the hash of the header for heads,
+// and the hash of the block along with the index of the log for logs. Clients pass
+// the cursor of the last notification received when resuming, and the notifications
+// missed since are replayed from the chain, as long as the cursor is within the
+// resume window of the current head.
+//
+// If the block of the cursor has been reorged out, the replay starts after the
+// common ancestor with the canonical chain. Log subscriptions first receive the
+// logs of the reorged blocks again, marked as removed.
+
+var (
+	errInvalidCursor = errors.New("invalid subscription cursor")
+	errUnknownCursor = errors.New("unknown subscription cursor")
+	errCursorTooOld  = errors.New("subscription cursor too old to resume")
+)
+
+// headCursor returns the cursor of a head notification.
+func headCursor(header *types.Header) string {
+	return header.Hash().Hex()
+}
+
+// parseHeadCursor decodes the cursor of a head notification.
+func parseHeadCursor(cursor string) (common.Hash, error) {
+	var hash common.Hash
+	if err := hash.UnmarshalText([]byte(cursor)); err != nil {
+		return common.Hash{}, errInvalidCursor
+	}
+	return hash, nil
+}
+
+// logCursor returns the cursor of a log notification.
+func logCursor(log *types.Log) string {
+	return fmt.Sprintf("%s:%d", log.BlockHash.Hex(), log.Index)
+}
+
+// parseLogCursor decodes the cursor of a log notification.
+func parseLogCursor(cursor string) (common.Hash, uint, error) {
+	hashHex, indexStr, ok := strings.Cut(cursor, ":")
+	if !ok {
+		return common.Hash{}, 0, errInvalidCursor
+	}
+	hash, err := parseHeadCursor(hashHex)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	index, err := strconv.ParseUint(indexStr, 10, 32)
+	if err != nil {
+		return common.Hash{}, 0, errInvalidCursor
+	}
+	return hash, uint(index), nil
+}
+
+// resumePoint is the chain segment a client missed since the block of its cursor.
+type resumePoint struct {
+	cursor   *types.Header   // Block of the cursor
+	orphaned []*types.Header // Blocks reorged out since the cursor, newest first
+	missed   []*types.Header // Canonical blocks after the common ancestor, up to the head
+}
+
+// canonical reports whether the block of the cursor is still canonical.
+func (p *resumePoint) canonical() bool {
+	return len(p.orphaned) == 0
+}
+
+// replayed returns the hashes of the canonical blocks replayed to the client, which
+// must not be sent again when delivered by the live subscription.
+func (p *resumePoint) replayed() map[common.Hash]struct{} {
+	hashes := make(map[common.Hash]struct{}, len(p.missed))
+	for _, header := range p.missed {
+		hashes[header.Hash()] = struct{}{}
+	}
+	return hashes
+}
+
+// resumePoint finds the blocks missed by a client since the block of its cursor,
+// up to the given canonical block, which are at most window blocks.
+func (api *FilterAPI) resumePoint(ctx context.Context, hash common.Hash, head *types.Header, window uint64) (*resumePoint, error) {
+	backend := api.sys.backend
+	header, err := backend.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, errUnknownCursor
+	}
+	// Walk back from the cursor until reaching the canonical chain.
+	point := &resumePoint{cursor: header}
+	for {
+		canon, _ := backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+		if canon != nil && canon.Hash() == header.Hash() {
+			break
+		}
+		if uint64(len(point.orphaned)) == window {
+			return nil, errCursorTooOld
+		}
+		point.orphaned = append(point.orphaned, header)
+		if header, err = backend.HeaderByHash(ctx, header.ParentHash); err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errUnknownCursor
+		}
+	}
+	ancestor := header.Number.Uint64()
+	if head.Number.Uint64() > ancestor && head.Number.Uint64()-ancestor > window {
+		return nil, errCursorTooOld
+	}
+	for number := ancestor + 1; number <= head.Number.Uint64(); number++ {
+		header, err := backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			break // Chain rewound meanwhile
+		}
+		point.missed = append(point.missed, header)
+	}
+	return point, nil
+}
+
+// replayHeads sends the heads missed by a client resuming the subscription from
+// a cursor, up to the given head, returning the hashes of the replayed blocks.
+// Nothing is replayed for new subscriptions.
+func (api *FilterAPI) replayHeads(ctx context.Context, notifier *rpc.Notifier, id rpc.ID, head *types.Header) (map[common.Hash]struct{}, error) {
+	cursor := notifier.ResumeCursor()
+	if cursor == "" || head == nil {
+		return nil, nil
+	}
+	hash, err := parseHeadCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	point, err := api.resumePoint(ctx, hash, head, api.sys.cfg.ResumeWindow)
+	if err != nil {
+		return nil, err
+	}
+	for _, header := range point.missed {
+		notifier.NotifyWithCursor(id, header, headCursor(header))
+	}
+	return point.replayed(), nil
+}
+
+// replayLogs sends the logs missed by a client resuming the subscription from a
+// cursor, up to the given head, returning the hashes of the replayed blocks. The
+// logs of the orphaned blocks are sent first, marked as removed. Nothing is
+// replayed for new subscriptions.
+func (api *FilterAPI) replayLogs(ctx context.Context, notifier *rpc.Notifier, id rpc.ID, crit FilterCriteria, head *types.Header) (map[common.Hash]struct{}, error) {
+	cursor := notifier.ResumeCursor()
+	if cursor == "" || head == nil {
+		return nil, nil
+	}
+	hash, index, err := parseLogCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	point, err := api.resumePoint(ctx, hash, head, api.sys.cfg.ResumeWindow)
+	if err != nil {
+		return nil, err
+	}
+	replay, err := api.segmentLogs(ctx, point, index, crit)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range replay {
+		notifyLog(notifier, id, log)
+	}
+	return point.replayed(), nil
+}
+
+// segmentLogs returns the logs matching the criteria which changed along the
+// chain segment, given that the logs of the cursor block were delivered up to
+// index. The logs of the orphaned blocks come first, marked as removed.
+func (api *FilterAPI) segmentLogs(ctx context.Context, point *resumePoint, index uint, crit FilterCriteria) ([]*types.Log, error) {
+	var logs []*types.Log
+
+	// Remove the logs of the orphaned blocks, up to the cursor in its block.
+	for _, header := range point.orphaned {
+		blockLogs, err := api.sys.NewBlockFilter(header.Hash(), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(blockLogs) - 1; i >= 0; i-- {
+			if header == point.cursor && blockLogs[i].Index > index {
+				continue
+			}
+			removed := *blockLogs[i]
+			removed.Removed = true
+			logs = append(logs, &removed)
+		}
+	}
+	// Add the logs of the canonical blocks, after the cursor in its block.
+	if point.canonical() {
+		blockLogs, err := api.sys.NewBlockFilter(point.cursor.Hash(), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range blockLogs {
+			if log.Index > index {
+				logs = append(logs, log)
+			}
+		}
+	}
+	if len(point.missed) > 0 {
+		var (
+			begin = point.missed[0].Number.Int64()
+			end   = point.missed[len(point.missed)-1].Number.Int64()
+		)
+		missed, err := api.sys.NewRangeFilter(begin, end, crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, missed...)
+	}
+	return logs, nil
+}
+
+// notifyLog sends a log to a subscriber. Removed logs carry no cursor, so that the
+// client resumes from the last log added.
+func notifyLog(notifier *rpc.Notifier, id rpc.ID, log *types.Log) {
+	var cursor string
+	if !log.Removed {
+		cursor = logCursor(log)
+	}
+	notifier.NotifyWithCursor(id, log, cursor)
+}
+
+// forwardAfterReplay passes the events of a live subscription to deliver until the
+// subscription ends. Events are held back until the missed ones were replayed, as
+// signaled by sending the hashes of the replayed blocks on replayDone. If the replay
+// fails, replayDone is closed and forwarding ends.
+func forwardAfterReplay[T any](events <-chan T, replayDone <-chan map[common.Hash]struct{}, done <-chan error, deliver func(T, map[common.Hash]struct{})) {
+	var (
+		pending  []T
+		replayed map[common.Hash]struct{}
+		waiting  = replayDone // nil once the replay is done
+	)
+	for {
+		select {
+		case ev := <-events:
+			if waiting != nil {
+				pending = append(pending, ev)
+				continue
+			}
+			deliver(ev, replayed)
+
+		case hashes, ok := <-waiting:
+			if !ok {
+				return
+			}
+			replayed, waiting = hashes, nil
+			for _, ev := range pending {
+				deliver(ev, replayed)
+			}
+			pending = nil
+
+		case <-done:
+			return
+		}
+	}
+}
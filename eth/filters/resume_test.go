@@ -0,0 +1,179 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// resumeTestNotification is a subscription notification as received by a client.
+type resumeTestNotification struct {
+	Params struct {
+		Result json.RawMessage `json:"result"`
+		Cursor string          `json:"cursor"`
+	} `json:"params"`
+}
+
+// Tests that resumed head and log subscriptions replay the notifications missed
+// since the cursor, including the removal of the logs of reorged blocks.
+func TestSubscriptionResume(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		_, sys = newTestFilterSystem(t, db, Config{ResumeWindow: 8})
+		api    = NewFilterAPI(sys)
+		addr   = common.HexToAddress("0x1234")
+		gspec  = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		addLogs = func(i int, gen *core.BlockGen) {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr}, {Address: addr}}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(999, common.HexToAddress("0x999"), big.NewInt(999), 999, gen.BaseFee(), nil))
+		}
+	)
+	gendb, chain, receipts := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, addLogs)
+	fork, forkReceipts := core.GenerateChain(gspec.Config, chain[4], ethash.NewFaker(), gendb, 2, func(i int, gen *core.BlockGen) {
+		gen.SetExtra([]byte("fork"))
+		addLogs(i, gen)
+	})
+	for i, block := range fork {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), forkReceipts[i])
+	}
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	// resubscribe resumes a subscription from the cursor, returning the error of
+	// the request or the expected number of notifications.
+	resubscribe := func(cursor string, args string, count int) ([]resumeTestNotification, error) {
+		conn, serverConn := net.Pipe()
+		defer conn.Close()
+		go server.ServeCodec(rpc.NewCodec(serverConn), 0)
+
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		req := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_resubscribe","params":["%s",%s]}`, cursor, args)
+		if _, err := conn.Write([]byte(req)); err != nil {
+			return nil, err
+		}
+		var (
+			dec      = json.NewDecoder(conn)
+			response struct {
+				Error *struct{ Message string } `json:"error"`
+			}
+		)
+		if err := dec.Decode(&response); err != nil {
+			return nil, err
+		}
+		if response.Error != nil {
+			return nil, fmt.Errorf("%s", response.Error.Message)
+		}
+		notifications := make([]resumeTestNotification, count)
+		for i := range notifications {
+			if err := dec.Decode(&notifications[i]); err != nil {
+				return nil, err
+			}
+		}
+		return notifications, nil
+	}
+
+	// Resume a head subscription from a canonical block.
+	heads, err := resubscribe(chain[6].Hash().Hex(), `"newHeads"`, 3)
+	if err != nil {
+		t.Fatalf("failed to resume head subscription: %v", err)
+	}
+	for i, n := range heads {
+		var header types.Header
+		if err := json.Unmarshal(n.Params.Result, &header); err != nil {
+			t.Fatal(err)
+		}
+		if want := chain[7+i].Hash(); header.Hash() != want || n.Params.Cursor != want.Hex() {
+			t.Errorf("head %d mismatch: have %x (cursor %s), want %x", i, header.Hash(), n.Params.Cursor, want)
+		}
+	}
+	// Resume a log subscription from the first log of an orphaned block. The log
+	// must be removed along with those of its parent, before replaying the logs
+	// of the canonical chain since the fork.
+	cursor := fmt.Sprintf("%s:%d", fork[1].Hash().Hex(), 0)
+	logs, err := resubscribe(cursor, `"logs",{"address":["`+addr.Hex()+`"]}`, 3+5*2)
+	if err != nil {
+		t.Fatalf("failed to resume log subscription: %v", err)
+	}
+	var want []string
+	for _, removed := range []struct {
+		block *types.Block
+		index uint
+	}{{fork[1], 0}, {fork[0], 1}, {fork[0], 0}} {
+		want = append(want, fmt.Sprintf("%x:%d:true:", removed.block.Hash(), removed.index))
+	}
+	for _, block := range chain[5:] {
+		for i := 0; i < 2; i++ {
+			want = append(want, fmt.Sprintf("%x:%d:false:%s:%d", block.Hash(), i, block.Hash().Hex(), i))
+		}
+	}
+	for i, n := range logs {
+		var log types.Log
+		if err := json.Unmarshal(n.Params.Result, &log); err != nil {
+			t.Fatal(err)
+		}
+		have := fmt.Sprintf("%x:%d:%v:%s", log.BlockHash, log.Index, log.Removed, n.Params.Cursor)
+		if have != want[i] {
+			t.Errorf("log %d mismatch:\nhave %s\nwant %s", i, have, want[i])
+		}
+	}
+	// Check that resuming fails for cursors the node can't replay from.
+	for _, tt := range []struct {
+		cursor string
+		args   string
+		err    error
+	}{
+		{chain[0].Hash().Hex(), `"newHeads"`, errCursorTooOld},
+		{common.Hash{1}.Hex(), `"newHeads"`, errUnknownCursor},
+		{chain[6].Hash().Hex(), `"logs",{}`, errInvalidCursor},
+	} {
+		_, err := resubscribe(tt.cursor, tt.args, 0)
+		if err == nil || !strings.Contains(err.Error(), tt.err.Error()) {
+			t.Errorf("cursor %s: error mismatch: have %v, want %v", tt.cursor, err, tt.err)
+		}
+	}
+}
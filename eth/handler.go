@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -71,6 +72,10 @@ type txPool interface {
 	// Add should add the given transactions to the pool.
 	Add(txs []*types.Transaction, local bool, sync bool) []error
 
+	// IsPrivate returns whether a pooled transaction is private, meaning it must
+	// not be announced or propagated to remote peers.
+	IsPrivate(hash common.Hash) bool
+
 	// Pending should return pending transactions.
 	// The slice should be modifiable by the caller.
 	Pending(filter txpool.PendingFilter) map[common.Address][]*txpool.LazyTransaction
@@ -93,6 +98,7 @@ type handlerConfig struct {
 	BloomCache     uint64                 // Megabytes to alloc for snap sync bloom
 	EventMux       *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
+	TxPropagation  int                    // Number of recent transactions to record the propagation of
 }
 
 type handler struct {
@@ -108,9 +114,10 @@ type handler struct {
 	chain    *core.BlockChain
 	maxPeers int
 
-	downloader *downloader.Downloader
-	txFetcher  *fetcher.TxFetcher
-	peers      *peerSet
+	downloader    *downloader.Downloader
+	txFetcher     *fetcher.TxFetcher
+	txPropagation *fetcher.TxPropagationRecorder // Nil if propagation recording is disabled
+	peers         *peerSet
 
 	eventMux *event.TypeMux
 	txsCh    chan core.NewTxsEvent
@@ -193,9 +200,22 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		return h.txpool.Add(txs, false, false)
 	}
 	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, addTxs, fetchTx, h.removePeer)
+	h.txFetcher.SetDeliveryCallback(h.reportTxDelivery)
+	if config.TxPropagation > 0 {
+		h.txPropagation = fetcher.NewTxPropagationRecorder(mclock.System{}, config.TxPropagation)
+		h.txFetcher.SetPropagationRecorder(h.txPropagation)
+	}
 	return h, nil
 }
 
+// reportTxDelivery scores a peer on the transactions it delivered, given the
+// number of transactions added to the pool and the number of invalid ones.
+func (h *handler) reportTxDelivery(id string, added, invalid int) {
+	if peer := h.peers.peer(id); peer != nil {
+		peer.Peer.ReportTransactions(added, invalid)
+	}
+}
+
 // protoTracker tracks the number of active protocol handlers.
 func (h *handler) protoTracker() {
 	defer h.wg.Done()
@@ -464,6 +484,8 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		blobTxs  int // Number of blob transactions to announce only
 		largeTxs int // Number of large transactions to announce only
 
+		privateTxs int // Number of private transactions to withhold
+
 		directCount int // Number of transactions sent directly to peers (duplicates included)
 		annCount    int // Number of transactions announced across all peers (duplicates included)
 
@@ -485,6 +507,9 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	for _, tx := range txs {
 		var maybeDirect bool
 		switch {
+		case h.txpool.IsPrivate(tx.Hash()):
+			privateTxs++
+			continue
 		case tx.Type() == types.BlobTxType:
 			blobTxs++
 		case tx.Size() > txMaxBroadcastSize:
@@ -529,7 +554,7 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		annCount += len(hashes)
 		peer.AsyncSendPooledTransactionHashes(hashes)
 	}
-	log.Debug("Distributed transactions", "plaintxs", len(txs)-blobTxs-largeTxs, "blobtxs", blobTxs, "largetxs", largeTxs,
+	log.Debug("Distributed transactions", "plaintxs", len(txs)-blobTxs-largeTxs-privateTxs, "blobtxs", blobTxs, "largetxs", largeTxs, "privatetxs", privateTxs,
 		"bcastpeers", len(txset), "bcastcount", directCount, "annpeers", len(annos), "anncount", annCount)
 }
 
@@ -171,7 +171,11 @@ func (p *Peer) dispatchResponse(res *Response, metadata func() interface{}) erro
 			// for fresh cancellations too
 			select {
 			case res.Req.sink <- res:
-				return <-res.Done // Response delivered, return any errors
+				// Response delivered, score the peer on it and return any errors
+				err := <-res.Done
+				p.Peer.ReportResponse(res.code, res.Time, responseItems(res.Res))
+				p.Peer.ReportData(err == nil)
+				return err
 			case <-res.Req.cancel:
 				return nil // Request cancelled, silently discard response
 			}
@@ -182,6 +186,20 @@ func (p *Peer) dispatchResponse(res *Response, metadata func() interface{}) erro
 	}
 }
 
+// responseItems returns the number of items delivered by a response.
+func responseItems(res interface{}) int {
+	switch res := res.(type) {
+	case *BlockHeadersRequest:
+		return len(*res)
+	case *BlockBodiesResponse:
+		return len(*res)
+	case *ReceiptsResponse:
+		return len(*res)
+	default:
+		return 0
+	}
+}
+
 // dispatcher is a loop that accepts requests from higher layer packages, pushes
 // it to the network and tracks and dispatches the responses back to the original
 // requester.
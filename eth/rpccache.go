@@ -0,0 +1,100 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcCacheInvalidator watches the chain for rollbacks below the finalized block,
+// dropping the cached RPC responses derived from the rolled back blocks.
+type rpcCacheInvalidator struct {
+	chain *core.BlockChain
+	cache *rpc.ResponseCache
+
+	sub    event.Subscription
+	heads  chan core.ChainHeadEvent
+	closed chan struct{}
+}
+
+// newRPCCacheInvalidator creates an invalidator for the given response cache.
+func newRPCCacheInvalidator(chain *core.BlockChain, cache *rpc.ResponseCache) *rpcCacheInvalidator {
+	return &rpcCacheInvalidator{
+		chain:  chain,
+		cache:  cache,
+		heads:  make(chan core.ChainHeadEvent, 10),
+		closed: make(chan struct{}),
+	}
+}
+
+// start begins watching the chain head events.
+func (i *rpcCacheInvalidator) start() {
+	i.sub = i.chain.SubscribeChainHeadEvent(i.heads)
+	go i.loop()
+}
+
+// stop terminates the chain watcher.
+func (i *rpcCacheInvalidator) stop() {
+	i.sub.Unsubscribe()
+	<-i.closed
+}
+
+// loop checks on every head change whether the last seen finalized block is still
+// canonical, invalidating the cache from the fork point if not.
+func (i *rpcCacheInvalidator) loop() {
+	defer close(i.closed)
+
+	finalized := i.chain.CurrentFinalBlock()
+	for {
+		select {
+		case <-i.heads:
+			if finalized != nil {
+				if number, ok := i.forkPoint(finalized); ok {
+					log.Warn("Chain rolled back below finalized block, invalidating RPC cache", "finalized", finalized.Number, "fork", number)
+					i.cache.Invalidate(number)
+				}
+			}
+			finalized = i.chain.CurrentFinalBlock()
+
+		case <-i.sub.Err():
+			return
+		}
+	}
+}
+
+// forkPoint returns the number of the first block which is no longer canonical
+// on the chain leading up to the given header, or false if the header is still
+// canonical. If the ancestry cannot be resolved, all blocks are deemed reorged.
+func (i *rpcCacheInvalidator) forkPoint(header *types.Header) (uint64, bool) {
+	if i.chain.GetCanonicalHash(header.Number.Uint64()) == header.Hash() {
+		return 0, false
+	}
+	for header.Number.Uint64() > 0 {
+		number := header.Number.Uint64() - 1
+		if header = i.chain.GetHeader(header.ParentHash, number); header == nil {
+			return 0, true
+		}
+		if i.chain.GetCanonicalHash(number) == header.Hash() {
+			return number + 1, true
+		}
+	}
+	return 0, true
+}
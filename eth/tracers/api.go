@@ -196,6 +196,8 @@ type blockTraceResult struct {
 	Block  hexutil.Uint64   `json:"block"`  // Block number corresponding to this trace
 	Hash   common.Hash      `json:"hash"`   // Block hash corresponding to this trace
 	Traces []*txTraceResult `json:"traces"` // Trace results produced by the task
+
+	txs types.Transactions // Transactions of the block, for reporting untraced ones
 }
 
 // txTraceTask represents a single transaction trace task when an entire block
@@ -420,6 +422,7 @@ func (api *API) traceChain(start, end *types.Block, config *TraceConfig, closed
 				Block:  hexutil.Uint64(res.block.NumberU64()),
 				Hash:   res.block.Hash(),
 				Traces: res.results,
+				txs:    res.block.Transactions(),
 			}
 			done[uint64(result.Block)] = result
 
@@ -0,0 +1,394 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"slices"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/internal"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+//go:generate go run github.com/fjl/gencodec -type erc7562Frame -field-override erc7562FrameMarshaling -out gen_erc7562frame_json.go
+
+func init() {
+	tracers.DefaultDirectory.Register("erc7562Tracer", newErc7562Tracer, false)
+}
+
+// accessedSlots lists the storage slots of a single address that were touched
+// within a call frame.
+type accessedSlots struct {
+	Reads           map[common.Hash][]common.Hash `json:"reads"`
+	Writes          map[common.Hash]uint64        `json:"writes"`
+	TransientReads  map[common.Hash]uint64        `json:"transientReads"`
+	TransientWrites map[common.Hash]uint64        `json:"transientWrites"`
+}
+
+func newAccessedSlots() *accessedSlots {
+	return &accessedSlots{
+		Reads:           make(map[common.Hash][]common.Hash),
+		Writes:          make(map[common.Hash]uint64),
+		TransientReads:  make(map[common.Hash]uint64),
+		TransientWrites: make(map[common.Hash]uint64),
+	}
+}
+
+// contractSizeWithOpcode is the code size of an address touched by a frame,
+// along with the opcode that first accessed it.
+type contractSizeWithOpcode struct {
+	ContractSize int       `json:"contractSize"`
+	Opcode       vm.OpCode `json:"opcode"`
+}
+
+// erc7562Frame is a call frame annotated with the information needed by
+// ERC-4337 bundlers to enforce the ERC-7562 validation rules.
+type erc7562Frame struct {
+	Type         vm.OpCode       `json:"-"`
+	From         common.Address  `json:"from"`
+	Gas          uint64          `json:"gas"`
+	GasUsed      uint64          `json:"gasUsed"`
+	To           *common.Address `json:"to,omitempty"`
+	Input        []byte          `json:"input"`
+	Output       []byte          `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Value        *big.Int        `json:"value,omitempty"`
+	Reverted     bool            `json:"reverted,omitempty"`
+	OutOfGas     bool            `json:"outOfGas,omitempty"`
+
+	AccessedSlots     map[common.Address]*accessedSlots          `json:"accessedSlots"`
+	ExtCodeAccessInfo []common.Address                           `json:"extCodeAccessInfo"`
+	UsedOpcodes       map[hexutil.Uint64]uint64                  `json:"usedOpcodes"`
+	ContractSize      map[common.Address]*contractSizeWithOpcode `json:"contractSize"`
+	KeccakPreimages   [][]byte                                   `json:"keccak,omitempty"`
+	Calls             []erc7562Frame                             `json:"calls,omitempty"`
+}
+
+func newErc7562Frame(typ vm.OpCode, from, to common.Address, input []byte, gas uint64, value *big.Int) erc7562Frame {
+	return erc7562Frame{
+		Type:              typ,
+		From:              from,
+		To:                &to,
+		Input:             common.CopyBytes(input),
+		Gas:               gas,
+		Value:             value,
+		AccessedSlots:     make(map[common.Address]*accessedSlots),
+		ExtCodeAccessInfo: make([]common.Address, 0),
+		UsedOpcodes:       make(map[hexutil.Uint64]uint64),
+		ContractSize:      make(map[common.Address]*contractSizeWithOpcode),
+	}
+}
+
+func (f erc7562Frame) TypeString() string {
+	return f.Type.String()
+}
+
+func (f *erc7562Frame) processOutput(output []byte, err error, reverted bool) {
+	output = common.CopyBytes(output)
+	// Clear error if tx wasn't reverted. This happened
+	// for pre-homestead contract storage OOG.
+	if err != nil && !reverted {
+		err = nil
+	}
+	if err == nil {
+		f.Output = output
+		return
+	}
+	f.Error = err.Error()
+	f.Reverted = reverted
+	f.OutOfGas = errors.Is(err, vm.ErrOutOfGas) || errors.Is(err, vm.ErrCodeStoreOutOfGas)
+	if f.Type == vm.CREATE || f.Type == vm.CREATE2 {
+		f.To = nil
+	}
+	if !errors.Is(err, vm.ErrExecutionReverted) || len(output) == 0 {
+		return
+	}
+	f.Output = output
+	if len(output) < 4 {
+		return
+	}
+	if unpacked, err := abi.UnpackRevert(output); err == nil {
+		f.RevertReason = unpacked
+	}
+}
+
+// slots returns the storage access record of the given address, creating it
+// if needed.
+func (f *erc7562Frame) slots(addr common.Address) *accessedSlots {
+	slots, ok := f.AccessedSlots[addr]
+	if !ok {
+		slots = newAccessedSlots()
+		f.AccessedSlots[addr] = slots
+	}
+	return slots
+}
+
+type erc7562FrameMarshaling struct {
+	TypeString      string `json:"type"`
+	Gas             hexutil.Uint64
+	GasUsed         hexutil.Uint64
+	Value           *hexutil.Big
+	Input           hexutil.Bytes
+	Output          hexutil.Bytes
+	KeccakPreimages []hexutil.Bytes
+}
+
+type erc7562Tracer struct {
+	callstack  []erc7562Frame
+	config     erc7562TracerConfig
+	env        *tracing.VMContext
+	gasLimit   uint64
+	ignored    map[vm.OpCode]struct{}
+	pendingGas bool // Whether the last opcode was a GAS whose use is not yet known
+	interrupt  atomic.Bool
+	reason     error
+}
+
+type erc7562TracerConfig struct {
+	// IgnoredOpcodes are not recorded in the used opcodes of a frame. If
+	// unset, the stack, arithmetic and comparison opcodes are ignored.
+	IgnoredOpcodes []hexutil.Uint64 `json:"ignoredOpcodes"`
+	WithKeccak     bool             `json:"withKeccak"` // If true, keccak preimages are collected
+}
+
+// defaultIgnoredOpcodes returns the opcodes which have no bearing on the
+// ERC-7562 validation rules.
+func defaultIgnoredOpcodes() []hexutil.Uint64 {
+	ignored := make([]hexutil.Uint64, 0, 64)
+
+	// Allow all PUSHx, DUPx and SWAPx opcodes as they have sequential codes
+	for op := vm.PUSH0; op <= vm.SWAP16; op++ {
+		ignored = append(ignored, hexutil.Uint64(op))
+	}
+	for _, op := range []vm.OpCode{
+		vm.POP, vm.ADD, vm.SUB, vm.MUL,
+		vm.DIV, vm.EQ, vm.LT, vm.GT,
+		vm.SLT, vm.SGT, vm.SHL, vm.SHR,
+		vm.AND, vm.OR, vm.NOT, vm.ISZERO,
+	} {
+		ignored = append(ignored, hexutil.Uint64(op))
+	}
+	return ignored
+}
+
+// newErc7562Tracer returns a native go tracer which tracks the call frames
+// of a user operation validation, along with the opcodes, storage slots
+// and contract code they access.
+func newErc7562Tracer(ctx *tracers.Context, cfg json.RawMessage) (*tracers.Tracer, error) {
+	var config erc7562TracerConfig
+	if cfg != nil {
+		if err := json.Unmarshal(cfg, &config); err != nil {
+			return nil, err
+		}
+	}
+	if config.IgnoredOpcodes == nil {
+		config.IgnoredOpcodes = defaultIgnoredOpcodes()
+	}
+	ignored := make(map[vm.OpCode]struct{}, len(config.IgnoredOpcodes))
+	for _, op := range config.IgnoredOpcodes {
+		ignored[vm.OpCode(op)] = struct{}{}
+	}
+	// First callframe contains tx context info
+	// and is populated on start and end.
+	t := &erc7562Tracer{
+		callstack: make([]erc7562Frame, 0, 1),
+		config:    config,
+		ignored:   ignored,
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.OnTxStart,
+			OnTxEnd:   t.OnTxEnd,
+			OnEnter:   t.OnEnter,
+			OnExit:    t.OnExit,
+			OnOpcode:  t.OnOpcode,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+func (t *erc7562Tracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+	t.gasLimit = tx.Gas()
+}
+
+func (t *erc7562Tracer) OnTxEnd(receipt *types.Receipt, err error) {
+	// Error happened during tx validation.
+	if err != nil {
+		return
+	}
+	if receipt != nil && len(t.callstack) > 0 {
+		t.callstack[0].GasUsed = receipt.GasUsed
+	}
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *erc7562Tracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	// Skip if tracing was interrupted
+	if t.interrupt.Load() {
+		return
+	}
+	call := newErc7562Frame(vm.OpCode(typ), from, to, input, gas, value)
+	if depth == 0 {
+		call.Gas = t.gasLimit
+	}
+	t.callstack = append(t.callstack, call)
+}
+
+// OnExit is called when EVM exits a scope, even if the scope didn't
+// execute any code.
+func (t *erc7562Tracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if t.interrupt.Load() {
+		return
+	}
+	if depth == 0 {
+		if len(t.callstack) == 1 {
+			t.callstack[0].processOutput(output, err, reverted)
+		}
+		return
+	}
+	size := len(t.callstack)
+	if size <= 1 {
+		return
+	}
+	// Pop call.
+	call := t.callstack[size-1]
+	t.callstack = t.callstack[:size-1]
+	size -= 1
+
+	call.GasUsed = gasUsed
+	call.processOutput(output, err, reverted)
+	// Nest call into parent.
+	t.callstack[size-1].Calls = append(t.callstack[size-1].Calls, call)
+}
+
+// OnOpcode records the opcodes, storage slots and contract code accessed by
+// the currently executing frame.
+func (t *erc7562Tracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	if err != nil || t.interrupt.Load() || len(t.callstack) == 0 {
+		return
+	}
+	var (
+		op        = vm.OpCode(opcode)
+		frame     = &t.callstack[len(t.callstack)-1]
+		stackData = scope.StackData()
+		stackLen  = len(stackData)
+		caller    = scope.Address()
+	)
+	// GAS is only of interest if it's not used to forward gas to a call.
+	if t.pendingGas {
+		t.pendingGas = false
+		if !isCallOpcode(op) {
+			frame.UsedOpcodes[hexutil.Uint64(vm.GAS)]++
+		}
+	}
+	if op == vm.GAS {
+		t.pendingGas = true
+	} else if _, ok := t.ignored[op]; !ok {
+		frame.UsedOpcodes[hexutil.Uint64(op)]++
+	}
+	switch {
+	case stackLen >= 1 && op == vm.SLOAD:
+		slot := common.Hash(stackData[stackLen-1].Bytes32())
+		slots := frame.slots(caller)
+		if _, written := slots.Writes[slot]; written {
+			return
+		}
+		if _, read := slots.Reads[slot]; !read {
+			slots.Reads[slot] = []common.Hash{t.env.StateDB.GetState(caller, slot)}
+		}
+	case stackLen >= 1 && op == vm.SSTORE:
+		slot := common.Hash(stackData[stackLen-1].Bytes32())
+		frame.slots(caller).Writes[slot]++
+	case stackLen >= 1 && op == vm.TLOAD:
+		slot := common.Hash(stackData[stackLen-1].Bytes32())
+		frame.slots(caller).TransientReads[slot]++
+	case stackLen >= 1 && op == vm.TSTORE:
+		slot := common.Hash(stackData[stackLen-1].Bytes32())
+		frame.slots(caller).TransientWrites[slot]++
+	case stackLen >= 1 && (op == vm.EXTCODESIZE || op == vm.EXTCODECOPY || op == vm.EXTCODEHASH):
+		addr := common.Address(stackData[stackLen-1].Bytes20())
+		if !slices.Contains(frame.ExtCodeAccessInfo, addr) {
+			frame.ExtCodeAccessInfo = append(frame.ExtCodeAccessInfo, addr)
+		}
+		t.recordContractSize(frame, addr, op)
+	case stackLen >= 2 && isCallOpcode(op):
+		addr := common.Address(stackData[stackLen-2].Bytes20())
+		t.recordContractSize(frame, addr, op)
+	case stackLen >= 2 && op == vm.KECCAK256 && t.config.WithKeccak:
+		offset := stackData[stackLen-1]
+		size := stackData[stackLen-2]
+		preimage, err := internal.GetMemoryCopyPadded(scope.MemoryData(), int64(offset.Uint64()), int64(size.Uint64()))
+		if err != nil {
+			log.Warn("failed to copy KECCAK256 input", "err", err, "tracer", "erc7562Tracer", "offset", offset, "size", size)
+			return
+		}
+		frame.KeccakPreimages = append(frame.KeccakPreimages, preimage)
+	}
+}
+
+// recordContractSize stores the code size of the given address, unless it is
+// a precompile or was already seen by the frame.
+func (t *erc7562Tracer) recordContractSize(frame *erc7562Frame, addr common.Address, op vm.OpCode) {
+	if _, ok := frame.ContractSize[addr]; ok || t.isPrecompiled(addr) {
+		return
+	}
+	frame.ContractSize[addr] = &contractSizeWithOpcode{
+		ContractSize: len(t.env.StateDB.GetCode(addr)),
+		Opcode:       op,
+	}
+}
+
+func (t *erc7562Tracer) isPrecompiled(addr common.Address) bool {
+	rules := t.env.ChainConfig.Rules(t.env.BlockNumber, t.env.Random != nil, t.env.Time)
+	return slices.Contains(vm.ActivePrecompiles(rules), addr)
+}
+
+func isCallOpcode(op vm.OpCode) bool {
+	return op == vm.CALL || op == vm.CALLCODE || op == vm.DELEGATECALL || op == vm.STATICCALL
+}
+
+// GetResult returns the json-encoded nested list of annotated call frames,
+// and any error arising from the encoding or forceful termination (via `Stop`).
+func (t *erc7562Tracer) GetResult() (json.RawMessage, error) {
+	if len(t.callstack) != 1 {
+		return nil, errors.New("incorrect number of top-level calls")
+	}
+	res, err := json.Marshal(t.callstack[0])
+	if err != nil {
+		return nil, err
+	}
+	return res, t.reason
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (t *erc7562Tracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+type erc7562Result struct {
+	Reverted      bool                      `json:"reverted"`
+	UsedOpcodes   map[hexutil.Uint64]uint64 `json:"usedOpcodes"`
+	AccessedSlots map[common.Address]struct {
+		Reads  map[common.Hash][]common.Hash `json:"reads"`
+		Writes map[common.Hash]uint64        `json:"writes"`
+	} `json:"accessedSlots"`
+	ExtCodeAccessInfo []common.Address `json:"extCodeAccessInfo"`
+	ContractSize      map[common.Address]struct {
+		ContractSize int       `json:"contractSize"`
+		Opcode       vm.OpCode `json:"opcode"`
+	} `json:"contractSize"`
+	Calls []erc7562Result `json:"calls"`
+}
+
+func TestErc7562Tracer(t *testing.T) {
+	var (
+		from     = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		account  = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		reverter = common.HexToAddress("0x3000000000000000000000000000000000000003")
+
+		// PUSH1 0x00 PUSH1 0x00 REVERT
+		reverterCode = []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}
+	)
+	code := []byte{
+		// SLOAD(1)
+		byte(vm.PUSH1), 1, byte(vm.SLOAD), byte(vm.POP),
+		// SSTORE(2, 42)
+		byte(vm.PUSH1), 42, byte(vm.PUSH1), 2, byte(vm.SSTORE),
+		// Banned opcode
+		byte(vm.TIMESTAMP), byte(vm.POP),
+		// EXTCODESIZE(reverter)
+		byte(vm.PUSH20),
+	}
+	code = append(code, reverter.Bytes()...)
+	code = append(code, byte(vm.EXTCODESIZE), byte(vm.POP))
+	// CALL(gas, reverter, 0, 0, 0, 0, 0)
+	code = append(code, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH20))
+	code = append(code, reverter.Bytes()...)
+	code = append(code, byte(vm.GAS), byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(account, code)
+	statedb.SetState(account, common.Hash{31: 1}, common.Hash{31: 7})
+	statedb.SetCode(reverter, reverterCode)
+
+	tracer, err := tracers.DefaultDirectory.New("erc7562Tracer", &tracers.Context{}, nil)
+	require.NoError(t, err)
+
+	var (
+		tx  = types.NewTx(&types.LegacyTx{To: &account, Gas: 1_000_000, GasPrice: big.NewInt(0)})
+		ctx = vm.BlockContext{
+			CanTransfer: core.CanTransfer,
+			Transfer:    core.Transfer,
+			BlockNumber: big.NewInt(1),
+			Time:        1,
+			GasLimit:    tx.Gas(),
+			BaseFee:     big.NewInt(0),
+		}
+		msg = &core.Message{
+			From:              from,
+			To:                &account,
+			GasLimit:          tx.Gas(),
+			GasPrice:          big.NewInt(0),
+			GasFeeCap:         big.NewInt(0),
+			GasTipCap:         big.NewInt(0),
+			Value:             big.NewInt(0),
+			SkipAccountChecks: true,
+		}
+		evm = vm.NewEVM(ctx, core.NewEVMTxContext(msg), statedb, params.TestChainConfig, vm.Config{Tracer: tracer.Hooks})
+	)
+	tracer.OnTxStart(evm.GetVMContext(), tx, from)
+	res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(tx.Gas()))
+	require.NoError(t, err)
+	tracer.OnTxEnd(&types.Receipt{GasUsed: res.UsedGas}, nil)
+
+	blob, err := tracer.GetResult()
+	require.NoError(t, err)
+	var result erc7562Result
+	require.NoError(t, json.Unmarshal(blob, &result))
+
+	// Opcodes: forwarding gas to a call is allowed, reading the timestamp isn't.
+	require.Equal(t, uint64(1), result.UsedOpcodes[hexutil.Uint64(vm.TIMESTAMP)])
+	require.Equal(t, uint64(1), result.UsedOpcodes[hexutil.Uint64(vm.SLOAD)])
+	require.Zero(t, result.UsedOpcodes[hexutil.Uint64(vm.GAS)])
+	require.Zero(t, result.UsedOpcodes[hexutil.Uint64(vm.PUSH1)])
+
+	// Storage access of the account.
+	slots := result.AccessedSlots[account]
+	require.Equal(t, []common.Hash{{31: 7}}, slots.Reads[common.Hash{31: 1}])
+	require.Equal(t, uint64(1), slots.Writes[common.Hash{31: 2}])
+
+	// Code access of the reverter.
+	require.Equal(t, []common.Address{reverter}, result.ExtCodeAccessInfo)
+	require.Equal(t, len(reverterCode), result.ContractSize[reverter].ContractSize)
+	require.Equal(t, vm.EXTCODESIZE, result.ContractSize[reverter].Opcode)
+
+	// The inner call is marked as reverted, the outer one isn't.
+	require.False(t, result.Reverted)
+	require.Len(t, result.Calls, 1)
+	require.True(t, result.Calls[0].Reverted)
+	require.Equal(t, uint64(1), result.Calls[0].UsedOpcodes[hexutil.Uint64(vm.REVERT)])
+}
@@ -0,0 +1,151 @@
+// Code generated by github.com/fjl/gencodec. DO NOT EDIT.
+
+package native
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+var _ = (*erc7562FrameMarshaling)(nil)
+
+// MarshalJSON marshals as JSON.
+func (e erc7562Frame) MarshalJSON() ([]byte, error) {
+	type erc7562Frame0 struct {
+		Type              vm.OpCode                                  `json:"-"`
+		From              common.Address                             `json:"from"`
+		Gas               hexutil.Uint64                             `json:"gas"`
+		GasUsed           hexutil.Uint64                             `json:"gasUsed"`
+		To                *common.Address                            `json:"to,omitempty"`
+		Input             hexutil.Bytes                              `json:"input"`
+		Output            hexutil.Bytes                              `json:"output,omitempty"`
+		Error             string                                     `json:"error,omitempty"`
+		RevertReason      string                                     `json:"revertReason,omitempty"`
+		Value             *hexutil.Big                               `json:"value,omitempty"`
+		Reverted          bool                                       `json:"reverted,omitempty"`
+		OutOfGas          bool                                       `json:"outOfGas,omitempty"`
+		AccessedSlots     map[common.Address]*accessedSlots          `json:"accessedSlots"`
+		ExtCodeAccessInfo []common.Address                           `json:"extCodeAccessInfo"`
+		UsedOpcodes       map[hexutil.Uint64]uint64                  `json:"usedOpcodes"`
+		ContractSize      map[common.Address]*contractSizeWithOpcode `json:"contractSize"`
+		KeccakPreimages   []hexutil.Bytes                            `json:"keccak,omitempty"`
+		Calls             []erc7562Frame                             `json:"calls,omitempty"`
+		TypeString        string                                     `json:"type"`
+	}
+	var enc erc7562Frame0
+	enc.Type = e.Type
+	enc.From = e.From
+	enc.Gas = hexutil.Uint64(e.Gas)
+	enc.GasUsed = hexutil.Uint64(e.GasUsed)
+	enc.To = e.To
+	enc.Input = e.Input
+	enc.Output = e.Output
+	enc.Error = e.Error
+	enc.RevertReason = e.RevertReason
+	enc.Value = (*hexutil.Big)(e.Value)
+	enc.Reverted = e.Reverted
+	enc.OutOfGas = e.OutOfGas
+	enc.AccessedSlots = e.AccessedSlots
+	enc.ExtCodeAccessInfo = e.ExtCodeAccessInfo
+	enc.UsedOpcodes = e.UsedOpcodes
+	enc.ContractSize = e.ContractSize
+	if e.KeccakPreimages != nil {
+		enc.KeccakPreimages = make([]hexutil.Bytes, len(e.KeccakPreimages))
+		for k, v := range e.KeccakPreimages {
+			enc.KeccakPreimages[k] = v
+		}
+	}
+	enc.Calls = e.Calls
+	enc.TypeString = e.TypeString()
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON unmarshals from JSON.
+func (e *erc7562Frame) UnmarshalJSON(input []byte) error {
+	type erc7562Frame0 struct {
+		Type              *vm.OpCode                                 `json:"-"`
+		From              *common.Address                            `json:"from"`
+		Gas               *hexutil.Uint64                            `json:"gas"`
+		GasUsed           *hexutil.Uint64                            `json:"gasUsed"`
+		To                *common.Address                            `json:"to,omitempty"`
+		Input             *hexutil.Bytes                             `json:"input"`
+		Output            *hexutil.Bytes                             `json:"output,omitempty"`
+		Error             *string                                    `json:"error,omitempty"`
+		RevertReason      *string                                    `json:"revertReason,omitempty"`
+		Value             *hexutil.Big                               `json:"value,omitempty"`
+		Reverted          *bool                                      `json:"reverted,omitempty"`
+		OutOfGas          *bool                                      `json:"outOfGas,omitempty"`
+		AccessedSlots     map[common.Address]*accessedSlots          `json:"accessedSlots"`
+		ExtCodeAccessInfo []common.Address                           `json:"extCodeAccessInfo"`
+		UsedOpcodes       map[hexutil.Uint64]uint64                  `json:"usedOpcodes"`
+		ContractSize      map[common.Address]*contractSizeWithOpcode `json:"contractSize"`
+		KeccakPreimages   []hexutil.Bytes                            `json:"keccak,omitempty"`
+		Calls             []erc7562Frame                             `json:"calls,omitempty"`
+	}
+	var dec erc7562Frame0
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Type != nil {
+		e.Type = *dec.Type
+	}
+	if dec.From != nil {
+		e.From = *dec.From
+	}
+	if dec.Gas != nil {
+		e.Gas = uint64(*dec.Gas)
+	}
+	if dec.GasUsed != nil {
+		e.GasUsed = uint64(*dec.GasUsed)
+	}
+	if dec.To != nil {
+		e.To = dec.To
+	}
+	if dec.Input != nil {
+		e.Input = *dec.Input
+	}
+	if dec.Output != nil {
+		e.Output = *dec.Output
+	}
+	if dec.Error != nil {
+		e.Error = *dec.Error
+	}
+	if dec.RevertReason != nil {
+		e.RevertReason = *dec.RevertReason
+	}
+	if dec.Value != nil {
+		e.Value = (*big.Int)(dec.Value)
+	}
+	if dec.Reverted != nil {
+		e.Reverted = *dec.Reverted
+	}
+	if dec.OutOfGas != nil {
+		e.OutOfGas = *dec.OutOfGas
+	}
+	if dec.AccessedSlots != nil {
+		e.AccessedSlots = dec.AccessedSlots
+	}
+	if dec.ExtCodeAccessInfo != nil {
+		e.ExtCodeAccessInfo = dec.ExtCodeAccessInfo
+	}
+	if dec.UsedOpcodes != nil {
+		e.UsedOpcodes = dec.UsedOpcodes
+	}
+	if dec.ContractSize != nil {
+		e.ContractSize = dec.ContractSize
+	}
+	if dec.KeccakPreimages != nil {
+		e.KeccakPreimages = make([][]byte, len(dec.KeccakPreimages))
+		for k, v := range dec.KeccakPreimages {
+			e.KeccakPreimages[k] = v
+		}
+	}
+	if dec.Calls != nil {
+		e.Calls = dec.Calls
+	}
+	return nil
+}
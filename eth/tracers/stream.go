@@ -0,0 +1,247 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// streamPath is the HTTP path the streaming chain tracer is mounted on.
+	streamPath = "/debug/traceChain"
+
+	// streamMaxRequestSize is the maximum size of a streaming trace request.
+	streamMaxRequestSize = 64 * 1024
+
+	// streamWriteTimeout is the maximum amount of time a single trace record may
+	// take to be written out. It is only enforced while writing, so slow tracing
+	// is fine, but a client not consuming the stream gets disconnected.
+	streamWriteTimeout = 30 * time.Second
+)
+
+// TraceCursor is a position within a streamed chain trace, pointing to the next
+// transaction to be traced. It allows resuming an interrupted stream.
+type TraceCursor struct {
+	Block   hexutil.Uint64 `json:"block"`
+	TxIndex hexutil.Uint64 `json:"txIndex"`
+}
+
+// ChainTraceRequest is the body of a streaming chain trace request, mirroring the
+// parameters of debug_traceChain. If a cursor is given, tracing resumes from it
+// instead of the block following start.
+type ChainTraceRequest struct {
+	Start  rpc.BlockNumber `json:"start"`
+	End    rpc.BlockNumber `json:"end"`
+	Config *TraceConfig    `json:"config,omitempty"`
+	Cursor *TraceCursor    `json:"cursor,omitempty"`
+}
+
+// ChainTraceRecord is a single line of a streamed chain trace, holding the trace
+// of one transaction and the cursor to resume the stream right after it.
+type ChainTraceRecord struct {
+	Block   hexutil.Uint64 `json:"block"`
+	Hash    common.Hash    `json:"hash"`
+	TxIndex hexutil.Uint64 `json:"txIndex"`
+	TxHash  common.Hash    `json:"txHash"`
+	Result  interface{}    `json:"result,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Cursor  TraceCursor    `json:"cursor"`
+}
+
+// ChainTraceEnd is the last line of a streamed chain trace. If the stream did not
+// finish, the error explains why and the cursor points to where it stopped.
+type ChainTraceEnd struct {
+	Done   bool        `json:"done"`
+	Error  string      `json:"error,omitempty"`
+	Cursor TraceCursor `json:"cursor"`
+}
+
+// StreamChain traces the requested chain segment, invoking emit for each of the
+// transaction traces in chain order as soon as they are available. Tracing runs
+// ahead of emit by a bounded number of blocks, a slow emit throttles it.
+//
+// The returned cursor points to the first transaction not yet emitted. A non-nil
+// error means the segment was not fully traced, the cursor can be used to resume.
+func (api *API) StreamChain(ctx context.Context, req *ChainTraceRequest, emit func(*ChainTraceRecord) error) (TraceCursor, error) {
+	from, err := api.blockByNumber(ctx, req.Start)
+	if err != nil {
+		return TraceCursor{}, err
+	}
+	to, err := api.blockByNumber(ctx, req.End)
+	if err != nil {
+		return TraceCursor{}, err
+	}
+	if from.NumberU64() >= to.NumberU64() {
+		return TraceCursor{}, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", req.End, req.Start)
+	}
+	cursor := TraceCursor{Block: hexutil.Uint64(from.NumberU64() + 1)}
+	if req.Cursor != nil {
+		if uint64(req.Cursor.Block) <= from.NumberU64() || uint64(req.Cursor.Block) > to.NumberU64()+1 {
+			return TraceCursor{}, fmt.Errorf("cursor block (#%d) out of range (#%d, #%d]", req.Cursor.Block, from.NumberU64(), to.NumberU64())
+		}
+		cursor = *req.Cursor
+	}
+	if uint64(cursor.Block) > to.NumberU64() {
+		return cursor, nil // nothing left to trace
+	}
+	// Tracing starts on top of the parent of the cursor block
+	if parent := uint64(cursor.Block) - 1; parent != from.NumberU64() {
+		if from, err = api.blockByNumber(ctx, rpc.BlockNumber(parent)); err != nil {
+			return TraceCursor{}, err
+		}
+	}
+	var (
+		closed = make(chan error)
+		resCh  = api.traceChain(from, to, req.Config, closed)
+	)
+	// On any early return, stop the tracer and drain the pending results
+	defer func() {
+		close(closed)
+		for range resCh {
+		}
+	}()
+	for {
+		var (
+			res *blockTraceResult
+			ok  bool
+		)
+		select {
+		case res, ok = <-resCh:
+		case <-ctx.Done():
+			return cursor, ctx.Err()
+		}
+		if !ok {
+			break
+		}
+		for i, trace := range res.Traces {
+			if uint64(res.Block) == uint64(cursor.Block) && uint64(i) < uint64(cursor.TxIndex) {
+				continue // already emitted before resuming
+			}
+			record := &ChainTraceRecord{
+				Block:   res.Block,
+				Hash:    res.Hash,
+				TxIndex: hexutil.Uint64(i),
+				Cursor:  TraceCursor{Block: res.Block, TxIndex: hexutil.Uint64(i + 1)},
+			}
+			if i == len(res.Traces)-1 {
+				record.Cursor = TraceCursor{Block: res.Block + 1}
+			}
+			if trace == nil {
+				// Tracing a block is aborted at the first failing transaction
+				record.TxHash, record.Error = res.txs[i].Hash(), "transaction not traced"
+			} else {
+				record.TxHash, record.Result, record.Error = trace.TxHash, trace.Result, trace.Error
+			}
+			if err := emit(record); err != nil {
+				return cursor, err
+			}
+			cursor = record.Cursor
+		}
+		// Blocks without transactions are not delivered, bar the last one
+		cursor = TraceCursor{Block: res.Block + 1}
+	}
+	if uint64(cursor.Block) <= to.NumberU64() {
+		return cursor, errors.New("chain tracing aborted")
+	}
+	return cursor, nil
+}
+
+// streamHandler serves chain traces over plain HTTP as newline-delimited JSON.
+type streamHandler struct {
+	api *API
+}
+
+// RegisterStreamHandler mounts the streaming chain tracer on the HTTP server of
+// the node, using its CORS and virtual host settings. Contrary to the
+// subscription based debug_traceChain, it works over plain HTTP.
+//
+// The handler is served next to the RPC server, bypassing its rate limits,
+// method policies and audit log, so it should only be registered on request.
+func RegisterStreamHandler(stack *node.Node, backend Backend) {
+	var (
+		config  = stack.Config()
+		handler = node.NewHTTPHandlerStack(&streamHandler{api: NewAPI(backend)}, config.HTTPCors, config.HTTPVirtualHosts, nil)
+	)
+	stack.RegisterHandler("Chain tracer", streamPath, handler)
+}
+
+// ServeHTTP implements http.Handler, decoding a ChainTraceRequest and streaming
+// back a ChainTraceRecord per transaction, terminated by a ChainTraceEnd.
+func (h *streamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req ChainTraceRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, streamMaxRequestSize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	var (
+		ctl     = http.NewResponseController(w)
+		enc     = json.NewEncoder(w)
+		started bool
+	)
+	// write sends a single line over, blocking until the client accepts it
+	write := func(v interface{}) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		// Only limit the write itself, tracing may take arbitrarily long
+		if err := ctl.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		if err := ctl.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if err := ctl.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	}
+	cursor, err := h.api.StreamChain(r.Context(), &req, func(record *ChainTraceRecord) error {
+		return write(record)
+	})
+	if err != nil && !started {
+		// Nothing was streamed yet, report a plain HTTP failure
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	end := &ChainTraceEnd{Done: err == nil, Cursor: cursor}
+	if err != nil {
+		end.Error = err.Error()
+		log.Debug("Chain trace stream interrupted", "block", uint64(cursor.Block), "tx", uint64(cursor.TxIndex), "err", err)
+	}
+	write(end)
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// newStreamTestAPI creates a tracer API on top of a chain of 20 blocks, where
+// every third block is empty and the others contain a growing number of txs.
+func newStreamTestAPI(t *testing.T) *API {
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	var (
+		signer = types.HomesteadSigner{}
+		nonce  uint64
+	)
+	backend := newTestBackend(t, 20, genesis, func(i int, b *core.BlockGen) {
+		if i%3 == 2 {
+			return
+		}
+		for j := 0; j < i%4+1; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, accounts[1].addr, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, accounts[0].key)
+			b.AddTx(tx)
+			nonce++
+		}
+	})
+	t.Cleanup(backend.teardown)
+	return NewAPI(backend)
+}
+
+// Tests that streamed chain traces are delivered in order, one per transaction,
+// and that an interrupted stream can be resumed from any of the cursors.
+func TestStreamChain(t *testing.T) {
+	api := newStreamTestAPI(t)
+
+	// Stream the entire chain, collecting the records
+	var records []*ChainTraceRecord
+	req := &ChainTraceRequest{Start: 0, End: 20}
+	cursor, err := api.StreamChain(context.Background(), req, func(record *ChainTraceRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to stream chain: %v", err)
+	}
+	if cursor.Block != 21 || cursor.TxIndex != 0 {
+		t.Fatalf("final cursor mismatch: have %+v, want block 21", cursor)
+	}
+	var txs int
+	for i := 0; i < 20; i++ {
+		if i%3 != 2 {
+			txs += i%4 + 1
+		}
+	}
+	if len(records) != txs {
+		t.Fatalf("record count mismatch: have %d, want %d", len(records), txs)
+	}
+	for i, record := range records {
+		if record.Result == nil || record.Error != "" {
+			t.Fatalf("record %d: missing trace result: %+v", i, record)
+		}
+		if i > 0 {
+			prev := records[i-1]
+			if record.Block < prev.Block || (record.Block == prev.Block && record.TxIndex != prev.TxIndex+1) {
+				t.Fatalf("record %d: out of order: block %d tx %d after block %d tx %d", i, record.Block, record.TxIndex, prev.Block, prev.TxIndex)
+			}
+		}
+	}
+	// Interrupt the stream midway and resume it from the returned cursor
+	var (
+		partial []*ChainTraceRecord
+		errStop = errors.New("stop")
+	)
+	cursor, err = api.StreamChain(context.Background(), req, func(record *ChainTraceRecord) error {
+		if len(partial) == 7 {
+			return errStop
+		}
+		partial = append(partial, record)
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("interrupted stream error mismatch: have %v, want %v", err, errStop)
+	}
+	if cursor != partial[6].Cursor {
+		t.Fatalf("interrupted cursor mismatch: have %+v, want %+v", cursor, partial[6].Cursor)
+	}
+	resumed := &ChainTraceRequest{Start: 0, End: 20, Cursor: &cursor}
+	if _, err := api.StreamChain(context.Background(), resumed, func(record *ChainTraceRecord) error {
+		partial = append(partial, record)
+		return nil
+	}); err != nil {
+		t.Fatalf("failed to resume stream: %v", err)
+	}
+	if len(partial) != len(records) {
+		t.Fatalf("resumed record count mismatch: have %d, want %d", len(partial), len(records))
+	}
+	for i := range records {
+		if partial[i].TxHash != records[i].TxHash {
+			t.Fatalf("record %d: resumed tx mismatch: have %x, want %x", i, partial[i].TxHash, records[i].TxHash)
+		}
+	}
+	// Out of range cursors must be rejected
+	for _, cursor := range []TraceCursor{{Block: 0}, {Block: 22}} {
+		req := &ChainTraceRequest{Start: 0, End: 20, Cursor: &cursor}
+		if _, err := api.StreamChain(context.Background(), req, func(*ChainTraceRecord) error { return nil }); err == nil {
+			t.Errorf("cursor %+v: expected error", cursor)
+		}
+	}
+}
+
+// Tests that chain traces are streamed over plain HTTP as newline-delimited JSON,
+// terminated by an end marker.
+func TestStreamChainHTTP(t *testing.T) {
+	api := newStreamTestAPI(t)
+	server := httptest.NewServer(&streamHandler{api: api})
+	defer server.Close()
+
+	body, _ := json.Marshal(&ChainTraceRequest{Start: 10, End: rpc.BlockNumber(15)})
+	resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status mismatch: have %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("content type mismatch: have %q", ct)
+	}
+	var (
+		scanner = bufio.NewScanner(resp.Body)
+		lines   [][]byte
+	)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, bytes.Clone(scanner.Bytes()))
+	}
+	// Blocks 11-15 contain 3 + 0 + 1 + 2 + 0 transactions, plus the end marker
+	if len(lines) != 7 {
+		t.Fatalf("line count mismatch: have %d, want 7", len(lines))
+	}
+	for i, line := range lines[:6] {
+		var record ChainTraceRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatalf("line %d: invalid record: %v", i, err)
+		}
+		if record.Block < 11 || record.Block > 15 || record.Result == nil {
+			t.Fatalf("line %d: invalid record: %s", i, line)
+		}
+	}
+	var end ChainTraceEnd
+	if err := json.Unmarshal(lines[6], &end); err != nil {
+		t.Fatalf("invalid end marker: %v", err)
+	}
+	if !end.Done || end.Error != "" || end.Cursor.Block != 16 {
+		t.Fatalf("end marker mismatch: %s", lines[6])
+	}
+	// Invalid ranges are reported before streaming starts
+	body, _ = json.Marshal(&ChainTraceRequest{Start: 15, End: 10})
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status mismatch: have %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
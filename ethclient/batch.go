@@ -0,0 +1,198 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errBatchPending  = errors.New("batch not executed yet")
+	errBatchExecuted = errors.New("batch already executed")
+)
+
+// Batch collects calls to be sent to the node in a single round trip. Calls are
+// queued with the methods mirroring those of Client, each returning a handle to
+// the typed result of the call, which becomes available once the batch has been
+// executed.
+//
+// Note that nodes may limit the number of calls in a batch, see the
+// --rpc.batch-request-limit flag of geth. A batch can only be executed once.
+type Batch struct {
+	ec       *Client
+	elems    []rpc.BatchElem
+	finalize []func(error) // Decodes the results once the batch has been executed
+	executed bool
+}
+
+// BatchResult is the result of a call queued in a Batch.
+type BatchResult[T any] struct {
+	value T
+	err   error
+}
+
+// Result returns the result of the call, or the error returned for it by the node.
+// If the batch has not been executed yet, an error is returned.
+func (r *BatchResult[T]) Result() (T, error) {
+	return r.value, r.err
+}
+
+// NewBatch creates an empty batch of calls.
+func (ec *Client) NewBatch() *Batch {
+	return &Batch{ec: ec}
+}
+
+// Len returns the number of calls queued in the batch.
+func (b *Batch) Len() int {
+	return len(b.elems)
+}
+
+// Execute sends all queued calls to the node and waits for the results. The error
+// returned is only about the transport of the batch, errors specific to a call
+// are returned by the Result method of its handle.
+func (b *Batch) Execute(ctx context.Context) error {
+	if b.executed {
+		return errBatchExecuted
+	}
+	b.executed = true
+
+	if len(b.elems) == 0 {
+		return nil
+	}
+	err := b.ec.c.BatchCallContext(ctx, b.elems)
+	for i, finalize := range b.finalize {
+		if err != nil {
+			finalize(err)
+		} else {
+			finalize(b.elems[i].Error)
+		}
+	}
+	return err
+}
+
+// queueCall adds a call to the batch, decoding its result of type R into the type
+// T of the returned handle using the given conversion.
+func queueCall[R any, T any](b *Batch, convert func(R) (T, error), method string, args ...interface{}) *BatchResult[T] {
+	var (
+		raw    = new(R)
+		result = &BatchResult[T]{err: errBatchPending}
+	)
+	b.elems = append(b.elems, rpc.BatchElem{Method: method, Args: args, Result: raw})
+	b.finalize = append(b.finalize, func(err error) {
+		if err != nil {
+			result.err = err
+			return
+		}
+		result.value, result.err = convert(*raw)
+	})
+	return result
+}
+
+// BlockNumber queues a call returning the most recent block number.
+func (b *Batch) BlockNumber() *BatchResult[uint64] {
+	return queueCall(b, func(r hexutil.Uint64) (uint64, error) {
+		return uint64(r), nil
+	}, "eth_blockNumber")
+}
+
+// HeaderByHash queues a call returning the block header with the given hash.
+func (b *Batch) HeaderByHash(hash common.Hash) *BatchResult[*types.Header] {
+	return queueCall(b, notFoundIfNil[types.Header], "eth_getBlockByHash", hash, false)
+}
+
+// HeaderByNumber queues a call returning a block header from the current canonical
+// chain. If number is nil, the latest known header is returned.
+func (b *Batch) HeaderByNumber(number *big.Int) *BatchResult[*types.Header] {
+	return queueCall(b, notFoundIfNil[types.Header], "eth_getBlockByNumber", toBlockNumArg(number), false)
+}
+
+// TransactionReceipt queues a call returning the receipt of a transaction.
+func (b *Batch) TransactionReceipt(txHash common.Hash) *BatchResult[*types.Receipt] {
+	return queueCall(b, notFoundIfNil[types.Receipt], "eth_getTransactionReceipt", txHash)
+}
+
+// BlockReceipts queues a call returning the receipts of a given block number or hash.
+func (b *Batch) BlockReceipts(blockNrOrHash rpc.BlockNumberOrHash) *BatchResult[[]*types.Receipt] {
+	return queueCall(b, func(r []*types.Receipt) ([]*types.Receipt, error) {
+		if r == nil {
+			return nil, ethereum.NotFound
+		}
+		return r, nil
+	}, "eth_getBlockReceipts", blockNrOrHash.String())
+}
+
+// BalanceAt queues a call returning the wei balance of the given account. The
+// block number can be nil, in which case the latest known block is used.
+func (b *Batch) BalanceAt(account common.Address, blockNumber *big.Int) *BatchResult[*big.Int] {
+	return queueCall(b, func(r hexutil.Big) (*big.Int, error) {
+		return (*big.Int)(&r), nil
+	}, "eth_getBalance", account, toBlockNumArg(blockNumber))
+}
+
+// StorageAt queues a call returning the value of key in the contract storage of
+// the given account. The block number can be nil, in which case the latest known
+// block is used.
+func (b *Batch) StorageAt(account common.Address, key common.Hash, blockNumber *big.Int) *BatchResult[[]byte] {
+	return queueCall(b, decodeBytes, "eth_getStorageAt", account, key, toBlockNumArg(blockNumber))
+}
+
+// CodeAt queues a call returning the contract code of the given account. The block
+// number can be nil, in which case the latest known block is used.
+func (b *Batch) CodeAt(account common.Address, blockNumber *big.Int) *BatchResult[[]byte] {
+	return queueCall(b, decodeBytes, "eth_getCode", account, toBlockNumArg(blockNumber))
+}
+
+// NonceAt queues a call returning the account nonce of the given account. The block
+// number can be nil, in which case the latest known block is used.
+func (b *Batch) NonceAt(account common.Address, blockNumber *big.Int) *BatchResult[uint64] {
+	return queueCall(b, func(r hexutil.Uint64) (uint64, error) {
+		return uint64(r), nil
+	}, "eth_getTransactionCount", account, toBlockNumArg(blockNumber))
+}
+
+// CallContract queues a message call executed on the state of the given block. The
+// block number can be nil, in which case the latest known block is used.
+func (b *Batch) CallContract(msg ethereum.CallMsg, blockNumber *big.Int) *BatchResult[[]byte] {
+	return queueCall(b, decodeBytes, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
+}
+
+// CallContractAtHash queues a message call executed on the state of the block with
+// the given hash.
+func (b *Batch) CallContractAtHash(msg ethereum.CallMsg, blockHash common.Hash) *BatchResult[[]byte] {
+	return queueCall(b, decodeBytes, "eth_call", toCallArg(msg), rpc.BlockNumberOrHashWithHash(blockHash, false))
+}
+
+// notFoundIfNil converts nil results into ethereum.NotFound errors.
+func notFoundIfNil[T any](r *T) (*T, error) {
+	if r == nil {
+		return nil, ethereum.NotFound
+	}
+	return r, nil
+}
+
+// decodeBytes converts hex encoded results into byte slices.
+func decodeBytes(r hexutil.Bytes) ([]byte, error) {
+	return r, nil
+}
@@ -472,6 +472,9 @@ func toFilterArg(q ethereum.FilterQuery) (interface{}, error) {
 		}
 		arg["toBlock"] = toBlockNumArg(q.ToBlock)
 	}
+	if q.Commitment != "" {
+		arg["commitment"] = q.Commitment
+	}
 	return arg, nil
 }
 
@@ -294,6 +294,9 @@ func TestEthClient(t *testing.T) {
 		"TransactionSender": {
 			func(t *testing.T) { testTransactionSender(t, client) },
 		},
+		"Batch": {
+			func(t *testing.T) { testBatch(t, chain, client) },
+		},
 	}
 
 	t.Parallel()
@@ -593,6 +596,51 @@ func testCallContract(t *testing.T, client *rpc.Client) {
 	}
 }
 
+func testBatch(t *testing.T, chain []*types.Block, client *rpc.Client) {
+	ec := NewClient(client)
+	batch := ec.NewBatch()
+
+	var (
+		header   = batch.HeaderByNumber(big.NewInt(1))
+		missing  = batch.HeaderByNumber(big.NewInt(1000000))
+		balance  = batch.BalanceAt(testAddr, big.NewInt(0))
+		nonce    = batch.NonceAt(testAddr, nil)
+		receipt  = batch.TransactionReceipt(testTx1.Hash())
+		call     = batch.CallContract(ethereum.CallMsg{From: testAddr, To: &common.Address{}, Gas: 21000}, nil)
+		reverted = batch.CallContract(ethereum.CallMsg{From: testAddr, To: &common.Address{}, Value: new(big.Int).Lsh(testBalance, 1)}, nil)
+	)
+	if _, err := header.Result(); err == nil {
+		t.Fatal("result available before execution")
+	}
+	if err := batch.Execute(context.Background()); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if h, err := header.Result(); err != nil || h.Hash() != chain[1].Hash() {
+		t.Errorf("header mismatch: have %v, err %v, want %x", h, err, chain[1].Hash())
+	}
+	if _, err := missing.Result(); !errors.Is(err, ethereum.NotFound) {
+		t.Errorf("missing header error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+	if b, err := balance.Result(); err != nil || b.Cmp(testBalance) != 0 {
+		t.Errorf("balance mismatch: have %v, err %v, want %v", b, err, testBalance)
+	}
+	if n, err := nonce.Result(); err != nil || n != 2 {
+		t.Errorf("nonce mismatch: have %d, err %v, want 2", n, err)
+	}
+	if r, err := receipt.Result(); err != nil || r.TxHash != testTx1.Hash() || r.BlockNumber.Uint64() != 2 {
+		t.Errorf("receipt mismatch: have %+v, err %v", r, err)
+	}
+	if _, err := call.Result(); err != nil {
+		t.Errorf("call failed: %v", err)
+	}
+	if _, err := reverted.Result(); err == nil {
+		t.Error("call with insufficient funds succeeded")
+	}
+	if err := batch.Execute(context.Background()); err == nil {
+		t.Error("batch executed twice")
+	}
+}
+
 func testAtFunctions(t *testing.T, client *rpc.Client) {
 	ec := NewClient(client)
 
@@ -0,0 +1,477 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	healthCheckTimeout = 5 * time.Second  // Timeout of a single backend health check
+	resubscribeBackoff = 10 * time.Second // Maximum wait between resubscription attempts
+	errorRateDecay     = 0.2              // Weight of the latest call in the error rate
+)
+
+// ErrNoBackend is returned by FailoverClient if none of the backends could serve
+// a call.
+var ErrNoBackend = errors.New("no backend available")
+
+// FailoverConfig contains the settings of a FailoverClient.
+type FailoverConfig struct {
+	HealthCheckInterval time.Duration // Interval between polls of the backend heads
+	MaxHeadLag          uint64        // Blocks a healthy backend may trail the best head by
+	MaxErrorRate        float64       // Rate of failed calls above which a backend is unhealthy
+}
+
+// DefaultFailoverConfig contains the default settings of a FailoverClient.
+var DefaultFailoverConfig = FailoverConfig{
+	HealthCheckInterval: 5 * time.Second,
+	MaxHeadLag:          2,
+	MaxErrorRate:        0.25,
+}
+
+// BackendStatus is the health of a backend as observed by a FailoverClient.
+type BackendStatus struct {
+	URL       string  // Endpoint of the backend
+	Head      uint64  // Last head block number reported by the backend
+	Lag       uint64  // Number of blocks the backend trails the best known head by
+	ErrorRate float64 // Decaying average of the rate of failed calls
+	Healthy   bool    // Whether the backend is within the configured lag and error rate
+}
+
+// FailoverClient distributes calls across several nodes serving the Ethereum RPC
+// API. The nodes are periodically health checked by how far their head trails the
+// best known head, and by the rate of calls they fail to serve. Every call is sent
+// to the healthiest node, and retried on the next healthiest one if the node fails
+// to serve it.
+//
+// A call is only retried if it failed because of the node. Errors returned by the
+// node itself, such as execution reverts, are relayed to the caller as-is.
+type FailoverClient struct {
+	config   FailoverConfig
+	backends []*failoverBackend
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// failoverBackend is a single node behind a FailoverClient.
+type failoverBackend struct {
+	url   string
+	index int // Position in the endpoint list, used as tie breaker
+
+	lock      sync.Mutex
+	client    *Client // Nil if the node could not be dialed yet
+	head      uint64
+	errorRate float64
+}
+
+// DialFailover creates a client distributing calls across the nodes at the given
+// URLs, in order of preference between equally healthy nodes. Nodes which cannot be
+// reached are retried on every health check, an error is only returned if none of
+// the nodes can be reached.
+func DialFailover(ctx context.Context, urls []string, config FailoverConfig) (*FailoverClient, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no endpoints specified")
+	}
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = DefaultFailoverConfig.HealthCheckInterval
+	}
+	if config.MaxErrorRate <= 0 {
+		config.MaxErrorRate = DefaultFailoverConfig.MaxErrorRate
+	}
+	fc := &FailoverClient{
+		config:  config,
+		closeCh: make(chan struct{}),
+	}
+	for i, url := range urls {
+		fc.backends = append(fc.backends, &failoverBackend{url: url, index: i})
+	}
+	fc.checkHealth(ctx)
+
+	connected := false
+	for _, b := range fc.backends {
+		if b.connected() {
+			connected = true
+			break
+		}
+	}
+	if !connected {
+		fc.closeBackends()
+		return nil, fmt.Errorf("failed to dial any of %d endpoints", len(urls))
+	}
+	fc.wg.Add(1)
+	go fc.loop()
+	return fc, nil
+}
+
+// Close stops the health checks and closes the connections to all nodes.
+func (fc *FailoverClient) Close() {
+	close(fc.closeCh)
+	fc.wg.Wait()
+	fc.closeBackends()
+}
+
+func (fc *FailoverClient) closeBackends() {
+	for _, b := range fc.backends {
+		b.lock.Lock()
+		if b.client != nil {
+			b.client.Close()
+			b.client = nil
+		}
+		b.lock.Unlock()
+	}
+}
+
+// Do invokes fn with the client of the healthiest node. If fn fails because of
+// the node, it is retried with the next healthiest node, until it succeeds or all
+// nodes have been tried. The URL of the node which served the call is returned.
+func (fc *FailoverClient) Do(ctx context.Context, fn func(*Client) error) (string, error) {
+	return fc.do(ctx, 0, fn)
+}
+
+// do invokes fn on the healthiest node having at least the given head block.
+func (fc *FailoverClient) do(ctx context.Context, minHead uint64, fn func(*Client) error) (string, error) {
+	var lastErr error = ErrNoBackend
+	for _, status := range fc.Status() {
+		if status.Head < minHead {
+			continue
+		}
+		url, err := fc.backend(status.URL).call(fn)
+		if !isBackendFailure(err) {
+			return url, err
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+// Session creates a sequence of calls pinned to the healthiest node, whose results
+// are consistent with the block the node had as its head when the session began.
+func (fc *FailoverClient) Session() (*FailoverSession, error) {
+	status := fc.Status()
+	if !status[0].Healthy {
+		return nil, ErrNoBackend
+	}
+	return &FailoverSession{fc: fc, url: status[0].URL, block: status[0].Head}, nil
+}
+
+// SubscribeNewHead subscribes to notifications about the current blockchain head
+// on the healthiest node. If the node fails, the subscription is re-established
+// on the next healthiest one, in which case notifications may be repeated or
+// skipped.
+func (fc *FailoverClient) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return fc.subscribe(ctx, func(ctx context.Context, c *Client) (ethereum.Subscription, error) {
+		return c.SubscribeNewHead(ctx, ch)
+	})
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query on the
+// healthiest node. If the node fails, the subscription is re-established on the
+// next healthiest one, in which case logs may be repeated or skipped.
+func (fc *FailoverClient) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return fc.subscribe(ctx, func(ctx context.Context, c *Client) (ethereum.Subscription, error) {
+		return c.SubscribeFilterLogs(ctx, q, ch)
+	})
+}
+
+// subscribe creates a subscription on the healthiest node, moving it over to the
+// next healthiest one whenever the serving node fails.
+func (fc *FailoverClient) subscribe(ctx context.Context, fn func(context.Context, *Client) (ethereum.Subscription, error)) (ethereum.Subscription, error) {
+	var (
+		sub    ethereum.Subscription
+		served string
+	)
+	served, err := fc.Do(ctx, func(c *Client) (err error) {
+		sub, err = fn(ctx, c)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	first := sub
+	return event.ResubscribeErr(resubscribeBackoff, func(ctx context.Context, lastErr error) (event.Subscription, error) {
+		if first != nil {
+			sub, first = first, nil
+			return sub, nil
+		}
+		// The previous subscription failed, which is only expected if the node
+		// went away, so count it against the node's error rate.
+		if lastErr != nil {
+			fc.backend(served).record(false)
+		}
+		var err error
+		served, err = fc.Do(ctx, func(c *Client) (err error) {
+			sub, err = fn(ctx, c)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return sub, nil
+	}), nil
+}
+
+// backend returns the backend with the given URL.
+func (fc *FailoverClient) backend(url string) *failoverBackend {
+	for _, b := range fc.backends {
+		if b.url == url {
+			return b
+		}
+	}
+	return nil
+}
+
+// Status returns the health of all backends, ordered from healthiest to least
+// healthy: healthy nodes first, then by head lag, error rate and preference.
+func (fc *FailoverClient) Status() []BackendStatus {
+	type entry struct {
+		BackendStatus
+		reliable bool // Whether the node is connected and within the error rate
+		index    int
+	}
+	var (
+		entries = make([]entry, len(fc.backends))
+		best    uint64
+	)
+	for i, b := range fc.backends {
+		b.lock.Lock()
+		entries[i] = entry{
+			BackendStatus: BackendStatus{URL: b.url, Head: b.head, ErrorRate: b.errorRate},
+			reliable:      b.client != nil && b.errorRate <= fc.config.MaxErrorRate,
+			index:         b.index,
+		}
+		b.lock.Unlock()
+
+		// Failing nodes may be stuck on a stale head, so only the heads of the
+		// reliable nodes are trusted as the best head.
+		if entries[i].reliable && entries[i].Head > best {
+			best = entries[i].Head
+		}
+	}
+	for i := range entries {
+		e := &entries[i]
+		e.Lag = best - min(best, e.Head)
+		e.Healthy = e.reliable && e.Lag <= fc.config.MaxHeadLag
+	}
+	// Lagging nodes are preferred over failing ones, as they may still serve
+	// calls about older blocks.
+	slices.SortFunc(entries, func(a, b entry) int {
+		switch {
+		case a.Healthy != b.Healthy:
+			if a.Healthy {
+				return -1
+			}
+			return 1
+		case a.reliable != b.reliable:
+			if a.reliable {
+				return -1
+			}
+			return 1
+		case a.Lag != b.Lag:
+			return cmp.Compare(a.Lag, b.Lag)
+		case a.ErrorRate != b.ErrorRate:
+			return cmp.Compare(a.ErrorRate, b.ErrorRate)
+		default:
+			return cmp.Compare(a.index, b.index)
+		}
+	})
+	status := make([]BackendStatus, len(entries))
+	for i, e := range entries {
+		status[i] = e.BackendStatus
+	}
+	return status
+}
+
+// loop periodically checks the health of the backends.
+func (fc *FailoverClient) loop() {
+	defer fc.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-fc.closeCh
+		cancel()
+	}()
+	ticker := time.NewTicker(fc.config.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			fc.checkHealth(ctx)
+		case <-fc.closeCh:
+			return
+		}
+	}
+}
+
+// checkHealth polls the head of all backends concurrently.
+func (fc *FailoverClient) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, b := range fc.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.check(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// check polls the head of the node, dialing it first if not yet connected.
+func (b *failoverBackend) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	client := b.connect(ctx)
+	if client == nil {
+		return
+	}
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		b.record(false)
+		return
+	}
+	b.lock.Lock()
+	b.head = head
+	b.lock.Unlock()
+	b.record(true)
+}
+
+// connect returns the client of the node, dialing it if not yet connected.
+func (b *failoverBackend) connect(ctx context.Context) *Client {
+	b.lock.Lock()
+	client := b.client
+	b.lock.Unlock()
+	if client != nil {
+		return client
+	}
+	client, err := DialContext(ctx, b.url)
+	if err != nil {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.client != nil {
+		client.Close()
+		return b.client
+	}
+	b.client = client
+	return client
+}
+
+// connected reports whether the node was dialed successfully.
+func (b *failoverBackend) connected() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.client != nil
+}
+
+// call invokes fn with the client of the node, updating the error rate based on
+// the outcome.
+func (b *failoverBackend) call(fn func(*Client) error) (string, error) {
+	b.lock.Lock()
+	client := b.client
+	b.lock.Unlock()
+	if client == nil {
+		return b.url, ErrNoBackend
+	}
+	err := fn(client)
+	b.record(!isBackendFailure(err))
+	return b.url, err
+}
+
+// record updates the error rate of the node with the outcome of a call.
+func (b *failoverBackend) record(ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.errorRate *= 1 - errorRateDecay
+	if !ok {
+		b.errorRate += errorRateDecay
+	}
+}
+
+// isBackendFailure reports whether a call failed because of the node serving it,
+// rather than because of the call itself.
+func isBackendFailure(err error) bool {
+	if err == nil || errors.Is(err, ethereum.NotFound) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == 429
+	}
+	var rpcErr rpc.Error
+	return !errors.As(err, &rpcErr)
+}
+
+// FailoverSession is a sequence of calls pinned to a single node of a
+// FailoverClient. The calls of a session should query the state at the block
+// returned by Block, which all nodes serving the session are guaranteed to have.
+// A session is not safe for concurrent use.
+type FailoverSession struct {
+	fc    *FailoverClient
+	url   string
+	block uint64
+}
+
+// Block returns the number of the block the session is pinned to.
+func (s *FailoverSession) Block() uint64 {
+	return s.block
+}
+
+// Backend returns the URL of the node currently serving the session.
+func (s *FailoverSession) Backend() string {
+	return s.url
+}
+
+// Do invokes fn with the client of the node serving the session. If the node fails,
+// the session is moved over to the healthiest node which has the block the session
+// is pinned to. The URL of the node which served the call is returned.
+func (s *FailoverSession) Do(ctx context.Context, fn func(*Client) error) (string, error) {
+	url, err := s.fc.backend(s.url).call(fn)
+	if !isBackendFailure(err) {
+		return url, err
+	}
+	if ctx.Err() != nil {
+		return "", err
+	}
+	if url, err = s.fc.do(ctx, s.block, fn); err != nil {
+		return "", err
+	}
+	s.url = url
+	return url, nil
+}
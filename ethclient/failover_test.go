@@ -0,0 +1,215 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethclient
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// failoverTestService is a minimal eth namespace reporting a fixed head.
+type failoverTestService struct {
+	head uint64
+}
+
+func (s *failoverTestService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.head)
+}
+
+func (s *failoverTestService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		time.Sleep(10 * time.Millisecond) // Let the subscription ID be delivered first
+		notifier.Notify(sub.ID, &types.Header{Number: new(big.Int).SetUint64(s.head), Difficulty: new(big.Int)})
+	}()
+	return sub, nil
+}
+
+// failoverTestBackend is a node serving the test service over HTTP and WebSocket.
+type failoverTestBackend struct {
+	server *rpc.Server
+	http   *httptest.Server
+}
+
+func newFailoverTestBackend(t *testing.T, head uint64, ws bool) *failoverTestBackend {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &failoverTestService{head: head}); err != nil {
+		t.Fatal(err)
+	}
+	b := &failoverTestBackend{server: server}
+	if ws {
+		b.http = httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	} else {
+		b.http = httptest.NewServer(server)
+	}
+	t.Cleanup(b.stop)
+	return b
+}
+
+func (b *failoverTestBackend) url(ws bool) string {
+	if ws {
+		return "ws" + strings.TrimPrefix(b.http.URL, "http")
+	}
+	return b.http.URL
+}
+
+func (b *failoverTestBackend) stop() {
+	b.server.Stop()
+	b.http.Close()
+}
+
+func dialFailoverTest(t *testing.T, backends []*failoverTestBackend, ws bool) *FailoverClient {
+	t.Helper()
+
+	var urls []string
+	for _, b := range backends {
+		urls = append(urls, b.url(ws))
+	}
+	config := DefaultFailoverConfig
+	config.HealthCheckInterval = time.Hour // Checks are triggered manually
+
+	fc, err := DialFailover(context.Background(), urls, config)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(fc.Close)
+	return fc
+}
+
+// Tests that calls are routed to the healthiest backend, and that they fail over
+// to the next healthiest one if the backend goes away.
+func TestFailoverRouting(t *testing.T) {
+	backends := []*failoverTestBackend{
+		newFailoverTestBackend(t, 90, false),
+		newFailoverTestBackend(t, 100, false),
+		newFailoverTestBackend(t, 100, false),
+	}
+	fc := dialFailoverTest(t, backends, false)
+
+	// The lagging backend must be deprioritized despite being preferred
+	status := fc.Status()
+	if status[0].URL != backends[1].url(false) || status[2].URL != backends[0].url(false) {
+		t.Fatalf("backend order mismatch: %+v", status)
+	}
+	if status[2].Healthy || status[2].Lag != 10 {
+		t.Errorf("lagging backend status mismatch: %+v", status[2])
+	}
+	var head uint64
+	blockNumber := func(c *Client) (err error) {
+		head, err = c.BlockNumber(context.Background())
+		return err
+	}
+	served, err := fc.Do(context.Background(), blockNumber)
+	if err != nil || served != backends[1].url(false) || head != 100 {
+		t.Fatalf("call mismatch: served by %s, head %d, err %v", served, head, err)
+	}
+	// Errors returned by the node itself must not be retried elsewhere
+	served, err = fc.Do(context.Background(), func(c *Client) error {
+		return c.Client().CallContext(context.Background(), nil, "eth_missing")
+	})
+	if err == nil || served != backends[1].url(false) {
+		t.Fatalf("failed call mismatch: served by %s, err %v", served, err)
+	}
+	// Calls must fail over once the healthiest backend goes away
+	backends[1].stop()
+	served, err = fc.Do(context.Background(), blockNumber)
+	if err != nil || served != backends[2].url(false) {
+		t.Fatalf("failover mismatch: served by %s, err %v", served, err)
+	}
+	fc.checkHealth(context.Background())
+	if status := fc.Status(); status[2].URL != backends[1].url(false) || status[2].ErrorRate == 0 {
+		t.Errorf("failed backend status mismatch: %+v", status[2])
+	}
+}
+
+// Tests that sessions stay on their backend, and only move to backends having
+// the block the session is pinned to.
+func TestFailoverSession(t *testing.T) {
+	backends := []*failoverTestBackend{
+		newFailoverTestBackend(t, 100, false),
+		newFailoverTestBackend(t, 99, false),
+		newFailoverTestBackend(t, 100, false),
+	}
+	fc := dialFailoverTest(t, backends, false)
+
+	session, err := fc.Session()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Block() != 100 || session.Backend() != backends[0].url(false) {
+		t.Fatalf("session mismatch: block %d, backend %s", session.Block(), session.Backend())
+	}
+	noop := func(c *Client) error {
+		_, err := c.BlockNumber(context.Background())
+		return err
+	}
+	backends[0].stop()
+	served, err := session.Do(context.Background(), noop)
+	if err != nil || served != backends[2].url(false) || session.Backend() != served {
+		t.Fatalf("session failover mismatch: served by %s, err %v", served, err)
+	}
+	backends[2].stop()
+	if served, err := session.Do(context.Background(), noop); err == nil {
+		t.Fatalf("session moved to backend without pinned block: %s", served)
+	}
+}
+
+// Tests that subscriptions are re-established on another backend if the serving
+// backend goes away.
+func TestFailoverSubscription(t *testing.T) {
+	backends := []*failoverTestBackend{
+		newFailoverTestBackend(t, 100, true),
+		newFailoverTestBackend(t, 100, true),
+	}
+	fc := dialFailoverTest(t, backends, true)
+
+	heads := make(chan *types.Header)
+	sub, err := fc.SubscribeNewHead(context.Background(), heads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	select {
+	case <-heads:
+	case <-time.After(time.Second):
+		t.Fatal("no head from the initial backend")
+	}
+	backends[0].stop()
+
+	select {
+	case head := <-heads:
+		if head.Number.Uint64() != 100 {
+			t.Errorf("head mismatch: have %d, want 100", head.Number)
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not re-established")
+	}
+}
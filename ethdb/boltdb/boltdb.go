@@ -0,0 +1,488 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package boltdb implements the key-value database layer based on bbolt, a pure
+// Go B+tree storage engine.
+package boltdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// fileName is the name of the bolt database file within the database directory.
+	fileName = "bolt.db"
+
+	// openTimeout is the amount of time to wait for the file lock of a database
+	// held by another process.
+	openTimeout = time.Second
+)
+
+var (
+	// mmapSize is the initial size of the memory map of the database file. Bolt
+	// needs to remap the file as it grows, which blocks until all the read-only
+	// transactions (i.e. open iterators) are finished. A large initial map keeps
+	// remapping rare; on 64 bit unix systems it only reserves address space, but
+	// Windows and 32 bit platforms need to stay conservative.
+	mmapSize = func() int {
+		if runtime.GOOS == "windows" || strconv.IntSize == 32 {
+			return 1 << 30
+		}
+		return 1 << 40
+	}()
+
+	// bucket is the name of the single bolt bucket holding all the data.
+	bucket = []byte("ethdb")
+
+	errNotFound = errors.New("not found")
+	errClosed   = errors.New("database closed")
+)
+
+func init() {
+	ethdb.RegisterEngine(ethdb.Engine{
+		Name: "bolt",
+		Open: func(config ethdb.EngineConfig) (ethdb.KeyValueStore, error) {
+			return New(config.Directory, config.ReadOnly, config.Ephemeral)
+		},
+		Detect: func(dir string) bool {
+			_, err := os.Stat(filepath.Join(dir, fileName))
+			return err == nil
+		},
+	})
+}
+
+// Database is a persistent key-value store based on the bbolt storage engine.
+// Apart from basic data storage functionality it also supports batch writes and
+// iterating over the keyspace in binary-alphabetical order.
+//
+// Bolt is a single-writer B+tree: every write is a transaction of its own, so
+// callers should prefer batches for bulk insertions. Iterators hold a read-only
+// transaction, providing a consistent view of the database until released. Note,
+// a write growing the database beyond the memory map waits for all iterators to
+// be released.
+type Database struct {
+	fn string    // filename for reporting
+	db *bbolt.DB // Underlying bolt storage engine
+
+	quitLock sync.RWMutex // Mutex protecting the closed flag
+	closed   bool         // keep track of whether we're Closed
+
+	iterLock  sync.Mutex             // Mutex protecting the set of live iterators
+	iterators map[*iterator]struct{} // Live iterators, holding open read transactions
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// New returns a wrapped bolt DB object, stored in a single file within the given
+// directory. Bolt relies on the OS page cache, hence no cache or file handle
+// allowance is needed.
+func New(dir string, readonly bool, ephemeral bool) (*Database, error) {
+	if !readonly {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	file := filepath.Join(dir, fileName)
+
+	logger := log.New("database", file)
+	logger.Info("Opening bolt database", "readonly", readonly)
+
+	inner, err := bbolt.Open(file, 0644, &bbolt.Options{
+		Timeout:         openTimeout,
+		ReadOnly:        readonly,
+		InitialMmapSize: mmapSize,
+		FreelistType:    bbolt.FreelistMapType,
+		NoFreelistSync:  true,
+		NoSync:          ephemeral,
+		NoGrowSync:      ephemeral,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !readonly {
+		err = inner.Update(func(tx *bbolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			return err
+		})
+		if err != nil {
+			inner.Close()
+			return nil, err
+		}
+	}
+	return &Database{
+		fn:        file,
+		db:        inner,
+		iterators: make(map[*iterator]struct{}),
+		log:       logger,
+	}, nil
+}
+
+// Close flushes any pending data to disk and closes all io accesses to the
+// underlying key-value store. Any iterators not yet released are invalidated.
+func (d *Database) Close() error {
+	d.quitLock.Lock()
+	defer d.quitLock.Unlock()
+	// Allow double closing, simplifies things
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	// Bolt waits for all read transactions to finish before closing, abort the
+	// ones held by leaked iterators.
+	d.iterLock.Lock()
+	for it := range d.iterators {
+		it.release()
+	}
+	d.iterLock.Unlock()
+
+	return d.db.Close()
+}
+
+// view runs the given function within a read-only transaction, if the database
+// is still open and the data bucket exists.
+func (d *Database) view(fn func(b *bbolt.Bucket) error) error {
+	d.quitLock.RLock()
+	defer d.quitLock.RUnlock()
+	if d.closed {
+		return errClosed
+	}
+	return d.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return errNotFound
+		}
+		return fn(b)
+	})
+}
+
+// update runs the given function within a read-write transaction, if the
+// database is still open.
+func (d *Database) update(fn func(b *bbolt.Bucket) error) error {
+	d.quitLock.RLock()
+	defer d.quitLock.RUnlock()
+	if d.closed {
+		return errClosed
+	}
+	return d.db.Update(func(tx *bbolt.Tx) error {
+		return fn(tx.Bucket(bucket))
+	})
+}
+
+// lookup retrieves the value of the given key from the bucket. Bolt doesn't
+// distinguish between empty and missing values on Get, so a cursor is used.
+func lookup(b *bbolt.Bucket, key []byte) ([]byte, bool) {
+	k, v := b.Cursor().Seek(key)
+	if k == nil || !bytes.Equal(k, key) {
+		return nil, false
+	}
+	return v, true
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (d *Database) Has(key []byte) (bool, error) {
+	var found bool
+	err := d.view(func(b *bbolt.Bucket) error {
+		_, found = lookup(b, key)
+		return nil
+	})
+	if err == errNotFound {
+		return false, nil
+	}
+	return found, err
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (d *Database) Get(key []byte) ([]byte, error) {
+	var ret []byte
+	err := d.view(func(b *bbolt.Bucket) error {
+		dat, ok := lookup(b, key)
+		if !ok {
+			return errNotFound
+		}
+		// The value is only valid within the transaction
+		ret = common.CopyBytes(dat)
+		if ret == nil {
+			ret = []byte{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Put inserts the given value into the key-value store.
+func (d *Database) Put(key []byte, value []byte) error {
+	return d.update(func(b *bbolt.Bucket) error {
+		return b.Put(key, value)
+	})
+}
+
+// Delete removes the key from the key-value store.
+func (d *Database) Delete(key []byte) error {
+	return d.update(func(b *bbolt.Bucket) error {
+		return b.Delete(key)
+	})
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to its host
+// database until a final write is called.
+func (d *Database) NewBatch() ethdb.Batch {
+	return &batch{db: d}
+}
+
+// NewBatchWithSize creates a write-only database batch with pre-allocated buffer.
+func (d *Database) NewBatchWithSize(size int) ethdb.Batch {
+	return &batch{db: d}
+}
+
+// Stat returns the internal statistics of bolt in a text format.
+func (d *Database) Stat() (string, error) {
+	d.quitLock.RLock()
+	defer d.quitLock.RUnlock()
+	if d.closed {
+		return "", errClosed
+	}
+	var (
+		stats = d.db.Stats()
+		keys  int
+		depth int
+	)
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		if b := tx.Bucket(bucket); b != nil {
+			bstats := b.Stats()
+			keys, depth = bstats.KeyN, bstats.Depth
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("keys: %d, depth: %d, free pages: %d, pending pages: %d, read txs: %d, write txs: %d, write time: %v\n",
+		keys, depth, stats.FreePageN, stats.PendingPageN, stats.TxN, stats.TxStats.GetWrite(), stats.TxStats.GetWriteTime()), nil
+}
+
+// Compact is a noop for bolt, since freed pages are reused in place and the
+// B+tree is kept balanced on every write.
+func (d *Database) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// Path returns the path to the database file.
+func (d *Database) Path() string {
+	return d.fn
+}
+
+// keyvalue is a key-value tuple tagged with a deletion field to allow creating
+// bolt batches.
+type keyvalue struct {
+	key    []byte
+	value  []byte
+	delete bool
+}
+
+// batch is a write-only batch that commits changes to its host database when
+// Write is called, within a single bolt transaction. A batch cannot be used
+// concurrently.
+type batch struct {
+	db     *Database
+	writes []keyvalue
+	size   int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.writes = append(b.writes, keyvalue{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete inserts the key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.writes = append(b.writes, keyvalue{common.CopyBytes(key), nil, true})
+	b.size += len(key)
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to disk.
+func (b *batch) Write() error {
+	return b.db.update(func(bucket *bbolt.Bucket) error {
+		for _, kv := range b.writes {
+			var err error
+			if kv.delete {
+				err = bucket.Delete(kv.key)
+			} else {
+				err = bucket.Put(kv.key, kv.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	for _, kv := range b.writes {
+		if kv.delete {
+			if err := w.Delete(kv.key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(kv.key, kv.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterator is a wrapper of a bolt cursor, holding a read-only transaction open
+// until released. The iterator is not thread-safe.
+type iterator struct {
+	db     *Database
+	tx     *bbolt.Tx
+	cursor *bbolt.Cursor
+	prefix []byte
+	start  []byte
+
+	key, value []byte
+	moved      bool
+	err        error
+}
+
+// NewIterator creates a binary-alphabetical iterator over a subset
+// of database content with a particular key prefix, starting at a particular
+// initial key (or after, if it does not exist).
+func (d *Database) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	d.quitLock.RLock()
+	defer d.quitLock.RUnlock()
+	if d.closed {
+		return &iterator{err: errClosed}
+	}
+	tx, err := d.db.Begin(false)
+	if err != nil {
+		return &iterator{err: err}
+	}
+	it := &iterator{
+		db:     d,
+		tx:     tx,
+		prefix: prefix,
+		start:  append(common.CopyBytes(prefix), start...),
+	}
+	if b := tx.Bucket(bucket); b != nil {
+		it.cursor = b.Cursor()
+	}
+	d.iterLock.Lock()
+	d.iterators[it] = struct{}{}
+	d.iterLock.Unlock()
+
+	return it
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	if it.err != nil || it.cursor == nil {
+		return false
+	}
+	if !it.moved {
+		it.key, it.value = it.cursor.Seek(it.start)
+		it.moved = true
+	} else if it.key != nil {
+		it.key, it.value = it.cursor.Next()
+	}
+	if it.key != nil && !bytes.HasPrefix(it.key, it.prefix) {
+		it.key, it.value = nil, nil
+	}
+	return it.key != nil
+}
+
+// Error returns any accumulated error. Exhausting all the key/value pairs
+// is not considered to be an error.
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done. The caller
+// should not modify the contents of the returned slice, and its contents may
+// change on the next call to Next.
+func (it *iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current key/value pair, or nil if done. The
+// caller should not modify the contents of the returned slice, and its contents
+// may change on the next call to Next.
+func (it *iterator) Value() []byte {
+	if it.key == nil {
+		return nil
+	}
+	if it.value == nil {
+		return []byte{}
+	}
+	return it.value
+}
+
+// Release releases associated resources. Release should always succeed and can
+// be called multiple times without causing error.
+func (it *iterator) Release() {
+	if it.db == nil {
+		return
+	}
+	it.db.iterLock.Lock()
+	defer it.db.iterLock.Unlock()
+
+	it.release()
+}
+
+// release aborts the read transaction of the iterator and removes it from the
+// set of live iterators. The caller must hold the iterator lock.
+func (it *iterator) release() {
+	if it.tx != nil {
+		it.tx.Rollback()
+		it.tx, it.cursor = nil, nil
+		it.key, it.value = nil, nil
+		delete(it.db.iterators, it)
+	}
+}
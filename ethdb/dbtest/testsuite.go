@@ -318,6 +318,121 @@ func TestDatabaseSuite(t *testing.T, New func() ethdb.KeyValueStore) {
 		}
 	})
 
+	t.Run("BatchReplayOrder", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		// Operations on the same key must be replayed in insertion order
+		b := db.NewBatch()
+		b.Put([]byte("a"), []byte("1"))
+		b.Delete([]byte("a"))
+		b.Put([]byte("b"), []byte("1"))
+		b.Delete([]byte("b"))
+		b.Put([]byte("b"), []byte("2"))
+
+		b2 := db.NewBatch()
+		if err := b.Replay(b2); err != nil {
+			t.Fatal(err)
+		}
+		if err := b2.Write(); err != nil {
+			t.Fatal(err)
+		}
+		if has, err := db.Has([]byte("a")); err != nil || has {
+			t.Fatalf("deleted key present: has %v, err %v", has, err)
+		}
+		if v, err := db.Get([]byte("b")); err != nil || !bytes.Equal(v, []byte("2")) {
+			t.Fatalf("wrong value: %q, err %v", v, err)
+		}
+		// A reset batch must not write anything
+		b.Reset()
+		if b.ValueSize() != 0 {
+			t.Fatalf("reset batch has non-zero size: %d", b.ValueSize())
+		}
+		b.Put([]byte("c"), []byte("3"))
+		b.Reset()
+		if err := b.Write(); err != nil {
+			t.Fatal(err)
+		}
+		if has, _ := db.Has([]byte("c")); has {
+			t.Fatalf("reset batch written")
+		}
+	})
+
+	t.Run("IteratorSnapshot", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		for _, k := range []string{"1", "2", "3"} {
+			if err := db.Put([]byte(k), []byte("v"+k)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		it := db.NewIterator(nil, nil)
+
+		// Modifications after the iterator creation must not be visible
+		db.Put([]byte("0"), []byte("new"))
+		db.Put([]byte("2"), []byte("changed"))
+		db.Delete([]byte("3"))
+		db.Put([]byte("4"), []byte("new"))
+
+		var keys, vals []string
+		for it.Next() {
+			keys = append(keys, string(it.Key()))
+			vals = append(vals, string(it.Value()))
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"1", "2", "3"}; !slices.Equal(keys, want) {
+			t.Errorf("keys mismatch: got %s; want %s", keys, want)
+		}
+		if want := []string{"v1", "v2", "v3"}; !slices.Equal(vals, want) {
+			t.Errorf("values mismatch: got %s; want %s", vals, want)
+		}
+	})
+
+	t.Run("IteratorBounds", func(t *testing.T) {
+		db := New()
+		defer db.Close()
+
+		keys := [][]byte{
+			{0xfe}, {0xfe, 0xff}, {0xff}, {0xff, 0x00}, {0xff, 0xff}, {0xff, 0xff, 0x01},
+		}
+		for _, k := range keys {
+			if err := db.Put(k, k); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tests := []struct {
+			prefix []byte
+			start  []byte
+			want   [][]byte
+		}{
+			// Prefixes consisting of 0xff bytes have no upper bound
+			{prefix: []byte{0xff}, want: keys[2:]},
+			{prefix: []byte{0xff, 0xff}, want: keys[4:]},
+			// The prefix itself is part of the iteration
+			{prefix: []byte{0xfe}, want: keys[:2]},
+			// Start positions before and after the existing keys
+			{prefix: []byte{0xff}, start: []byte{0x00}, want: keys[3:]},
+			{prefix: []byte{0xff}, start: []byte{0x00, 0x00}, want: keys[4:]},
+			{prefix: []byte{0xff}, start: []byte{0xff, 0xff}, want: nil},
+			{prefix: []byte{0xfe}, start: []byte{0xff, 0xff}, want: nil},
+		}
+		for i, tt := range tests {
+			it := db.NewIterator(tt.prefix, tt.start)
+			var got [][]byte
+			for it.Next() {
+				got = append(got, slices.Clone(it.Key()))
+			}
+			it.Release()
+			if !slices.EqualFunc(got, tt.want, bytes.Equal) {
+				t.Errorf("test %d: keys mismatch: got %x; want %x", i, got, tt.want)
+			}
+		}
+	})
+
 	t.Run("OperationsAfterClose", func(t *testing.T) {
 		db := New()
 		db.Put([]byte("key"), []byte("value"))
@@ -345,6 +460,73 @@ func TestDatabaseSuite(t *testing.T, New func() ethdb.KeyValueStore) {
 	})
 }
 
+// TestEngineSuite runs a suite of tests against a registered disk-backed database
+// engine, checking persistence across restarts, detection of existing databases
+// and read-only mode.
+func TestEngineSuite(t *testing.T, engine ethdb.Engine) {
+	t.Run("Persistence", func(t *testing.T) {
+		dir := t.TempDir()
+		if engine.Detect(dir) {
+			t.Fatalf("engine %q detected database in empty directory", engine.Name)
+		}
+		db, err := engine.Open(ethdb.EngineConfig{Directory: dir, Cache: 16, Handles: 16})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		if err := db.Put([]byte("key"), []byte("value")); err != nil {
+			t.Fatal(err)
+		}
+		b := db.NewBatch()
+		b.Put([]byte("batchkey"), []byte("batchvalue"))
+		if err := b.Write(); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf("failed to close database: %v", err)
+		}
+		if !engine.Detect(dir) {
+			t.Fatalf("engine %q failed to detect its own database", engine.Name)
+		}
+		// Reopen the database and ensure all data is retained
+		db, err = engine.Open(ethdb.EngineConfig{Directory: dir, Cache: 16, Handles: 16})
+		if err != nil {
+			t.Fatalf("failed to reopen database: %v", err)
+		}
+		defer db.Close()
+
+		for key, want := range map[string]string{"key": "value", "batchkey": "batchvalue"} {
+			if have, err := db.Get([]byte(key)); err != nil || string(have) != want {
+				t.Errorf("key %q: have %q, err %v; want %q", key, have, err, want)
+			}
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		dir := t.TempDir()
+		db, err := engine.Open(ethdb.EngineConfig{Directory: dir, Cache: 16, Handles: 16})
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		if err := db.Put([]byte("key"), []byte("value")); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+
+		db, err = engine.Open(ethdb.EngineConfig{Directory: dir, Cache: 16, Handles: 16, ReadOnly: true})
+		if err != nil {
+			t.Fatalf("failed to open database in read-only mode: %v", err)
+		}
+		defer db.Close()
+
+		if have, err := db.Get([]byte("key")); err != nil || string(have) != "value" {
+			t.Errorf("read-only lookup failed: have %q, err %v", have, err)
+		}
+		if err := db.Put([]byte("key2"), []byte("value2")); err == nil {
+			t.Errorf("expected error on Put in read-only mode")
+		}
+	})
+}
+
 // BenchDatabaseSuite runs a suite of benchmarks against a KeyValueStore database
 // implementation.
 func BenchDatabaseSuite(b *testing.B, New func() ethdb.KeyValueStore) {
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// EngineConfig contains the options to open a disk-backed key-value store with.
+type EngineConfig struct {
+	Directory string // the directory of the database
+	Namespace string // the namespace for database relevant metrics
+	Cache     int    // the capacity(in megabytes) of the data caching
+	Handles   int    // number of files to be open simultaneously
+	ReadOnly  bool   // whether the database should be opened in read-only mode
+
+	// Ephemeral means that filesystem sync operations should be avoided: data integrity in the face of
+	// a crash is not important. This option should typically be used in tests.
+	Ephemeral bool
+}
+
+// Engine is a disk-backed key-value store implementation which can be selected
+// by name, e.g. through the --db.engine flag. Implementations register themselves
+// via RegisterEngine, typically from the init function of their package.
+type Engine struct {
+	// Name is the unique identifier of the engine.
+	Name string
+
+	// Open opens (or creates) a key-value store at the configured directory.
+	Open func(config EngineConfig) (KeyValueStore, error)
+
+	// Detect reports whether the given directory contains an existing database
+	// created by this engine.
+	Detect func(dir string) bool
+}
+
+var (
+	enginesLock sync.RWMutex
+	engines     = make(map[string]Engine)
+)
+
+// RegisterEngine makes a key-value store implementation available by name. It
+// panics if the engine is incomplete or an engine with the same name is already
+// registered.
+func RegisterEngine(engine Engine) {
+	if engine.Name == "" || engine.Open == nil || engine.Detect == nil {
+		panic("ethdb: incomplete database engine")
+	}
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+
+	if _, ok := engines[engine.Name]; ok {
+		panic(fmt.Sprintf("ethdb: database engine %q registered twice", engine.Name))
+	}
+	engines[engine.Name] = engine
+}
+
+// LookupEngine retrieves a registered key-value store implementation by name.
+func LookupEngine(name string) (Engine, bool) {
+	enginesLock.RLock()
+	defer enginesLock.RUnlock()
+
+	engine, ok := engines[name]
+	return engine, ok
+}
+
+// Engines returns the sorted names of all registered key-value store
+// implementations.
+func Engines() []string {
+	enginesLock.RLock()
+	defer enginesLock.RUnlock()
+
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
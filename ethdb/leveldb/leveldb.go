@@ -22,6 +22,8 @@ package leveldb
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -54,6 +56,23 @@ const (
 	metricsGatheringInterval = 3 * time.Second
 )
 
+func init() {
+	ethdb.RegisterEngine(ethdb.Engine{
+		Name: "leveldb",
+		Open: func(config ethdb.EngineConfig) (ethdb.KeyValueStore, error) {
+			return New(config.Directory, config.Cache, config.Handles, config.Namespace, config.ReadOnly)
+		},
+		Detect: func(dir string) bool {
+			if _, err := os.Stat(filepath.Join(dir, "CURRENT")); err != nil {
+				return false
+			}
+			// Pebble shares the CURRENT file, but also maintains OPTIONS files
+			matches, _ := filepath.Glob(filepath.Join(dir, "OPTIONS*"))
+			return len(matches) == 0
+		},
+	})
+}
+
 // Database is a persistent key-value store. Apart from basic data storage
 // functionality it also supports batch writes and iterating over the keyspace in
 // binary-alphabetical order.
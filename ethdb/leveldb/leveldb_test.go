@@ -37,6 +37,13 @@ func TestLevelDB(t *testing.T) {
 			}
 		})
 	})
+	t.Run("EngineSuite", func(t *testing.T) {
+		engine, ok := ethdb.LookupEngine("leveldb")
+		if !ok {
+			t.Fatal("leveldb engine not registered")
+		}
+		dbtest.TestEngineSuite(t, engine)
+	})
 }
 
 func BenchmarkLevelDB(b *testing.B) {
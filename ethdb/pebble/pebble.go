@@ -20,6 +20,8 @@ package pebble
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -51,6 +53,22 @@ const (
 	degradationWarnInterval = time.Minute
 )
 
+func init() {
+	ethdb.RegisterEngine(ethdb.Engine{
+		Name: "pebble",
+		Open: func(config ethdb.EngineConfig) (ethdb.KeyValueStore, error) {
+			return New(config.Directory, config.Cache, config.Handles, config.Namespace, config.ReadOnly, config.Ephemeral)
+		},
+		Detect: func(dir string) bool {
+			if _, err := os.Stat(filepath.Join(dir, "CURRENT")); err != nil {
+				return false
+			}
+			matches, _ := filepath.Glob(filepath.Join(dir, "OPTIONS*"))
+			return len(matches) > 0
+		},
+	})
+}
+
 // Database is a persistent key-value store based on the pebble storage engine.
 // Apart from basic data storage functionality it also supports batch writes and
 // iterating over the keyspace in binary-alphabetical order.
@@ -39,6 +39,13 @@ func TestPebbleDB(t *testing.T) {
 			}
 		})
 	})
+	t.Run("EngineSuite", func(t *testing.T) {
+		engine, ok := ethdb.LookupEngine("pebble")
+		if !ok {
+			t.Fatal("pebble engine not registered")
+		}
+		dbtest.TestEngineSuite(t, engine)
+	})
 }
 
 func BenchmarkPebbleDB(b *testing.B) {
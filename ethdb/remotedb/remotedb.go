@@ -19,17 +19,31 @@
 // read-only database.
 // There really are no guarantees in this database, since the local geth does not
 // exclusive access, but it can be used for basic diagnostics of a remote node.
+//
+// If the remote node exposes the authenticated database write methods (enabled
+// via --rpc.dbwrite), the database can also be opened in read-write mode, which
+// forwards single writes, batches and ancient appends to `debug_dbPut`,
+// `debug_dbDelete`, `debug_dbWriteBatch` and `debug_dbAppendAncients`.
 package remotedb
 
 import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// errReadOnly is returned if a write is attempted on a database opened without
+// write access.
+var errReadOnly = errors.New("remote database is read-only")
+
 // Database is a key-value lookup for a remote database via debug_dbGet.
 type Database struct {
-	remote *rpc.Client
+	remote   *rpc.Client
+	writable bool
 }
 
 func (db *Database) Has(key []byte) (bool, error) {
@@ -87,15 +101,39 @@ func (db *Database) ReadAncients(fn func(op ethdb.AncientReaderOp) error) (err e
 }
 
 func (db *Database) Put(key []byte, value []byte) error {
-	panic("not supported")
+	if !db.writable {
+		return errReadOnly
+	}
+	return db.remote.Call(nil, "debug_dbPut", hexutil.Bytes(key), hexutil.Bytes(value))
 }
 
 func (db *Database) Delete(key []byte) error {
-	panic("not supported")
+	if !db.writable {
+		return errReadOnly
+	}
+	return db.remote.Call(nil, "debug_dbDelete", hexutil.Bytes(key))
 }
 
+// ModifyAncients collects all the items appended by the callback and sends them
+// over to the remote node to be written in a single atomic operation. Only item
+// appends are supported, the callback is not given read access to the remote
+// ancient store.
 func (db *Database) ModifyAncients(f func(ethdb.AncientWriteOp) error) (int64, error) {
-	panic("not supported")
+	if !db.writable {
+		return 0, errReadOnly
+	}
+	op := new(ancientAppender)
+	if err := f(op); err != nil {
+		return 0, err
+	}
+	if len(op.items) == 0 {
+		return 0, nil
+	}
+	var size hexutil.Uint64
+	if err := db.remote.Call(&size, "debug_dbAppendAncients", op.items); err != nil {
+		return 0, err
+	}
+	return int64(size), nil
 }
 
 func (db *Database) TruncateHead(n uint64) (uint64, error) {
@@ -111,11 +149,11 @@ func (db *Database) Sync() error {
 }
 
 func (db *Database) NewBatch() ethdb.Batch {
-	panic("not supported")
+	return &batch{db: db}
 }
 
 func (db *Database) NewBatchWithSize(size int) ethdb.Batch {
-	panic("not supported")
+	return &batch{db: db, ops: make([]batchOp, 0, size)}
 }
 
 func (db *Database) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
@@ -139,8 +177,116 @@ func (db *Database) Close() error {
 	return nil
 }
 
+// New creates a read-only database backed by the given remote node.
 func New(client *rpc.Client) ethdb.Database {
 	return &Database{
 		remote: client,
 	}
 }
+
+// NewReadWrite creates a database backed by the given remote node, forwarding all
+// the writes to it too. The client needs to be authenticated with the remote node,
+// either connecting over IPC or with the JWT secret of the authenticated endpoint.
+func NewReadWrite(client *rpc.Client) ethdb.Database {
+	return &Database{
+		remote:   client,
+		writable: true,
+	}
+}
+
+// batchOp is a single key-value modification, matching the remote node's
+// debug_dbWriteBatch parameter format.
+type batchOp struct {
+	Key    hexutil.Bytes `json:"key"`
+	Value  hexutil.Bytes `json:"value,omitempty"`
+	Delete bool          `json:"delete,omitempty"`
+}
+
+// batch is a write-only batch that commits changes to the remote database when
+// Write is called. The remote node applies the entire batch atomically.
+type batch struct {
+	db   *Database
+	ops  []batchOp
+	size int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.ops = append(b.ops, batchOp{Key: common.CopyBytes(key), Value: common.CopyBytes(value)})
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete inserts the key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.ops = append(b.ops, batchOp{Key: common.CopyBytes(key), Delete: true})
+	b.size += len(key)
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to the remote database.
+func (b *batch) Write() error {
+	if !b.db.writable {
+		return errReadOnly
+	}
+	if len(b.ops) == 0 {
+		return nil
+	}
+	return b.db.remote.Call(nil, "debug_dbWriteBatch", b.ops)
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	for _, op := range b.ops {
+		if op.Delete {
+			if err := w.Delete(op.Key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.Put(op.Key, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ancientItem is a single raw ancient store item, matching the remote node's
+// debug_dbAppendAncients parameter format.
+type ancientItem struct {
+	Kind   string         `json:"kind"`
+	Number hexutil.Uint64 `json:"number"`
+	Data   hexutil.Bytes  `json:"data"`
+}
+
+// ancientAppender implements ethdb.AncientWriteOp, collecting the appended items
+// to be sent over to the remote node.
+type ancientAppender struct {
+	items []ancientItem
+}
+
+// Append adds an RLP-encoded item.
+func (a *ancientAppender) Append(kind string, number uint64, item interface{}) error {
+	blob, err := rlp.EncodeToBytes(item)
+	if err != nil {
+		return err
+	}
+	return a.AppendRaw(kind, number, blob)
+}
+
+// AppendRaw adds an item without RLP-encoding it.
+func (a *ancientAppender) AppendRaw(kind string, number uint64, item []byte) error {
+	a.items = append(a.items, ancientItem{Kind: kind, Number: hexutil.Uint64(number), Data: common.CopyBytes(item)})
+	return nil
+}
@@ -185,6 +185,11 @@ type FilterQuery struct {
 	// {{A}, {B}}         matches topic A in first position AND B in second position
 	// {{A, B}, {C, D}}   matches topic (A OR B) in first position AND (C OR D) in second position
 	Topics [][]common.Hash
+
+	// Commitment is the level blocks must reach before their logs are returned:
+	// "latest" (the default), "safe" or "finalized". Filters and subscriptions at
+	// the safe and finalized levels deliver logs once their block reaches the level.
+	Commitment string
 }
 
 // LogFilterer provides access to contract log events using a one-off query or continuous
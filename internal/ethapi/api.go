@@ -42,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/gasestimator"
 	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/internal/telemetry"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
@@ -280,6 +281,75 @@ func (api *TxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// RPCJournalEntry is a locally submitted transaction tracked by the journal of
+// the transaction pool.
+type RPCJournalEntry struct {
+	Hash   common.Hash    `json:"hash"`
+	Time   hexutil.Uint64 `json:"time"`
+	From   common.Address `json:"from"`
+	Nonce  hexutil.Uint64 `json:"nonce"`
+	Type   hexutil.Uint64 `json:"type"`
+	Pooled bool           `json:"pooled"`
+}
+
+// RPCJournalReplay is the outcome of re-adding a journaled transaction into the
+// transaction pool on startup.
+type RPCJournalReplay struct {
+	Hash       common.Hash    `json:"hash"`
+	Time       hexutil.Uint64 `json:"time"`
+	Subpool    string         `json:"subpool"`
+	Readmitted bool           `json:"readmitted"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// RPCTxPoolJournal is the content of the local transaction journal, along with
+// what happened to the journaled transactions when the node was last started.
+type RPCTxPoolJournal struct {
+	Entries []*RPCJournalEntry  `json:"entries"`
+	Replays []*RPCJournalReplay `json:"replays"`
+}
+
+// Journal returns the local transactions tracked by the transaction journal in
+// submission order, and the outcome of replaying the journal on startup. It
+// returns an error if journaling is disabled.
+func (api *TxPoolAPI) Journal() (*RPCTxPoolJournal, error) {
+	entries, replays := api.b.TxPoolJournal()
+	if entries == nil && replays == nil {
+		return nil, errors.New("transaction journal disabled")
+	}
+	var (
+		signer  = types.LatestSigner(api.b.ChainConfig())
+		journal = &RPCTxPoolJournal{
+			Entries: make([]*RPCJournalEntry, 0, len(entries)),
+			Replays: make([]*RPCJournalReplay, 0, len(replays)),
+		}
+	)
+	for _, entry := range entries {
+		from, _ := types.Sender(signer, entry.Tx)
+		journal.Entries = append(journal.Entries, &RPCJournalEntry{
+			Hash:   entry.Tx.Hash(),
+			Time:   hexutil.Uint64(entry.Time),
+			From:   from,
+			Nonce:  hexutil.Uint64(entry.Tx.Nonce()),
+			Type:   hexutil.Uint64(entry.Tx.Type()),
+			Pooled: api.b.GetPoolTransaction(entry.Tx.Hash()) != nil,
+		})
+	}
+	for _, replay := range replays {
+		result := &RPCJournalReplay{
+			Hash:       replay.Hash,
+			Time:       hexutil.Uint64(replay.Time),
+			Subpool:    replay.Subpool,
+			Readmitted: replay.Error == nil,
+		}
+		if replay.Error != nil {
+			result.Error = replay.Error.Error()
+		}
+		journal.Replays = append(journal.Replays, result)
+	}
+	return journal, nil
+}
+
 // EthereumAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type EthereumAccountAPI struct {
@@ -664,12 +734,16 @@ func (api *BlockChainAPI) BlockNumber() hexutil.Uint64 {
 // given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
 // block numbers are also allowed.
 func (api *BlockChainAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
-	state, _, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	state, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
 	b := state.GetBalance(address).ToBig()
-	return (*hexutil.Big)(b), state.Error()
+	if err := state.Error(); err != nil {
+		return nil, err
+	}
+	cacheIfFinalizedAt(ctx, api.b, blockNrOrHash, header)
+	return (*hexutil.Big)(b), nil
 }
 
 // AccountResult structs for GetProof
@@ -822,6 +896,7 @@ func (api *BlockChainAPI) GetHeaderByNumber(ctx context.Context, number rpc.Bloc
 func (api *BlockChainAPI) GetHeaderByHash(ctx context.Context, hash common.Hash) map[string]interface{} {
 	header, _ := api.b.HeaderByHash(ctx, hash)
 	if header != nil {
+		cacheIfFinalized(ctx, api.b, header)
 		return api.rpcMarshalHeader(ctx, header)
 	}
 	return nil
@@ -854,6 +929,7 @@ func (api *BlockChainAPI) GetBlockByNumber(ctx context.Context, number rpc.Block
 func (api *BlockChainAPI) GetBlockByHash(ctx context.Context, hash common.Hash, fullTx bool) (map[string]interface{}, error) {
 	block, err := api.b.BlockByHash(ctx, hash)
 	if block != nil {
+		cacheIfFinalized(ctx, api.b, block.Header())
 		return api.rpcMarshalBlock(ctx, block, true, fullTx)
 	}
 	return nil, err
@@ -909,19 +985,23 @@ func (api *BlockChainAPI) GetUncleCountByBlockHash(ctx context.Context, blockHas
 
 // GetCode returns the code stored at the given address in the state for the given block number.
 func (api *BlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	state, _, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	state, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
 	code := state.GetCode(address)
-	return code, state.Error()
+	if err := state.Error(); err != nil {
+		return nil, err
+	}
+	cacheIfFinalizedAt(ctx, api.b, blockNrOrHash, header)
+	return code, nil
 }
 
 // GetStorageAt returns the storage from the state at the given address, key and
 // block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
 // numbers are also allowed.
 func (api *BlockChainAPI) GetStorageAt(ctx context.Context, address common.Address, hexKey string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
-	state, _, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	state, header, err := api.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -930,7 +1010,11 @@ func (api *BlockChainAPI) GetStorageAt(ctx context.Context, address common.Addre
 		return nil, fmt.Errorf("unable to decode storage key: %s", err)
 	}
 	res := state.GetState(address, key)
-	return res[:], state.Error()
+	if err := state.Error(); err != nil {
+		return nil, err
+	}
+	cacheIfFinalizedAt(ctx, api.b, blockNrOrHash, header)
+	return res[:], nil
 }
 
 // GetBlockReceipts returns the block receipts for the given block hash or number or tag.
@@ -957,6 +1041,7 @@ func (api *BlockChainAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rp
 	for i, receipt := range receipts {
 		result[i] = marshalReceipt(receipt, block.Hash(), block.NumberU64(), signer, txs[i], i)
 	}
+	cacheIfFinalizedAt(ctx, api.b, blockNrOrHash, block.Header())
 
 	return result, nil
 }
@@ -1028,6 +1113,8 @@ type BlockOverrides struct {
 	Random      *common.Hash
 	BaseFee     *hexutil.Big
 	BlobBaseFee *hexutil.Big
+	BeaconRoot  *common.Hash
+	Withdrawals *types.Withdrawals
 }
 
 // Apply overrides the given header fields into the given block context.
@@ -1061,6 +1148,42 @@ func (diff *BlockOverrides) Apply(blockCtx *vm.BlockContext) {
 	}
 }
 
+// MakeHeader returns a new header object with the overridden
+// fields.
+// Note: MakeHeader ignores BlobBaseFee if set. That's because
+// header has no such field.
+func (diff *BlockOverrides) MakeHeader(header *types.Header) *types.Header {
+	if diff == nil {
+		return header
+	}
+	h := types.CopyHeader(header)
+	if diff.Number != nil {
+		h.Number = diff.Number.ToInt()
+	}
+	if diff.Difficulty != nil {
+		h.Difficulty = diff.Difficulty.ToInt()
+	}
+	if diff.Time != nil {
+		h.Time = uint64(*diff.Time)
+	}
+	if diff.GasLimit != nil {
+		h.GasLimit = uint64(*diff.GasLimit)
+	}
+	if diff.Coinbase != nil {
+		h.Coinbase = *diff.Coinbase
+	}
+	if diff.Random != nil {
+		h.MixDigest = *diff.Random
+	}
+	if diff.BaseFee != nil {
+		h.BaseFee = diff.BaseFee.ToInt()
+	}
+	if diff.BeaconRoot != nil {
+		h.ParentBeaconRoot = diff.BeaconRoot
+	}
+	return h
+}
+
 // ChainContextBackend provides methods required to implement ChainContext.
 type ChainContextBackend interface {
 	Engine() consensus.Engine
@@ -1120,6 +1243,38 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 	msg := args.ToMessage(blockCtx.BaseFee)
 	evm := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true}, &blockCtx)
 
+	// Execute the message.
+	_, span := telemetry.StartSpan(ctx, "evm.call", telemetry.Int64("evm.gas_limit", int64(msg.GasLimit)))
+	defer span.End()
+
+	gp := new(core.GasPool).AddGas(math.MaxUint64)
+	result, err := applyMessageWithEVM(ctx, evm, msg, state, timeout, gp)
+	traceStateAccess(span, state)
+	if err != nil {
+		span.RecordError(err)
+		return result, fmt.Errorf("err: %w (supplied gas %d)", err, msg.GasLimit)
+	}
+	span.SetAttributes(telemetry.Int64("evm.gas_used", int64(result.UsedGas)))
+	if result.Err != nil {
+		span.RecordError(result.Err)
+	}
+	return result, nil
+}
+
+// traceStateAccess annotates the span with the time spent reading the state from
+// the database while executing the EVM.
+func traceStateAccess(span *telemetry.Span, state *state.StateDB) {
+	span.SetAttributes(
+		telemetry.Int64("state.account_reads_us", state.AccountReads.Microseconds()),
+		telemetry.Int64("state.storage_reads_us", state.StorageReads.Microseconds()),
+		telemetry.Int64("state.accounts_loaded", int64(state.AccountLoaded)),
+		telemetry.Int64("state.storage_loaded", int64(state.StorageLoaded)),
+	)
+}
+
+// applyMessageWithEVM executes the message on the given EVM instance, aborting
+// the execution once the context is done.
+func applyMessageWithEVM(ctx context.Context, evm *vm.EVM, msg *core.Message, state *state.StateDB, timeout time.Duration, gp *core.GasPool) (*core.ExecutionResult, error) {
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
 	go func() {
@@ -1128,7 +1283,6 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 	}()
 
 	// Execute the message.
-	gp := new(core.GasPool).AddGas(math.MaxUint64)
 	result, err := core.ApplyMessage(evm, msg, gp)
 	if err := state.Error(); err != nil {
 		return nil, err
@@ -1138,19 +1292,21 @@ func doCall(ctx context.Context, b Backend, args TransactionArgs, state *state.S
 	if evm.Cancelled() {
 		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
 	}
-	if err != nil {
-		return result, fmt.Errorf("err: %w (supplied gas %d)", err, msg.GasLimit)
-	}
-	return result, nil
+	return result, err
 }
 
 func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, blockOverrides *BlockOverrides, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
+	ctx, span := telemetry.StartSpan(ctx, "ethapi.DoCall", telemetry.String("block", blockNrOrHash.String()))
+	defer span.End()
+
 	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
+	span.SetAttributes(telemetry.Int64("block.number", header.Number.Int64()))
 
 	return doCall(ctx, b, args, state, header, overrides, blockOverrides, timeout, globalGasCap)
 }
@@ -1174,7 +1330,49 @@ func (api *BlockChainAPI) Call(ctx context.Context, args TransactionArgs, blockN
 	if len(result.Revert()) > 0 {
 		return nil, newRevertError(result.Revert())
 	}
-	return result.Return(), result.Err
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	cacheIfFinalizedAt(ctx, api.b, *blockNrOrHash, nil)
+	return result.Return(), nil
+}
+
+// SimulateV1 executes series of transactions on top of a base state.
+// The transactions are packed into blocks. For each block, block header
+// fields can be overridden. The state can also be overridden prior to
+// execution of each block.
+//
+// Note, this function doesn't make any changes in the state/blockchain and is
+// useful to execute and retrieve values.
+func (api *BlockChainAPI) SimulateV1(ctx context.Context, opts simOpts, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, &invalidParamsError{message: "empty input"}
+	} else if len(opts.BlockStateCalls) > maxSimulateBlocks {
+		return nil, &clientLimitExceededError{message: "too many blocks"}
+	}
+	if blockNrOrHash == nil {
+		n := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &n
+	}
+	state, base, err := api.b.StateAndHeaderByNumberOrHash(ctx, *blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	gasCap := api.b.RPCGasCap()
+	if gasCap == 0 {
+		gasCap = math.MaxUint64
+	}
+	sim := &simulator{
+		b:              api.b,
+		state:          state,
+		base:           base,
+		chainConfig:    api.b.ChainConfig(),
+		gp:             new(core.GasPool).AddGas(gasCap),
+		traceTransfers: opts.TraceTransfers,
+		validate:       opts.Validation,
+		fullTx:         opts.ReturnFullTransactions,
+	}
+	return sim.execute(ctx, opts.BlockStateCalls)
 }
 
 // DoEstimateGas returns the lowest possible gas limit that allows the transaction to run
@@ -1659,6 +1857,7 @@ func (api *TransactionAPI) GetTransactionByHash(ctx context.Context, hash common
 	if err != nil {
 		return nil, err
 	}
+	cacheIfFinalized(ctx, api.b, header)
 	return newRPCTransaction(tx, blockHash, blockNumber, header.Time, index, header.BaseFee, api.b.ChainConfig()), nil
 }
 
@@ -1702,6 +1901,7 @@ func (api *TransactionAPI) GetTransactionReceipt(ctx context.Context, hash commo
 
 	// Derive the sender.
 	signer := types.MakeSigner(api.b.ChainConfig(), header.Number, header.Time)
+	cacheIfFinalized(ctx, api.b, header)
 	return marshalReceipt(receipt, blockHash, blockNumber, signer, tx, int(index)), nil
 }
 
@@ -1762,6 +1962,12 @@ func (api *TransactionAPI) sign(addr common.Address, tx *types.Transaction) (*ty
 
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
 func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
+	return submitTransaction(b, tx, func() error { return b.SendTx(ctx, tx) })
+}
+
+// submitTransaction runs the sanity checks on a transaction and, if they pass,
+// inserts it into the transaction pool via the provided send method.
+func submitTransaction(b Backend, tx *types.Transaction, send func() error) (common.Hash, error) {
 	// If the transaction fee cap is already specified, ensure the
 	// fee of the given transaction is _reasonable_.
 	if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
@@ -1771,7 +1977,7 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		// Ensure only eip155 signed transactions are submitted if EIP155Required is set.
 		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
-	if err := b.SendTx(ctx, tx); err != nil {
+	if err := send(); err != nil {
 		return common.Hash{}, err
 	}
 	// Print a log with full tx details for manual investigations and interventions
@@ -1855,6 +2061,41 @@ func (api *TransactionAPI) SendRawTransaction(ctx context.Context, input hexutil
 	return SubmitTransaction(ctx, api.b, tx)
 }
 
+// defaultPrivateTxLifetime is the number of blocks a private transaction is kept
+// in the pool for if no explicit expiry is requested.
+const defaultPrivateTxLifetime = 25
+
+// PrivateTransactionArgs represents the arguments to submit a private transaction
+// into the transaction pool.
+type PrivateTransactionArgs struct {
+	Tx             hexutil.Bytes   `json:"tx"`
+	MaxBlockNumber *hexutil.Uint64 `json:"maxBlockNumber"`
+	PublicOnExpiry bool            `json:"publicOnExpiry"`
+}
+
+// SendPrivateTransaction will add the signed transaction to the transaction pool
+// for local block building only, without ever propagating it to the network. If
+// the transaction is not included until the max block number, it is dropped from
+// the pool or, if requested, made public.
+func (api *TransactionAPI) SendPrivateTransaction(ctx context.Context, args PrivateTransactionArgs) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(args.Tx); err != nil {
+		return common.Hash{}, err
+	}
+	head := api.b.CurrentBlock().Number.Uint64()
+
+	expiry := head + defaultPrivateTxLifetime
+	if args.MaxBlockNumber != nil {
+		expiry = uint64(*args.MaxBlockNumber)
+	}
+	if expiry <= head {
+		return common.Hash{}, fmt.Errorf("max block number %d already reached, head %d", expiry, head)
+	}
+	return submitTransaction(api.b, tx, func() error {
+		return api.b.SendPrivateTx(ctx, tx, expiry, args.PublicOnExpiry)
+	})
+}
+
 // Sign calculates an ECDSA signature for:
 // keccak256("\x19Ethereum Signed Message:\n" + len(message) + message).
 //
@@ -47,6 +47,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -584,6 +585,9 @@ func (b testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) even
 func (b testBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription {
 	panic("implement me")
 }
+func (b testBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction, expiry uint64, publish bool) error {
+	panic("implement me")
+}
 func (b testBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
 	panic("implement me")
 }
@@ -603,6 +607,9 @@ func (b testBackend) TxPoolContent() (map[common.Address][]*types.Transaction, m
 func (b testBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
 	panic("implement me")
 }
+func (b testBackend) TxPoolJournal() ([]*txpool.JournalEntry, []*txpool.JournalReplay) {
+	panic("implement me")
+}
 func (b testBackend) SubscribeNewTxsEvent(events chan<- core.NewTxsEvent) event.Subscription {
 	panic("implement me")
 }
@@ -621,6 +628,9 @@ func (b testBackend) BloomStatus() (uint64, uint64) { panic("implement me") }
 func (b testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	panic("implement me")
 }
+func (b testBackend) LogIndexStatus() (uint64, uint64, uint64) {
+	panic("implement me")
+}
 
 func TestEstimateGas(t *testing.T) {
 	t.Parallel()
@@ -1010,6 +1020,152 @@ func TestCall(t *testing.T) {
 	}
 }
 
+func TestSimulateV1(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	var (
+		accounts = newAccounts(3)
+		genesis  = &core.Genesis{
+			Config: params.MergedTestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		genBlocks = 10
+		signer    = types.HomesteadSigner{}
+		reverter  = common.HexToAddress("0x0000000000000000000000000000000000000bad")
+		half      = (*hexutil.Big)(big.NewInt(params.Ether / 2))
+		quarter   = (*hexutil.Big)(big.NewInt(params.Ether / 4))
+	)
+	api := NewBlockChainAPI(newTestBackend(t, genBlocks, genesis, beacon.New(ethash.NewFaker()), func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: uint64(i), To: &accounts[1].addr, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.BaseFee(), Data: nil}), signer, accounts[0].key)
+		b.AddTx(tx)
+		b.SetPoS()
+	}))
+	var testSuite = []struct {
+		name      string
+		opts      simOpts
+		expectErr error
+		// number of returned blocks and, per block, the status of each call
+		want [][]uint64
+	}{
+		{
+			// The second block spends funds received in the first one.
+			name: "state carries over between blocks",
+			opts: simOpts{
+				BlockStateCalls: []simBlock{{
+					Calls: []TransactionArgs{{From: &accounts[0].addr, To: &accounts[2].addr, Value: half}},
+				}, {
+					Calls: []TransactionArgs{{From: &accounts[2].addr, To: &accounts[1].addr, Value: quarter}},
+				}},
+				TraceTransfers: true,
+			},
+			want: [][]uint64{{types.ReceiptStatusSuccessful}, {types.ReceiptStatusSuccessful}},
+		},
+		{
+			name: "state carries over between calls",
+			opts: simOpts{
+				BlockStateCalls: []simBlock{{
+					Calls: []TransactionArgs{
+						{From: &accounts[0].addr, To: &accounts[2].addr, Value: half},
+						{From: &accounts[2].addr, To: &accounts[1].addr, Value: quarter},
+					},
+				}},
+			},
+			want: [][]uint64{{types.ReceiptStatusSuccessful, types.ReceiptStatusSuccessful}},
+		},
+		{
+			name: "revert with state override",
+			opts: simOpts{
+				BlockStateCalls: []simBlock{{
+					StateOverrides: &StateOverride{
+						// PUSH1 0x00 PUSH1 0x00 REVERT
+						reverter: OverrideAccount{Code: hex2Bytes("60006000fd")},
+					},
+					Calls: []TransactionArgs{{From: &accounts[0].addr, To: &reverter}},
+				}},
+			},
+			want: [][]uint64{{types.ReceiptStatusFailed}},
+		},
+		{
+			name: "gaps are filled with empty blocks",
+			opts: simOpts{
+				BlockStateCalls: []simBlock{{
+					BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(int64(genBlocks + 3)))},
+					Calls:          []TransactionArgs{{From: &accounts[0].addr, To: &accounts[1].addr}},
+				}},
+			},
+			want: [][]uint64{{}, {}, {types.ReceiptStatusSuccessful}},
+		},
+		{
+			name: "block numbers out of order",
+			opts: simOpts{
+				BlockStateCalls: []simBlock{{
+					BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(int64(genBlocks + 2)))},
+				}, {
+					BlockOverrides: &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(int64(genBlocks + 1)))},
+				}},
+			},
+			expectErr: &invalidBlockNumberError{},
+		},
+		{
+			name:      "empty input",
+			opts:      simOpts{},
+			expectErr: &invalidParamsError{},
+		},
+	}
+	for _, tc := range testSuite {
+		result, err := api.SimulateV1(context.Background(), tc.opts, nil)
+		if tc.expectErr != nil {
+			if err == nil {
+				t.Errorf("test %q: want error %T, have nothing", tc.name, tc.expectErr)
+			} else if reflect.TypeOf(err) != reflect.TypeOf(tc.expectErr) {
+				t.Errorf("test %q: error mismatch, want %T, have %T (%v)", tc.name, tc.expectErr, err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %q: want no error, have %v", tc.name, err)
+			continue
+		}
+		if len(result) != len(tc.want) {
+			t.Errorf("test %q: block count mismatch, want %d, have %d", tc.name, len(tc.want), len(result))
+			continue
+		}
+		for i, block := range result {
+			if have, want := block["number"].(*hexutil.Big).ToInt().Uint64(), uint64(genBlocks+i+1); have != want {
+				t.Errorf("test %q: block %d number mismatch, want %d, have %d", tc.name, i, want, have)
+			}
+			calls := block["calls"].([]simCallResult)
+			if len(calls) != len(tc.want[i]) {
+				t.Errorf("test %q: block %d call count mismatch, want %d, have %d", tc.name, i, len(tc.want[i]), len(calls))
+				continue
+			}
+			for j, call := range calls {
+				if have, want := uint64(call.Status), tc.want[i][j]; have != want {
+					t.Errorf("test %q: block %d call %d status mismatch, want %d, have %d (%v)", tc.name, i, j, want, have, call.Error)
+				}
+				if call.Status == hexutil.Uint64(types.ReceiptStatusFailed) && call.Error == nil {
+					t.Errorf("test %q: block %d call %d missing error", tc.name, i, j)
+				}
+				if tc.opts.TraceTransfers && len(call.Logs) != 1 {
+					t.Errorf("test %q: block %d call %d transfer log mismatch, want 1, have %d", tc.name, i, j, len(call.Logs))
+				}
+				for _, log := range call.Logs {
+					if log.BlockHash != block["hash"].(common.Hash) {
+						t.Errorf("test %q: block %d call %d log has wrong block hash", tc.name, i, j)
+					}
+				}
+			}
+			if i > 0 && block["parentHash"].(common.Hash) != result[i-1]["hash"].(common.Hash) {
+				t.Errorf("test %q: block %d is not linked to its parent", tc.name, i)
+			}
+		}
+	}
+}
+
 func TestSignTransaction(t *testing.T) {
 	t.Parallel()
 	// Initialize test accounts
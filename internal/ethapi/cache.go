@@ -0,0 +1,61 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// cacheIfFinalized marks the response to the current call as cacheable if it was
+// derived from the given block, and that block is both canonical and finalized.
+func cacheIfFinalized(ctx context.Context, b Backend, header *types.Header) {
+	if header == nil || !rpc.CanCacheResponse(ctx) {
+		return
+	}
+	finalized, err := b.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+	if err != nil || finalized == nil || header.Number.Cmp(finalized.Number) > 0 {
+		return
+	}
+	canonical, err := b.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+	if err != nil || canonical == nil || canonical.Hash() != header.Hash() {
+		return
+	}
+	rpc.CacheResponse(ctx, header.Number.Uint64())
+}
+
+// cacheIfFinalizedAt marks the response to the current call as cacheable if it was
+// derived from the given finalized block. Blocks referenced by tags are resolved
+// differently over time, so responses for them are never cached. If the header is
+// not known by the caller, it is resolved from the block reference.
+func cacheIfFinalizedAt(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrHash, header *types.Header) {
+	if !rpc.CanCacheResponse(ctx) {
+		return
+	}
+	if number, ok := blockNrOrHash.Number(); ok && number < 0 {
+		return
+	}
+	if header == nil {
+		var err error
+		if header, err = b.HeaderByNumberOrHash(ctx, blockNrOrHash); err != nil {
+			return
+		}
+	}
+	cacheIfFinalized(ctx, b, header)
+}
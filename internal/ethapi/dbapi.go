@@ -17,8 +17,13 @@
 package ethapi
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
 )
 
 // DbGet returns the raw value of a key stored in the database.
@@ -41,3 +46,95 @@ func (api *DebugAPI) DbAncient(kind string, number uint64) (hexutil.Bytes, error
 func (api *DebugAPI) DbAncients() (uint64, error) {
 	return api.b.ChainDb().Ancients()
 }
+
+// DBWriteAPI offers raw write access to the chain database of a running node. It
+// bypasses all the consistency guarantees of the blockchain, so it is meant to be
+// registered as an authenticated API, only reachable over IPC or the JWT-guarded
+// endpoints, for operational repairs.
+type DBWriteAPI struct {
+	b Backend
+}
+
+// NewDBWriteAPI creates a new instance of DBWriteAPI.
+func NewDBWriteAPI(b Backend) *DBWriteAPI {
+	return &DBWriteAPI{b: b}
+}
+
+// DbBatchOp is a single key-value modification within a database batch. If the
+// delete flag is set, the value is ignored.
+type DbBatchOp struct {
+	Key    hexutil.Bytes `json:"key"`
+	Value  hexutil.Bytes `json:"value,omitempty"`
+	Delete bool          `json:"delete,omitempty"`
+}
+
+// DbAncientItem is a single raw item to be appended to an ancient store table.
+type DbAncientItem struct {
+	Kind   string         `json:"kind"`
+	Number hexutil.Uint64 `json:"number"`
+	Data   hexutil.Bytes  `json:"data"`
+}
+
+// DbPut inserts the given value into the database.
+func (api *DBWriteAPI) DbPut(key string, value hexutil.Bytes) error {
+	blob, err := common.ParseHexOrString(key)
+	if err != nil {
+		return err
+	}
+	log.Warn("Modifying database via RPC", "op", "put", "key", hexutil.Encode(blob))
+	return api.b.ChainDb().Put(blob, value)
+}
+
+// DbDelete removes the given key from the database.
+func (api *DBWriteAPI) DbDelete(key string) error {
+	blob, err := common.ParseHexOrString(key)
+	if err != nil {
+		return err
+	}
+	log.Warn("Modifying database via RPC", "op", "delete", "key", hexutil.Encode(blob))
+	return api.b.ChainDb().Delete(blob)
+}
+
+// DbWriteBatch atomically applies a list of insertions and deletions to the
+// database, in the given order.
+func (api *DBWriteAPI) DbWriteBatch(ops []DbBatchOp) error {
+	batch := api.b.ChainDb().NewBatch()
+	for i, op := range ops {
+		if len(op.Key) == 0 {
+			return fmt.Errorf("batch op %d: empty key", i)
+		}
+		var err error
+		if op.Delete {
+			err = batch.Delete(op.Key)
+		} else {
+			err = batch.Put(op.Key, op.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	log.Warn("Modifying database via RPC", "op", "batch", "items", len(ops), "size", batch.ValueSize())
+	return batch.Write()
+}
+
+// DbAppendAncients appends a list of raw items to the ancient store in a single
+// atomic operation. It is a mapping to the `AncientWriteOp.AppendRaw` method and
+// returns the number of bytes written.
+func (api *DBWriteAPI) DbAppendAncients(items []DbAncientItem) (hexutil.Uint64, error) {
+	if len(items) == 0 {
+		return 0, errors.New("no ancient items to append")
+	}
+	size, err := api.b.ChainDb().ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for _, item := range items {
+			if err := op.AppendRaw(item.Kind, uint64(item.Number), item.Data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	log.Warn("Modifying ancient store via RPC", "op", "append", "items", len(items), "size", size)
+	return hexutil.Uint64(size), nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/remotedb"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that a read-write remote database can modify the key-value store and
+// append to the ancient store of a node through the debug write API.
+func TestRemoteDBWrites(t *testing.T) {
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	var (
+		backend = testBackend{db: db}
+		server  = rpc.NewServer()
+	)
+	server.RegisterName("debug", NewDebugAPI(backend))
+	server.RegisterName("debug", NewDBWriteAPI(backend))
+	defer server.Stop()
+
+	// Writes through a read-only remote database must be rejected locally
+	readonly := remotedb.New(rpc.DialInProc(server))
+	defer readonly.Close()
+	if err := readonly.Put([]byte("key"), []byte("value")); err == nil {
+		t.Fatal("read-only remote database accepted a write")
+	}
+	remote := remotedb.NewReadWrite(rpc.DialInProc(server))
+	defer remote.Close()
+
+	// Single key-value modifications
+	if err := remote.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("failed to put key: %v", err)
+	}
+	if have, err := db.Get([]byte("key")); err != nil || !bytes.Equal(have, []byte("value")) {
+		t.Fatalf("remote put mismatch: have %q, err %v", have, err)
+	}
+	if err := remote.Delete([]byte("key")); err != nil {
+		t.Fatalf("failed to delete key: %v", err)
+	}
+	if has, _ := db.Has([]byte("key")); has {
+		t.Fatal("remote delete left key behind")
+	}
+	// Batched modifications, applied in order
+	batch := remote.NewBatch()
+	batch.Put([]byte("a"), []byte("1"))
+	batch.Put([]byte("b"), []byte("2"))
+	batch.Delete([]byte("a"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if has, _ := db.Has([]byte("a")); has {
+		t.Fatal("batch delete not applied")
+	}
+	if have, err := remote.Get([]byte("b")); err != nil || !bytes.Equal(have, []byte("2")) {
+		t.Fatalf("batch put mismatch: have %q, err %v", have, err)
+	}
+	// Ancient store appends, all the tables need to be extended together
+	tables := []string{
+		rawdb.ChainFreezerHeaderTable, rawdb.ChainFreezerHashTable, rawdb.ChainFreezerBodiesTable,
+		rawdb.ChainFreezerReceiptTable, rawdb.ChainFreezerDifficultyTable,
+	}
+	size, err := remote.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for number := uint64(0); number < 2; number++ {
+			for _, kind := range tables {
+				if err := op.AppendRaw(kind, number, []byte{byte(number), kind[0]}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to append ancients: %v", err)
+	}
+	if size == 0 {
+		t.Fatal("no ancient data reported written")
+	}
+	if frozen, err := remote.Ancients(); err != nil || frozen != 2 {
+		t.Fatalf("ancient count mismatch: have %d, err %v, want 2", frozen, err)
+	}
+	if have, err := remote.Ancient(rawdb.ChainFreezerBodiesTable, 1); err != nil || !bytes.Equal(have, []byte{1, 'b'}) {
+		t.Fatalf("ancient item mismatch: have %x, err %v", have, err)
+	}
+	// Non-contiguous appends must be rejected without modifying the store
+	_, err = remote.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for _, kind := range tables {
+			op.AppendRaw(kind, 5, []byte{5})
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("non-contiguous ancient append accepted")
+	}
+	if frozen, _ := db.Ancients(); frozen != 2 {
+		t.Fatalf("ancient count changed after failed append: have %d, want 2", frozen)
+	}
+}
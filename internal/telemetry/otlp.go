@@ -0,0 +1,253 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	exportQueueSize = 2048            // Maximum number of spans waiting for export
+	exportBatchSize = 512             // Maximum number of spans sent in one request
+	exportInterval  = 5 * time.Second // Interval between exports of partial batches
+	exportTimeout   = 10 * time.Second
+)
+
+// instrumentationScope is the name of the instrumentation library reported to
+// the collector.
+const instrumentationScope = "github.com/ethereum/go-ethereum"
+
+var droppedSpansMeter = metrics.NewRegisteredMeter("telemetry/spans/dropped", nil)
+
+// exporter batches the ended spans and posts them to an OTLP/HTTP collector,
+// using the JSON encoding of the protocol.
+type exporter struct {
+	endpoint  string
+	service   string
+	threshold uint64
+	client    *http.Client
+
+	queue   chan *Span
+	closeCh chan struct{}
+	closed  chan struct{}
+}
+
+func newExporter(config Config) *exporter {
+	exp := &exporter{
+		endpoint:  config.Endpoint,
+		service:   config.ServiceName,
+		threshold: sampleThreshold(config.SampleRatio),
+		client:    &http.Client{Timeout: exportTimeout},
+		queue:     make(chan *Span, exportQueueSize),
+		closeCh:   make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	go exp.loop()
+	return exp
+}
+
+// export queues an ended span for export. If the queue is full, the span is
+// dropped rather than blocking the traced operation.
+func (exp *exporter) export(span *Span) {
+	select {
+	case exp.queue <- span:
+	default:
+		droppedSpansMeter.Mark(1)
+	}
+}
+
+// close stops the exporter after sending out all queued spans.
+func (exp *exporter) close() {
+	close(exp.closeCh)
+	<-exp.closed
+}
+
+func (exp *exporter) loop() {
+	defer close(exp.closed)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatchSize)
+	for {
+		select {
+		case span := <-exp.queue:
+			if batch = append(batch, span); len(batch) == exportBatchSize {
+				exp.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				exp.send(batch)
+				batch = batch[:0]
+			}
+		case <-exp.closeCh:
+			for {
+				select {
+				case span := <-exp.queue:
+					if batch = append(batch, span); len(batch) == exportBatchSize {
+						exp.send(batch)
+						batch = batch[:0]
+					}
+				default:
+					if len(batch) > 0 {
+						exp.send(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// send posts a batch of spans to the collector.
+func (exp *exporter) send(batch []*Span) {
+	body, err := json.Marshal(exp.encode(batch))
+	if err != nil {
+		log.Warn("Failed to encode trace spans", "err", err)
+		return
+	}
+	resp, err := exp.client.Post(exp.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		droppedSpansMeter.Mark(int64(len(batch)))
+		log.Debug("Failed to export trace spans", "endpoint", exp.endpoint, "spans", len(batch), "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		droppedSpansMeter.Mark(int64(len(batch)))
+		log.Debug("Trace collector rejected spans", "endpoint", exp.endpoint, "spans", len(batch), "status", resp.Status)
+	}
+}
+
+// The types below are the JSON encoding of the OTLP trace export request. Only
+// the fields populated by the exporter are declared.
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// otlpStatusError is the status code of failed spans.
+const otlpStatusError = 2
+
+// encode converts a batch of spans into an OTLP export request.
+func (exp *exporter) encode(batch []*Span) *otlpExportRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		span.lock.Lock()
+		s := otlpSpan{
+			TraceID:           span.ctx.TraceID.String(),
+			SpanID:            span.ctx.SpanID.String(),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        encodeAttributes(span.attrs),
+		}
+		if span.parent != (SpanID{}) {
+			s.ParentSpanID = span.parent.String()
+		}
+		if span.err != "" {
+			s.Status = &otlpStatus{Code: otlpStatusError, Message: span.err}
+		}
+		span.lock.Unlock()
+		spans = append(spans, s)
+	}
+	return &otlpExportRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: encodeAttributes([]Attribute{String("service.name", exp.service)}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: instrumentationScope},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+// encodeAttributes converts span attributes into their OTLP representation.
+func encodeAttributes(attrs []Attribute) []otlpAttribute {
+	encoded := make([]otlpAttribute, 0, len(attrs))
+	for _, attr := range attrs {
+		var value map[string]any
+		switch v := attr.Value.(type) {
+		case string:
+			value = map[string]any{"stringValue": v}
+		case int64:
+			// 64 bit integers are encoded as strings in the JSON mapping
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		encoded = append(encoded, otlpAttribute{Key: attr.Key, Value: value})
+	}
+	return encoded
+}
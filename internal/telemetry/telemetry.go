@@ -0,0 +1,340 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package telemetry implements distributed tracing of the node internals, with
+// the recorded spans exported to an OpenTelemetry collector via OTLP/HTTP.
+//
+// Tracing is disabled by default, in which case starting a span is a no-op that
+// returns a nil span. All methods of Span are safe to call on nil.
+//
+// Spans cover RPC requests, the API methods serving them and the calls into the
+// eth backend, named eth.backend.*. The state and database layers below are not
+// traced themselves: state access is only reported as aggregate attributes of
+// the EVM execution spans.
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config contains the settings of the trace exporter.
+type Config struct {
+	Endpoint    string  // OTLP/HTTP traces endpoint of the collector
+	ServiceName string  // Service name reported to the collector
+	SampleRatio float64 // Fraction of the locally started traces to record
+}
+
+// DefaultConfig is the default tracing configuration, exporting all traces to a
+// collector running on the local machine.
+var DefaultConfig = Config{
+	Endpoint:    "http://localhost:4318/v1/traces",
+	ServiceName: "geth",
+	SampleRatio: 1,
+}
+
+// active is the exporter of the currently enabled tracer, nil if disabled.
+var active atomic.Pointer[exporter]
+
+// Enable starts recording spans and exporting them to the configured collector.
+// Any previously enabled exporter is stopped.
+func Enable(config Config) error {
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return fmt.Errorf("invalid sample ratio %v, must be within [0, 1]", config.SampleRatio)
+	}
+	u, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid collector endpoint: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid collector endpoint %q, must be an http(s) URL", config.Endpoint)
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = DefaultConfig.ServiceName
+	}
+	if prev := active.Swap(newExporter(config)); prev != nil {
+		prev.close()
+	}
+	return nil
+}
+
+// Disable stops recording spans, flushing the pending ones to the collector.
+func Disable() {
+	if prev := active.Swap(nil); prev != nil {
+		prev.close()
+	}
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// TraceID is the identifier of a trace, shared by all its spans.
+type TraceID [16]byte
+
+// String returns the hex encoding of the trace ID.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID is the identifier of a single span within a trace.
+type SpanID [8]byte
+
+// String returns the hex encoding of the span ID.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span which is propagated to its children, both
+// within the process and across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether the span context has both a trace and span ID set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent returns the W3C trace context header encoding of the span context.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a W3C trace context header value.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+
+	value = strings.TrimSpace(value)
+	if len(value) < 55 {
+		return sc, errors.New("traceparent too short")
+	}
+	version, err := hex.DecodeString(value[:2])
+	if err != nil || version[0] == 0xff || value[2] != '-' {
+		return sc, errors.New("invalid traceparent version")
+	}
+	// Future versions may append fields, which must be ignored
+	if version[0] == 0 && len(value) != 55 || len(value) > 55 && value[55] != '-' {
+		return sc, errors.New("invalid traceparent length")
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(value[3:35])); err != nil || value[35] != '-' {
+		return sc, errors.New("invalid traceparent trace ID")
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(value[36:52])); err != nil || value[52] != '-' {
+		return sc, errors.New("invalid traceparent parent ID")
+	}
+	flags, err := hex.DecodeString(value[53:55])
+	if err != nil {
+		return sc, errors.New("invalid traceparent flags")
+	}
+	if !sc.IsValid() {
+		return sc, errors.New("zero traceparent identifier")
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+	return sc, nil
+}
+
+type spanContextKey struct{}
+
+// ContextWithRemoteSpanContext returns a copy of the context with the given span
+// context received from a remote caller set as the parent of new spans.
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context of the current span, if any.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// Span kinds as defined by the OTLP specification.
+const (
+	kindInternal = 1
+	kindServer   = 2
+)
+
+// Span is a single timed operation within a trace.
+type Span struct {
+	exp    *exporter
+	ctx    SpanContext
+	parent SpanID
+	name   string
+	kind   int
+	start  time.Time
+
+	lock  sync.Mutex
+	end   time.Time
+	attrs []Attribute
+	err   string
+	ended bool
+}
+
+// StartSpan starts an internal span, as a child of the current span in the
+// context. If tracing is disabled or the trace is not sampled, the returned span
+// is nil.
+func StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, name, kindInternal, attrs)
+}
+
+// StartServerSpan starts a span handling a request of a remote caller, as a child
+// of the span context propagated by the caller, if any.
+func StartServerSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	return startSpan(ctx, name, kindServer, attrs)
+}
+
+func startSpan(ctx context.Context, name string, kind int, attrs []Attribute) (context.Context, *Span) {
+	exp := active.Load()
+	if exp == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		exp:   exp,
+		name:  name,
+		kind:  kind,
+		start: time.Now(),
+		attrs: attrs,
+	}
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		if !parent.Sampled {
+			return ctx, nil
+		}
+		span.ctx.TraceID, span.parent = parent.TraceID, parent.SpanID
+	} else {
+		rand.Read(span.ctx.TraceID[:])
+	}
+	rand.Read(span.ctx.SpanID[:])
+
+	// Sample root spans by their trace ID, so the decision is deterministic for
+	// all nodes observing the same trace. Unsampled spans are not recorded, but
+	// they are still propagated so that their children are not sampled either.
+	if span.parent == (SpanID{}) && !sampleTrace(span.ctx.TraceID, exp.threshold) {
+		return context.WithValue(ctx, spanContextKey{}, span.ctx), nil
+	}
+	span.ctx.Sampled = true
+
+	return context.WithValue(ctx, spanContextKey{}, span.ctx), span
+}
+
+// SpanContext returns the propagated identifiers of the span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.ctx
+}
+
+// SetAttributes adds attributes to the span, overwriting existing ones with the
+// same key.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, attr := range attrs {
+		replaced := false
+		for i := range s.attrs {
+			if s.attrs[i].Key == attr.Key {
+				s.attrs[i], replaced = attr, true
+				break
+			}
+		}
+		if !replaced {
+			s.attrs = append(s.attrs, attr)
+		}
+	}
+}
+
+// RecordError marks the span as failed with the given error. Nil errors are
+// ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	s.err = err.Error()
+	s.lock.Unlock()
+}
+
+// End completes the span, queueing it for export. Calling End multiple times is
+// allowed, only the first one has any effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.lock.Unlock()
+
+	s.exp.export(s)
+}
+
+// Attribute is a key-value pair annotating a span.
+type Attribute struct {
+	Key   string
+	Value any // string, int64 or bool
+}
+
+// String creates a string valued attribute.
+func String(key string, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int64 creates an integer valued attribute.
+func Int64(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool creates a boolean valued attribute.
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// sampleThreshold converts a sample ratio into the trace ID threshold below which
+// traces are sampled.
+func sampleThreshold(ratio float64) uint64 {
+	if ratio >= 1 {
+		return math.MaxUint64
+	}
+	return uint64(ratio * (1 << 63) * 2)
+}
+
+// sampleTrace reports whether the trace is sampled, given the threshold.
+func sampleTrace(id TraceID, threshold uint64) bool {
+	if threshold == math.MaxUint64 {
+		return true
+	}
+	return binary.BigEndian.Uint64(id[8:]) < threshold
+}
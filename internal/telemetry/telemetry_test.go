@@ -0,0 +1,172 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		input   string
+		valid   bool
+		sampled bool
+	}{
+		{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", true, true},
+		{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00", true, false},
+		{"01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-future", true, true},
+		{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra", false, false},
+		{"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", false, false},
+		{"00-00000000000000000000000000000000-b7ad6b7169203331-01", false, false},
+		{"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01", false, false},
+		{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b716920333x-01", false, false},
+		{"00-0af7651916cd43dd8448eb211c80319c_b7ad6b7169203331-01", false, false},
+		{"00-0af7651916cd43dd8448eb211c80319c", false, false},
+	}
+	for _, tt := range tests {
+		sc, err := ParseTraceparent(tt.input)
+		if (err == nil) != tt.valid {
+			t.Errorf("%q: validity mismatch: have %v, want valid %v", tt.input, err, tt.valid)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if sc.Sampled != tt.sampled {
+			t.Errorf("%q: sampled mismatch: have %v, want %v", tt.input, sc.Sampled, tt.sampled)
+		}
+		if tt.input[:2] == "00" && sc.Traceparent() != tt.input {
+			t.Errorf("%q: encoding mismatch: have %q", tt.input, sc.Traceparent())
+		}
+	}
+}
+
+// collector is a fake OTLP/HTTP endpoint recording the received spans.
+type collector struct {
+	lock  sync.Mutex
+	spans []otlpSpan
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req otlpExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+}
+
+// Tests that spans are linked to their parents, both remote and local, and that
+// they are exported to the collector when tracing is disabled.
+func TestExport(t *testing.T) {
+	c := new(collector)
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	if err := Enable(Config{Endpoint: srv.URL, SampleRatio: 1}); err != nil {
+		t.Fatalf("failed to enable tracing: %v", err)
+	}
+	remote, _ := ParseTraceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx := ContextWithRemoteSpanContext(context.Background(), remote)
+
+	ctx, server := StartServerSpan(ctx, "eth_call", String("rpc.method", "eth_call"))
+	_, child := StartSpan(ctx, "evm.call")
+	child.SetAttributes(Int64("gas", 21000), Bool("failed", true))
+	child.RecordError(errors.New("execution reverted"))
+	child.End()
+	server.End()
+
+	// Unsampled parents must not be recorded
+	remote.Sampled = false
+	if _, span := StartSpan(ContextWithRemoteSpanContext(context.Background(), remote), "dropped"); span != nil {
+		t.Error("span recorded for unsampled parent")
+	}
+	Disable()
+
+	if len(c.spans) != 2 {
+		t.Fatalf("exported span count mismatch: have %d, want 2", len(c.spans))
+	}
+	exported := map[string]otlpSpan{}
+	for _, span := range c.spans {
+		exported[span.Name] = span
+	}
+	have, want := exported["eth_call"], server.SpanContext()
+	if have.TraceID != remote.TraceID.String() || have.ParentSpanID != remote.SpanID.String() || have.SpanID != want.SpanID.String() {
+		t.Errorf("server span mismatch: %+v", have)
+	}
+	if have.Kind != kindServer || have.Status != nil {
+		t.Errorf("server span kind/status mismatch: %+v", have)
+	}
+	have = exported["evm.call"]
+	if have.TraceID != remote.TraceID.String() || have.ParentSpanID != want.SpanID.String() {
+		t.Errorf("child span mismatch: %+v", have)
+	}
+	if have.Status == nil || have.Status.Code != otlpStatusError || have.Status.Message != "execution reverted" {
+		t.Errorf("child span status mismatch: %+v", have.Status)
+	}
+	if len(have.Attributes) != 2 || have.Attributes[0].Value["intValue"] != "21000" || have.Attributes[1].Value["boolValue"] != true {
+		t.Errorf("child span attributes mismatch: %+v", have.Attributes)
+	}
+}
+
+// Tests that root spans are sampled according to the configured ratio, and that
+// the decision is inherited by their children.
+func TestSampling(t *testing.T) {
+	defer Disable()
+
+	if _, span := StartSpan(context.Background(), "disabled"); span != nil {
+		t.Fatal("span recorded with tracing disabled")
+	}
+	if err := Enable(Config{Endpoint: "http://localhost:0", SampleRatio: 0}); err != nil {
+		t.Fatalf("failed to enable tracing: %v", err)
+	}
+	ctx, root := StartServerSpan(context.Background(), "root")
+	if root != nil {
+		t.Fatal("root span recorded with zero sample ratio")
+	}
+	if sc, ok := SpanContextFromContext(ctx); !ok || sc.Sampled || !sc.IsValid() {
+		t.Fatalf("unsampled span context not propagated: %+v", sc)
+	}
+	if err := Enable(Config{Endpoint: "http://localhost:0", SampleRatio: 1}); err != nil {
+		t.Fatalf("failed to enable tracing: %v", err)
+	}
+	if _, span := StartSpan(ctx, "child"); span != nil {
+		t.Fatal("child of unsampled span recorded")
+	}
+	if _, span := StartSpan(context.Background(), "root"); span == nil {
+		t.Fatal("root span not recorded with full sample ratio")
+	}
+	if err := Enable(Config{Endpoint: "localhost:4318", SampleRatio: 1}); err == nil {
+		t.Fatal("endpoint without scheme accepted")
+	}
+	if err := Enable(Config{Endpoint: DefaultConfig.Endpoint, SampleRatio: 2}); err == nil {
+		t.Fatal("sample ratio above one accepted")
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// maxBundlesPerBlock is the maximum number of bundles accepted for inclusion
+	// in a single block, to cap the simulation overhead of block building.
+	maxBundlesPerBlock = 256
+
+	// maxBundleFutureBlocks is the maximum distance from the current head that
+	// bundles may target, to avoid accumulating bundles for far future blocks.
+	maxBundleFutureBlocks = 25
+
+	// maxBundles and maxBundleBytes cap the total number and size of the bundles
+	// tracked across all target blocks.
+	maxBundles     = 4096
+	maxBundleBytes = 32 * 1024 * 1024
+)
+
+var (
+	bundleSubmittedMeter    = metrics.NewRegisteredMeter("miner/bundles/submitted", nil)
+	bundleSimulatedMeter    = metrics.NewRegisteredMeter("miner/bundles/simulated", nil)
+	bundleIncludedMeter     = metrics.NewRegisteredMeter("miner/bundles/included", nil)
+	bundleFailedMeter       = metrics.NewRegisteredMeter("miner/bundles/failed", nil)       // Dropped due to a failing or reverting transaction
+	bundleUnprofitableMeter = metrics.NewRegisteredMeter("miner/bundles/unprofitable", nil) // Dropped due to paying less than the minimum tip
+	bundleExpiredMeter      = metrics.NewRegisteredMeter("miner/bundles/expired", nil)
+
+	bundleTxsIncludedMeter = metrics.NewRegisteredMeter("miner/bundles/txs/included", nil)
+)
+
+var (
+	errBundleEmpty    = errors.New("bundle contains no transactions")
+	errBundleBlobTx   = errors.New("blob transactions not supported in bundles")
+	errBundleTooLate  = errors.New("bundle target block already mined")
+	errBundleTooFar   = errors.New("bundle target block too far in the future")
+	errBundleTooMany  = errors.New("too many bundles for target block")
+	errBundlePoolFull = errors.New("bundle pool full")
+	errBundleReverted = errors.New("bundle transaction reverted")
+)
+
+// Bundle is an ordered group of transactions, which are to be included into a
+// specific block atomically: either all of them in the given order, or none.
+type Bundle struct {
+	Txs         []*types.Transaction // Transactions to include, in order
+	BlockNumber uint64               // Block number the bundle is valid for
+
+	// RevertingTxHashes is the list of transactions allowed to revert without
+	// invalidating the entire bundle.
+	RevertingTxHashes []common.Hash
+}
+
+// Hash returns the unique identifier of the bundle, derived from the hashes of
+// the contained transactions.
+func (b *Bundle) Hash() common.Hash {
+	hashes := make([]byte, 0, len(b.Txs)*common.HashLength)
+	for _, tx := range b.Txs {
+		hashes = append(hashes, tx.Hash().Bytes()...)
+	}
+	return crypto.Keccak256Hash(hashes)
+}
+
+// size returns the total encoded size of the transactions in the bundle.
+func (b *Bundle) size() uint64 {
+	var size uint64
+	for _, tx := range b.Txs {
+		size += tx.Size()
+	}
+	return size
+}
+
+// bundlePool tracks the bundles submitted for future blocks.
+type bundlePool struct {
+	bundles map[uint64][]*Bundle // Bundles grouped by target block number
+	count   int                  // Total number of bundles tracked
+	size    uint64               // Total size of the bundles tracked
+	lock    sync.Mutex
+}
+
+// newBundlePool creates an empty bundle pool.
+func newBundlePool() *bundlePool {
+	return &bundlePool{
+		bundles: make(map[uint64][]*Bundle),
+	}
+}
+
+// add inserts a bundle into the pool, dropping any expired ones.
+func (p *bundlePool) add(bundle *Bundle, head uint64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.prune(head)
+	if len(p.bundles[bundle.BlockNumber]) >= maxBundlesPerBlock {
+		return errBundleTooMany
+	}
+	hash := bundle.Hash()
+	for _, known := range p.bundles[bundle.BlockNumber] {
+		if known.Hash() == hash {
+			return nil
+		}
+	}
+	size := bundle.size()
+	if p.count >= maxBundles || p.size+size > maxBundleBytes {
+		return errBundlePoolFull
+	}
+	p.bundles[bundle.BlockNumber] = append(p.bundles[bundle.BlockNumber], bundle)
+	p.count++
+	p.size += size
+	return nil
+}
+
+// get retrieves the bundles targeting the given block number.
+func (p *bundlePool) get(number uint64) []*Bundle {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.prune(number - 1)
+	return slices.Clone(p.bundles[number])
+}
+
+// prune drops all the bundles targeting blocks at or below the given head. The
+// lock is assumed to be held.
+func (p *bundlePool) prune(head uint64) {
+	for number, bundles := range p.bundles {
+		if number <= head {
+			bundleExpiredMeter.Mark(int64(len(bundles)))
+			delete(p.bundles, number)
+
+			p.count -= len(bundles)
+			for _, bundle := range bundles {
+				p.size -= bundle.size()
+			}
+		}
+	}
+}
+
+// AddBundle submits a bundle of transactions for atomic inclusion into the block
+// with the given number, if it turns out to be profitable to do so.
+func (miner *Miner) AddBundle(bundle *Bundle) error {
+	if len(bundle.Txs) == 0 {
+		return errBundleEmpty
+	}
+	for _, tx := range bundle.Txs {
+		if tx.Type() == types.BlobTxType {
+			return errBundleBlobTx
+		}
+	}
+	head := miner.chain.CurrentHeader().Number.Uint64()
+	if bundle.BlockNumber <= head {
+		return fmt.Errorf("%w: target %d, head %d", errBundleTooLate, bundle.BlockNumber, head)
+	}
+	if bundle.BlockNumber > head+maxBundleFutureBlocks {
+		return fmt.Errorf("%w: target %d, head %d", errBundleTooFar, bundle.BlockNumber, head)
+	}
+	if err := miner.bundles.add(bundle, head); err != nil {
+		return err
+	}
+	bundleSubmittedMeter.Mark(1)
+	return nil
+}
+
+// simulatedBundle is a bundle executed against the pending state, along with
+// the resulting profit for the fee recipient.
+type simulatedBundle struct {
+	bundle  *Bundle
+	profit  *big.Int // Balance increase of the fee recipient
+	gasUsed uint64   // Total gas used by the bundle
+}
+
+// gasPrice returns the effective price paid by the bundle for each unit of gas.
+func (s *simulatedBundle) gasPrice() *big.Int {
+	if s.gasUsed == 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Div(s.profit, new(big.Int).SetUint64(s.gasUsed))
+}
+
+// commitBundles simulates all the bundles targeting the sealing block, and then
+// includes the profitable ones atomically, in the order of their effective gas
+// price. Bundles conflicting with ones included before are dropped.
+func (miner *Miner) commitBundles(env *environment, minTip *big.Int, interrupt *atomic.Int32) error {
+	bundles := miner.bundles.get(env.header.Number.Uint64())
+	if len(bundles) == 0 {
+		return nil
+	}
+	if minTip == nil {
+		minTip = new(big.Int)
+	}
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	// Simulate each bundle in isolation to determine its profitability
+	var sims []*simulatedBundle
+	for _, bundle := range bundles {
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		bundleSimulatedMeter.Mark(1)
+
+		sim, err := miner.simulateBundle(env, bundle)
+		if err != nil {
+			log.Debug("Bundle simulation failed", "hash", bundle.Hash(), "err", err)
+			bundleFailedMeter.Mark(1)
+			continue
+		}
+		if sim.gasPrice().Cmp(minTip) < 0 {
+			log.Debug("Bundle not profitable", "hash", bundle.Hash(), "price", sim.gasPrice(), "mintip", minTip)
+			bundleUnprofitableMeter.Mark(1)
+			continue
+		}
+		sims = append(sims, sim)
+	}
+	slices.SortStableFunc(sims, func(a, b *simulatedBundle) int {
+		return b.gasPrice().Cmp(a.gasPrice())
+	})
+	// Include the profitable bundles on top of each other, discarding any that
+	// fail due to a conflict with previously included ones
+	for _, sim := range sims {
+		if interrupt != nil {
+			if signal := interrupt.Load(); signal != commitInterruptNone {
+				return signalToErr(signal)
+			}
+		}
+		if err := miner.commitBundle(env, sim.bundle); err != nil {
+			log.Debug("Bundle inclusion failed", "hash", sim.bundle.Hash(), "err", err)
+			bundleFailedMeter.Mark(1)
+			continue
+		}
+		bundleIncludedMeter.Mark(1)
+		bundleTxsIncludedMeter.Mark(int64(len(sim.bundle.Txs)))
+	}
+	return nil
+}
+
+// simulateBundle executes a bundle on top of a copy of the sealing environment,
+// returning the profit it would yield for the fee recipient.
+func (miner *Miner) simulateBundle(env *environment, bundle *Bundle) (*simulatedBundle, error) {
+	work := env.copy()
+	before := work.state.GetBalance(work.coinbase).ToBig()
+	used := work.header.GasUsed
+
+	if err := miner.applyBundle(work, bundle); err != nil {
+		return nil, err
+	}
+	return &simulatedBundle{
+		bundle:  bundle,
+		profit:  new(big.Int).Sub(work.state.GetBalance(work.coinbase).ToBig(), before),
+		gasUsed: work.header.GasUsed - used,
+	}, nil
+}
+
+// commitBundle applies all the transactions of a bundle to the environment. If
+// any of them fails or reverts without being allowed to, the environment is left
+// untouched.
+//
+// Note, state snapshots do not survive across transactions, so the bundle is
+// executed on a copy of the environment which replaces the original on success.
+func (miner *Miner) commitBundle(env *environment, bundle *Bundle) error {
+	work := env.copy()
+	if err := miner.applyBundle(work, bundle); err != nil {
+		return err
+	}
+	*env = *work
+	return nil
+}
+
+// applyBundle executes the transactions of a bundle on top of the environment,
+// aborting at the first one which fails or reverts without being allowed to. The
+// environment is left in an inconsistent state on failure.
+func (miner *Miner) applyBundle(env *environment, bundle *Bundle) error {
+	for _, tx := range bundle.Txs {
+		env.state.SetTxContext(tx.Hash(), env.tcount)
+
+		if err := miner.commitTransaction(env, tx); err != nil {
+			return fmt.Errorf("tx %x: %w", tx.Hash(), err)
+		}
+		receipt := env.receipts[len(env.receipts)-1]
+		if receipt.Status == types.ReceiptStatusFailed && !slices.Contains(bundle.RevertingTxHashes, tx.Hash()) {
+			return fmt.Errorf("%w: %x", errBundleReverted, tx.Hash())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// makeBundleTx creates a transaction from the test bank account, paying a tip
+// equal to the initial base fee.
+func makeBundleTx(nonce uint64, to *common.Address, gas uint64, data []byte) *types.Transaction {
+	return types.MustSignNewTx(testBankKey, types.LatestSigner(params.TestChainConfig), &types.LegacyTx{
+		Nonce:    nonce,
+		To:       to,
+		Value:    big.NewInt(0),
+		Gas:      gas,
+		GasPrice: big.NewInt(2 * params.InitialBaseFee),
+		Data:     data,
+	})
+}
+
+// buildBundleBlock generates the next block on top of the current chain head.
+func buildBundleBlock(t *testing.T, miner *Miner, backend *testWorkerBackend) *types.Block {
+	t.Helper()
+
+	res := miner.generateWork(&generateParams{
+		parentHash: backend.chain.CurrentBlock().Hash(),
+		timestamp:  uint64(time.Now().Unix()),
+		coinbase:   common.HexToAddress("0xdeadbeef"),
+	})
+	if res.err != nil {
+		t.Fatalf("failed to generate block: %v", res.err)
+	}
+	return res.block
+}
+
+// checkBlockTxs ensures the block contains exactly the given transactions.
+func checkBlockTxs(t *testing.T, block *types.Block, txs []*types.Transaction) {
+	t.Helper()
+
+	if len(block.Transactions()) != len(txs) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(block.Transactions()), len(txs))
+	}
+	for i, tx := range block.Transactions() {
+		if tx.Hash() != txs[i].Hash() {
+			t.Errorf("tx %d: hash mismatch: have %x, want %x", i, tx.Hash(), txs[i].Hash())
+		}
+	}
+}
+
+// Tests that a profitable bundle is included atomically at the top of the block,
+// ahead of any conflicting pool transactions.
+func TestBundleInclusion(t *testing.T) {
+	miner, backend := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+
+	bundle := &Bundle{
+		Txs: []*types.Transaction{
+			makeBundleTx(0, &testUserAddress, params.TxGas, nil),
+			makeBundleTx(1, &testUserAddress, params.TxGas, nil),
+		},
+		BlockNumber: 1,
+	}
+	if err := miner.AddBundle(bundle); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	checkBlockTxs(t, buildBundleBlock(t, miner, backend), bundle.Txs)
+}
+
+// Tests that bundles with reverting transactions are only included if the revert
+// is explicitly permitted.
+func TestBundleReverting(t *testing.T) {
+	// Contract creation with an init code reverting immediately
+	revert := makeBundleTx(0, nil, 100000, common.FromHex("0x60006000fd"))
+
+	miner, backend := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	if err := miner.AddBundle(&Bundle{Txs: []*types.Transaction{revert}, BlockNumber: 1}); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	checkBlockTxs(t, buildBundleBlock(t, miner, backend), pendingTxs)
+
+	miner, backend = newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	if err := miner.AddBundle(&Bundle{Txs: []*types.Transaction{revert}, BlockNumber: 1, RevertingTxHashes: []common.Hash{revert.Hash()}}); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	checkBlockTxs(t, buildBundleBlock(t, miner, backend), []*types.Transaction{revert})
+}
+
+// Tests that bundles paying less than the minimum tip are not included.
+func TestBundleUnprofitable(t *testing.T) {
+	miner, backend := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+	miner.SetGasTip(big.NewInt(10 * params.InitialBaseFee))
+
+	bundle := &Bundle{
+		Txs:         []*types.Transaction{makeBundleTx(0, &testUserAddress, params.TxGas, nil)},
+		BlockNumber: 1,
+	}
+	if err := miner.AddBundle(bundle); err != nil {
+		t.Fatalf("failed to add bundle: %v", err)
+	}
+	checkBlockTxs(t, buildBundleBlock(t, miner, backend), pendingTxs) // local pool txs are exempt from the min tip
+}
+
+// Tests that invalid bundles are rejected on submission.
+func TestBundleValidation(t *testing.T) {
+	miner, _ := newTestWorker(t, params.TestChainConfig, ethash.NewFaker(), rawdb.NewMemoryDatabase(), 0)
+
+	tx := makeBundleTx(0, &testUserAddress, params.TxGas, nil)
+	tests := []struct {
+		bundle *Bundle
+		err    error
+	}{
+		{bundle: &Bundle{BlockNumber: 1}, err: errBundleEmpty},
+		{bundle: &Bundle{Txs: []*types.Transaction{tx}, BlockNumber: 0}, err: errBundleTooLate},
+		{bundle: &Bundle{Txs: []*types.Transaction{tx}, BlockNumber: maxBundleFutureBlocks + 1}, err: errBundleTooFar},
+		{bundle: &Bundle{Txs: []*types.Transaction{tx}, BlockNumber: 1}, err: nil},
+	}
+	for i, tt := range tests {
+		if err := miner.AddBundle(tt.bundle); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that the bundle pool limits the total number of bundles tracked, and
+// frees up the space of expired ones.
+func TestBundlePoolLimits(t *testing.T) {
+	pool := newBundlePool()
+	for i := 0; i < maxBundles; i++ {
+		bundle := &Bundle{
+			Txs:         []*types.Transaction{makeBundleTx(uint64(i), &testUserAddress, params.TxGas, nil)},
+			BlockNumber: uint64(1 + i/maxBundlesPerBlock),
+		}
+		if err := pool.add(bundle, 0); err != nil {
+			t.Fatalf("bundle %d: failed to add: %v", i, err)
+		}
+	}
+	last := uint64(maxBundles / maxBundlesPerBlock)
+	bundle := &Bundle{
+		Txs:         []*types.Transaction{makeBundleTx(maxBundles, &testUserAddress, params.TxGas, nil)},
+		BlockNumber: last + 1,
+	}
+	if err := pool.add(bundle, 0); !errors.Is(err, errBundlePoolFull) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errBundlePoolFull)
+	}
+	// Expiring the bundles of the first block should make room for new ones
+	if err := pool.add(bundle, 1); err != nil {
+		t.Fatalf("failed to add bundle after expiry: %v", err)
+	}
+	if pool.count != maxBundles-maxBundlesPerBlock+1 {
+		t.Errorf("bundle count mismatch: have %d, want %d", pool.count, maxBundles-maxBundlesPerBlock+1)
+	}
+}
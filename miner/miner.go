@@ -72,7 +72,8 @@ type Miner struct {
 	txpool      *txpool.TxPool
 	chain       *core.BlockChain
 	pending     *pending
-	pendingMu   sync.Mutex // Lock protects the pending block
+	pendingMu   sync.Mutex  // Lock protects the pending block
+	bundles     *bundlePool // Bundles submitted for atomic inclusion
 }
 
 // New creates a new miner with provided config.
@@ -84,6 +85,7 @@ func New(eth Backend, config Config, engine consensus.Engine) *Miner {
 		txpool:      eth.TxPool(),
 		chain:       eth.BlockChain(),
 		pending:     &pending{},
+		bundles:     newBundlePool(),
 	}
 }
 
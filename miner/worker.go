@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"sync/atomic"
 	"time"
 
@@ -58,6 +59,27 @@ type environment struct {
 	blobs    int
 }
 
+// copy creates a deep copy of the environment, allowing transactions to be
+// executed on top without affecting the original.
+func (env *environment) copy() *environment {
+	cpy := &environment{
+		signer:   env.signer,
+		state:    env.state.Copy(),
+		tcount:   env.tcount,
+		coinbase: env.coinbase,
+		header:   types.CopyHeader(env.header),
+		txs:      slices.Clone(env.txs),
+		receipts: slices.Clone(env.receipts),
+		sidecars: slices.Clone(env.sidecars),
+		blobs:    env.blobs,
+	}
+	if env.gasPool != nil {
+		gasPool := *env.gasPool
+		cpy.gasPool = &gasPool
+	}
+	return cpy
+}
+
 const (
 	commitInterruptNone int32 = iota
 	commitInterruptNewHead
@@ -377,14 +399,18 @@ func (miner *Miner) commitTransactions(env *environment, plainTxs, blobTxs *tran
 	return nil
 }
 
-// fillTransactions retrieves the pending transactions from the txpool and fills them
-// into the given sealing block. The transaction selection and ordering strategy can
-// be customized with the plugin in the future.
+// fillTransactions retrieves the profitable bundles and the pending transactions from
+// the txpool and fills them into the given sealing block. The transaction selection
+// and ordering strategy can be customized with the plugin in the future.
 func (miner *Miner) fillTransactions(interrupt *atomic.Int32, env *environment) error {
 	miner.confMu.RLock()
 	tip := miner.config.GasPrice
 	miner.confMu.RUnlock()
 
+	// Include any profitable bundles targeting this block ahead of the pool
+	if err := miner.commitBundles(env, tip, interrupt); err != nil {
+		return err
+	}
 	// Retrieve the pending transactions pre-filtered by the 1559/4844 dynamic fees
 	filter := txpool.PendingFilter{
 		MinTip: uint256.MustFromBig(tip),
@@ -178,9 +178,14 @@ func (api *adminAPI) StartHTTP(host *string, port *int, cors *string, apis *stri
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		connect:            api.node.config.HTTPConnect,
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			rateLimiter:            api.node.rateLimiter,
+			responseCache:          api.node.responseCache,
+			policy:                 api.node.policies.http,
+			auditor:                api.node.callAuditor(),
 		},
 	}
 	if cors != nil {
@@ -259,6 +264,10 @@ func (api *adminAPI) StartWS(host *string, port *int, allowedOrigins *string, ap
 		rpcEndpointConfig: rpcEndpointConfig{
 			batchItemLimit:         api.node.config.BatchRequestLimit,
 			batchResponseSizeLimit: api.node.config.BatchResponseMaxSize,
+			rateLimiter:            api.node.rateLimiter,
+			responseCache:          api.node.responseCache,
+			policy:                 api.node.policies.ws,
+			auditor:                api.node.callAuditor(),
 		},
 	}
 	if apis != nil {
@@ -317,6 +326,18 @@ func (api *adminAPI) NodeInfo() (*p2p.NodeInfo, error) {
 	return server.NodeInfo(), nil
 }
 
+// AuditLog returns the most recent entries of the RPC audit log matching the
+// query, in chronological order. All entries are matched if the query is omitted.
+func (api *adminAPI) AuditLog(query *AuditQuery) ([]*AuditEntry, error) {
+	if api.node.audit == nil {
+		return nil, errAuditDisabled
+	}
+	if query == nil {
+		query = new(AuditQuery)
+	}
+	return api.node.audit.query(*query)
+}
+
 // Datadir retrieves the current data directory the node is using.
 func (api *adminAPI) Datadir() string {
 	return api.node.DataDir()
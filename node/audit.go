@@ -0,0 +1,315 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	auditMaxParamSize      = 1024  // Larger parameters are recorded by size and hash
+	auditDefaultQueryLimit = 100   // Number of entries returned if the query sets no limit
+	auditMaxQueryLimit     = 10000 // Maximum number of entries returned by a query
+)
+
+// auditRedactedParams lists the positions of the secret parameters of methods,
+// which are never written to the audit log.
+var auditRedactedParams = map[string][]int{
+	"personal_openWallet":      {1},
+	"personal_newAccount":      {0},
+	"personal_importRawKey":    {0, 1},
+	"personal_unlockAccount":   {1},
+	"personal_sendTransaction": {1},
+	"personal_signTransaction": {1},
+	"personal_sign":            {2},
+	"personal_unpair":          {1},
+}
+
+var errAuditDisabled = errors.New("RPC audit log is disabled")
+
+// AuditEntry is the record of a call in the RPC audit log.
+type AuditEntry struct {
+	Time     time.Time       `json:"time"`
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params,omitempty"` // Redacted parameters
+	Duration time.Duration   `json:"duration"`         // Time taken to serve the call, in nanoseconds
+
+	// Identity of the caller
+	Transport  string                 `json:"transport"`
+	RemoteAddr string                 `json:"remoteAddr,omitempty"`
+	UserAgent  string                 `json:"userAgent,omitempty"`
+	Claims     map[string]interface{} `json:"claims,omitempty"` // JWT claims on authenticated endpoints
+
+	// Result of the call
+	Status    string `json:"status"` // "ok" or "error"
+	ErrorCode int    `json:"errorCode,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// AuditQuery selects entries of the RPC audit log. Empty fields match all entries.
+type AuditQuery struct {
+	Method string     `json:"method"` // Namespace or method name
+	Client string     `json:"client"` // IP address or JWT subject of the caller
+	From   *time.Time `json:"from"`
+	To     *time.Time `json:"to"`
+	Limit  int        `json:"limit"` // Maximum number of entries, the most recent are returned
+}
+
+// auditLog is an append-only record of the calls to privileged RPC methods, kept
+// in files rotated by size. Rotated files are retained.
+type auditLog struct {
+	methods []string // Audited namespaces, or methods given by full name
+	path    string
+	log     log.Logger
+
+	lock sync.Mutex
+	out  *lumberjack.Logger
+}
+
+// newAuditLog opens the audit log at path, recording the calls of the given
+// namespaces or methods, and rotating the file once it exceeds maxSize megabytes.
+func newAuditLog(path string, methods []string, maxSize int, logger log.Logger) (*auditLog, error) {
+	if len(methods) == 0 {
+		return nil, errors.New("no namespaces to audit")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	out := &lumberjack.Logger{Filename: path, MaxSize: maxSize}
+
+	// Open the file right away to report a misconfigured path at startup.
+	if _, err := out.Write(nil); err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	logger.Info("Auditing RPC calls", "path", path, "namespaces", strings.Join(methods, ","))
+	return &auditLog{methods: methods, path: path, log: logger, out: out}, nil
+}
+
+// Audits implements rpc.CallAuditor, reporting whether the method belongs to an
+// audited namespace or is audited by name.
+func (a *auditLog) Audits(method string) bool {
+	return slices.ContainsFunc(a.methods, func(name string) bool {
+		return auditMatch(name, method)
+	})
+}
+
+// auditMatch reports whether the method is the given one, or in the given namespace.
+func auditMatch(name, method string) bool {
+	if strings.Contains(name, "_") {
+		return name == method
+	}
+	namespace, _, _ := strings.Cut(method, "_")
+	return namespace == name
+}
+
+// RecordCall implements rpc.CallAuditor, appending the call to the log.
+func (a *auditLog) RecordCall(ctx context.Context, call *rpc.CallRecord) {
+	peer := rpc.PeerInfoFromContext(ctx)
+	entry := &AuditEntry{
+		Time:       call.Start.UTC(),
+		Method:     call.Method,
+		Params:     redactAuditParams(call.Method, call.Params),
+		Duration:   call.Duration,
+		Transport:  peer.Transport,
+		RemoteAddr: peer.RemoteAddr,
+		UserAgent:  peer.HTTP.UserAgent,
+		Claims:     peer.AuthClaims,
+		Status:     "ok",
+	}
+	if call.Err != nil {
+		entry.Status = "error"
+		entry.Error = call.Err.Error()
+		var rpcErr rpc.Error
+		if errors.As(call.Err, &rpcErr) {
+			entry.ErrorCode = rpcErr.ErrorCode()
+		}
+	}
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		a.log.Error("Failed to encode RPC audit entry", "method", call.Method, "err", err)
+		return
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if _, err := a.out.Write(append(blob, '\n')); err != nil {
+		a.log.Error("Failed to write RPC audit entry", "method", call.Method, "err", err)
+	}
+}
+
+// redactAuditParams returns the parameters of a call as recorded in the audit log.
+// Secret parameters are replaced by a placeholder, and large ones by their size
+// and hash.
+func redactAuditParams(method string, params json.RawMessage) json.RawMessage {
+	if len(params) == 0 {
+		return nil
+	}
+	var args []json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil {
+		// Not positional parameters, which the server rejects. Record the size
+		// only, they may still hold secrets.
+		return auditSummary(`"[invalid: %d bytes]"`, len(params))
+	}
+	for _, index := range auditRedactedParams[method] {
+		if index < len(args) {
+			args[index] = json.RawMessage(`"[redacted]"`)
+		}
+	}
+	for i, arg := range args {
+		if len(arg) > auditMaxParamSize {
+			args[i] = auditSummary(`"[%d bytes, keccak256 %x]"`, len(arg), crypto.Keccak256(arg))
+		}
+	}
+	blob, _ := json.Marshal(args)
+	return blob
+}
+
+func auditSummary(format string, args ...interface{}) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(format, args...))
+}
+
+// close flushes and closes the current file of the log.
+func (a *auditLog) close() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.out.Close()
+}
+
+// query returns the most recent entries matching the query, in chronological order.
+func (a *auditLog) query(query AuditQuery) ([]*AuditEntry, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = auditDefaultQueryLimit
+	}
+	limit = min(limit, auditMaxQueryLimit)
+
+	files, err := a.openFiles()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+	var entries []*AuditEntry
+	for _, file := range files {
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(nil, 1024*1024)
+		for scanner.Scan() {
+			entry := new(AuditEntry)
+			if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+				continue // Partially written entry
+			}
+			if !query.matches(entry) {
+				continue
+			}
+			if len(entries) == limit {
+				entries = entries[1:]
+			}
+			entries = append(entries, entry)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name(), err)
+		}
+	}
+	return entries, nil
+}
+
+// openFiles opens the rotated and current files of the log, oldest first. The files
+// are opened while holding the lock, so that reading them is not disturbed by the
+// rotation of the log.
+func (a *auditLog) openFiles() ([]*os.File, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	// Rotated files are named <name>-<timestamp><ext>, sorting chronologically.
+	var (
+		dir   = filepath.Dir(a.path)
+		ext   = filepath.Ext(a.path)
+		name  = strings.TrimSuffix(filepath.Base(a.path), ext) + "-"
+		paths []string
+	)
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, dirent := range dirents {
+		if base := dirent.Name(); strings.HasPrefix(base, name) && strings.HasSuffix(base, ext) && !dirent.IsDir() {
+			paths = append(paths, filepath.Join(dir, base))
+		}
+	}
+	sort.Strings(paths)
+	paths = append(paths, a.path)
+
+	var files []*os.File
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			for _, file := range files {
+				file.Close()
+			}
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// matches reports whether the entry is selected by the query.
+func (q *AuditQuery) matches(entry *AuditEntry) bool {
+	if q.Method != "" && !auditMatch(q.Method, entry.Method) {
+		return false
+	}
+	if q.From != nil && entry.Time.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && entry.Time.After(*q.To) {
+		return false
+	}
+	if q.Client != "" {
+		host, _, err := net.SplitHostPort(entry.RemoteAddr)
+		if err != nil {
+			host = entry.RemoteAddr
+		}
+		if subject, _ := entry.Claims["sub"].(string); q.Client != host && q.Client != subject {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that the audited calls are recorded with redacted parameters, and that the
+// entries can be queried across rotated files.
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "rpc.log")
+	audit, err := newAuditLog(path, []string{"personal", "debug_setHead"}, 1, log.Root())
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer audit.close()
+
+	for method, want := range map[string]bool{
+		"personal_unlockAccount": true,
+		"debug_setHead":          true,
+		"debug_traceCall":        false,
+		"personalized_call":      false,
+	} {
+		if audit.Audits(method) != want {
+			t.Errorf("%s: audited mismatch, want %v", method, want)
+		}
+	}
+	var (
+		start = time.Now()
+		ctx   = context.Background()
+		large = `"0x` + strings.Repeat("00", auditMaxParamSize) + `"`
+	)
+	record := func(method string, params string, err error) {
+		audit.RecordCall(ctx, &rpc.CallRecord{Method: method, Params: json.RawMessage(params), Start: start, Err: err})
+		start = start.Add(time.Second)
+	}
+	record("personal_unlockAccount", `["0x01", "secret", 10]`, nil)
+	record("debug_setHead", `["0x10"]`, nil)
+	if err := audit.out.Rotate(); err != nil {
+		t.Fatalf("failed to rotate audit log: %v", err)
+	}
+	record("personal_importRawKey", `["0xkey", "secret"]`, &rpc.HTTPError{StatusCode: 500})
+	record("debug_setHead", `[`+large+`]`, nil)
+
+	entries, err := audit.query(AuditQuery{})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("entry count mismatch: have %d, want 4", len(entries))
+	}
+	for i, want := range []string{
+		`["0x01","[redacted]",10]`,
+		`["0x10"]`,
+		`["[redacted]","[redacted]"]`,
+		`["[2052 bytes, keccak256 `,
+	} {
+		if !strings.HasPrefix(string(entries[i].Params), want) {
+			t.Errorf("entry %d: params mismatch: have %s, want %s", i, entries[i].Params, want)
+		}
+	}
+	if entries[2].Status != "error" || entries[0].Status != "ok" {
+		t.Errorf("status mismatch: have %s and %s", entries[2].Status, entries[0].Status)
+	}
+	// Check the query filters
+	from := entries[1].Time
+	for _, tt := range []struct {
+		query AuditQuery
+		want  []string
+	}{
+		{AuditQuery{Method: "debug_setHead"}, []string{"debug_setHead", "debug_setHead"}},
+		{AuditQuery{Method: "personal", Limit: 1}, []string{"personal_importRawKey"}},
+		{AuditQuery{From: &from, To: &from}, []string{"debug_setHead"}},
+		{AuditQuery{Client: "127.0.0.1"}, nil},
+	} {
+		entries, err := audit.query(tt.query)
+		if err != nil {
+			t.Fatalf("failed to query audit log: %v", err)
+		}
+		var have []string
+		for _, entry := range entries {
+			have = append(have, entry.Method)
+		}
+		if strings.Join(have, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("query %+v: methods mismatch: have %v, want %v", tt.query, have, tt.want)
+		}
+	}
+}
+
+// Tests that the node records the calls on its endpoints, and serves the audit
+// log through the admin API.
+func TestAuditLogNode(t *testing.T) {
+	conf := testNodeConfig()
+	conf.DataDir = t.TempDir()
+	conf.RPCAuditLog = "audit.log"
+	conf.RPCAuditNamespaces = []string{"admin"}
+
+	stack, err := New(conf)
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	defer stack.Close()
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	client := stack.Attach()
+	defer client.Close()
+
+	var datadir string
+	if err := client.Call(&datadir, "admin_datadir"); err != nil {
+		t.Fatal(err)
+	}
+	var entries []*AuditEntry
+	if err := client.Call(&entries, "admin_auditLog", AuditQuery{Method: "admin_datadir"}); err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Status != "ok" {
+		t.Fatalf("audit entries mismatch: %+v", entries)
+	}
+}
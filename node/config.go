@@ -137,6 +137,16 @@ type Config struct {
 	// HTTPPathPrefix specifies a path prefix on which http-rpc is to be served.
 	HTTPPathPrefix string `toml:",omitempty"`
 
+	// HTTPConnect enables the binary Connect transport on the HTTP-RPC servers,
+	// served below the path prefix alongside JSON-RPC. The authenticated server
+	// serves it with the same JWT authentication as the engine API.
+	HTTPConnect bool `toml:",omitempty"`
+
+	// HTTPTraceStream enables streaming chain traces over plain HTTP. The stream
+	// endpoint is not served by the RPC server, so it is not subject to the RPC
+	// rate limits, method policies and audit log.
+	HTTPTraceStream bool `toml:",omitempty"`
+
 	// AuthAddr is the listening address on which authenticated APIs are provided.
 	AuthAddr string `toml:",omitempty"`
 
@@ -204,6 +214,40 @@ type Config struct {
 	// BatchResponseMaxSize is the maximum number of bytes returned from a batched rpc call.
 	BatchResponseMaxSize int `toml:",omitempty"`
 
+	// RPCRateLimits are the per client limits on method calls over the public
+	// HTTP and WebSocket endpoints. The authenticated endpoints are not limited.
+	RPCRateLimits []rpc.RateLimit `toml:",omitempty"`
+
+	// RPCRateLimitKeyHeader is the HTTP header carrying the API key by which
+	// clients are identified for rate limiting. If unset, absent or not one of
+	// RPCRateLimitKeys, clients are identified by IP address.
+	RPCRateLimitKeyHeader string `toml:",omitempty"`
+
+	// RPCRateLimitKeys is the set of API keys accepted in RPCRateLimitKeyHeader.
+	RPCRateLimitKeys []string `toml:",omitempty"`
+
+	// RPCResponseCacheSize is the maximum number of bytes used to cache the results
+	// of RPC calls against finalized data, on the public HTTP and WebSocket endpoints
+	// as well as in-process. Zero disables the cache.
+	RPCResponseCacheSize int `toml:",omitempty"`
+
+	// RPCPolicyFile is the path of the JSON file restricting the methods which may
+	// be called on the public HTTP and WebSocket endpoints, and their parameters.
+	RPCPolicyFile string `toml:",omitempty"`
+
+	// RPCAuditLog is the path of the file recording the calls of the audited RPC
+	// namespaces on all endpoints. Relative paths are resolved in the instance
+	// directory. Empty disables auditing.
+	RPCAuditLog string `toml:",omitempty"`
+
+	// RPCAuditNamespaces lists the audited namespaces, or single methods given by
+	// their full name (e.g. "debug_setHead").
+	RPCAuditNamespaces []string `toml:",omitempty"`
+
+	// RPCAuditMaxSize is the size in megabytes at which the audit log is rotated.
+	// Rotated files are retained.
+	RPCAuditMaxSize int `toml:",omitempty"`
+
 	// JWTSecret is the path to the hex-encoded jwt secret.
 	JWTSecret string `toml:",omitempty"`
 
@@ -50,6 +50,9 @@ var (
 	DefaultAuthOrigins = []string{"localhost"} // Default origins for the authenticated apis
 	DefaultAuthPrefix  = ""                    // Default prefix for the authenticated apis
 	DefaultAuthModules = []string{"eth", "engine"}
+
+	// Default namespaces and methods recorded in the RPC audit log
+	DefaultAuditNamespaces = []string{"admin", "personal", "miner", "engine", "debug_setHead"}
 )
 
 // DefaultConfig contains reasonable default settings.
@@ -67,6 +70,8 @@ var DefaultConfig = Config{
 	BatchRequestLimit:    1000,
 	BatchResponseMaxSize: 25 * 1000 * 1000,
 	GraphQLVirtualHosts:  []string{"localhost"},
+	RPCAuditNamespaces:   DefaultAuditNamespaces,
+	RPCAuditMaxSize:      100,
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
 		MaxPeers:   50,
@@ -21,6 +21,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -75,6 +76,17 @@ func (handler *jwtHandler) ServeHTTP(out http.ResponseWriter, r *http.Request) {
 	case time.Until(claims.IssuedAt.Time) > jwtExpiryTimeout:
 		http.Error(out, "future token", http.StatusUnauthorized)
 	default:
+		ctx := r.Context()
+		if claims.Subject != "" {
+			ctx = rpc.NewContextWithAuthSubject(ctx, claims.Subject)
+		}
+		// Expose all claims of the verified token, including the optional
+		// non-registered ones like the client version.
+		allClaims := make(jwt.MapClaims)
+		if _, _, err := jwt.NewParser().ParseUnverified(strToken, allClaims); err == nil {
+			ctx = rpc.NewContextWithAuthClaims(ctx, allClaims)
+		}
+		r = r.WithContext(ctx)
 		handler.next.ServeHTTP(out, r)
 	}
 }
@@ -66,6 +66,11 @@ type Node struct {
 	ipc           *ipcServer  // Stores information about the ipc http server
 	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
 
+	rateLimiter   *rpc.RateLimiter   // Per client rate limiter shared by the public HTTP and WS endpoints
+	responseCache *rpc.ResponseCache // Cache of immutable call results shared by the public endpoints
+	policies      rpcPolicies        // Method and parameter restrictions of the public endpoints
+	audit         *auditLog          // Record of the privileged calls on all endpoints
+
 	databases map[*closeTrackingDB]struct{} // All open databases
 }
 
@@ -105,9 +110,37 @@ func New(conf *Config) (*Node, error) {
 	}
 	server := rpc.NewServer()
 	server.SetBatchLimits(conf.BatchRequestLimit, conf.BatchResponseMaxSize)
+
+	var limiter *rpc.RateLimiter
+	if len(conf.RPCRateLimits) > 0 {
+		var err error
+		limiter, err = rpc.NewRateLimiter(rpc.RateLimitConfig{
+			Limits:    conf.RPCRateLimits,
+			KeyHeader: conf.RPCRateLimitKeyHeader,
+			Keys:      conf.RPCRateLimitKeys,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	var cache *rpc.ResponseCache
+	if conf.RPCResponseCacheSize > 0 {
+		cache = rpc.NewResponseCache(conf.RPCResponseCacheSize)
+		server.SetResponseCache(cache)
+	}
+	var policies rpcPolicies
+	if conf.RPCPolicyFile != "" {
+		var err error
+		if policies, err = loadRPCPolicies(conf.RPCPolicyFile); err != nil {
+			return nil, err
+		}
+	}
 	node := &Node{
 		config:        conf,
 		inprocHandler: server,
+		rateLimiter:   limiter,
+		responseCache: cache,
+		policies:      policies,
 		eventmux:      new(event.TypeMux),
 		log:           conf.Logger,
 		stop:          make(chan struct{}),
@@ -149,12 +182,29 @@ func New(conf *Config) (*Node, error) {
 		return nil, err
 	}
 
+	// Open the RPC audit log.
+	if conf.RPCAuditLog != "" {
+		path := conf.ResolvePath(conf.RPCAuditLog)
+		if path == "" {
+			return nil, errors.New("relative RPC audit log path requires a data directory")
+		}
+		namespaces := conf.RPCAuditNamespaces
+		if len(namespaces) == 0 {
+			namespaces = DefaultAuditNamespaces
+		}
+		if node.audit, err = newAuditLog(path, namespaces, conf.RPCAuditMaxSize, node.log); err != nil {
+			return nil, err
+		}
+		node.inprocHandler.SetCallAuditor(node.audit)
+	}
+
 	// Configure RPC servers.
 	node.http = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.httpAuth = newHTTPServer(node.log, conf.HTTPTimeouts)
 	node.ws = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
 	node.wsAuth = newHTTPServer(node.log, rpc.DefaultHTTPTimeouts)
 	node.ipc = newIPCServer(node.log, conf.IPCEndpoint())
+	node.ipc.auditor = node.callAuditor()
 
 	return node, nil
 }
@@ -241,6 +291,11 @@ func (n *Node) doClose(errs []error) error {
 	if err := n.accman.Close(); err != nil {
 		errs = append(errs, err)
 	}
+	if n.audit != nil {
+		if err := n.audit.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if n.keyDirTemp {
 		if err := os.RemoveAll(n.keyDir); err != nil {
 			errs = append(errs, err)
@@ -405,18 +460,24 @@ func (n *Node) startRPC() error {
 	rpcConfig := rpcEndpointConfig{
 		batchItemLimit:         n.config.BatchRequestLimit,
 		batchResponseSizeLimit: n.config.BatchResponseMaxSize,
+		rateLimiter:            n.rateLimiter,
+		responseCache:          n.responseCache,
+		auditor:                n.callAuditor(),
 	}
 
 	initHttp := func(server *httpServer, port int) error {
 		if err := server.setListenAddr(n.config.HTTPHost, port); err != nil {
 			return err
 		}
+		config := rpcConfig
+		config.policy = n.policies.http
 		if err := server.enableRPC(openAPIs, httpConfig{
 			CorsAllowedOrigins: n.config.HTTPCors,
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
 			prefix:             n.config.HTTPPathPrefix,
-			rpcEndpointConfig:  rpcConfig,
+			connect:            n.config.HTTPConnect,
+			rpcEndpointConfig:  config,
 		}); err != nil {
 			return err
 		}
@@ -429,11 +490,13 @@ func (n *Node) startRPC() error {
 		if err := server.setListenAddr(n.config.WSHost, port); err != nil {
 			return err
 		}
+		config := rpcConfig
+		config.policy = n.policies.ws
 		if err := server.enableWS(openAPIs, wsConfig{
 			Modules:           n.config.WSModules,
 			Origins:           n.config.WSOrigins,
 			prefix:            n.config.WSPathPrefix,
-			rpcEndpointConfig: rpcConfig,
+			rpcEndpointConfig: config,
 		}); err != nil {
 			return err
 		}
@@ -452,12 +515,15 @@ func (n *Node) startRPC() error {
 			batchItemLimit:         engineAPIBatchItemLimit,
 			batchResponseSizeLimit: engineAPIBatchResponseSizeLimit,
 			httpBodyLimit:          engineAPIBodyLimit,
+			auditor:                n.callAuditor(),
 		}
-		err := server.enableRPC(allAPIs, httpConfig{
+		authAPIs, authModules := n.getAuthAPIs()
+		err := server.enableRPC(authAPIs, httpConfig{
 			CorsAllowedOrigins: DefaultAuthCors,
 			Vhosts:             n.config.AuthVirtualHosts,
-			Modules:            DefaultAuthModules,
+			Modules:            authModules,
 			prefix:             DefaultAuthPrefix,
+			connect:            n.config.HTTPConnect,
 			rpcEndpointConfig:  sharedConfig,
 		})
 		if err != nil {
@@ -470,8 +536,8 @@ func (n *Node) startRPC() error {
 		if err := server.setListenAddr(n.config.AuthAddr, port); err != nil {
 			return err
 		}
-		if err := server.enableWS(allAPIs, wsConfig{
-			Modules:           DefaultAuthModules,
+		if err := server.enableWS(authAPIs, wsConfig{
+			Modules:           authModules,
 			Origins:           DefaultAuthOrigins,
 			prefix:            DefaultAuthPrefix,
 			rpcEndpointConfig: sharedConfig,
@@ -602,6 +668,22 @@ func (n *Node) getAPIs() (unauthenticated, all []rpc.API) {
 	return unauthenticated, n.rpcAPIs
 }
 
+// getAuthAPIs returns the APIs served on the authenticated endpoints, along with
+// their namespaces. Beside the default auth modules, APIs explicitly requiring
+// authentication are exposed too, but without the rest of their namespace.
+func (n *Node) getAuthAPIs() (apis []rpc.API, modules []string) {
+	modules = slices.Clone(DefaultAuthModules)
+	for _, api := range n.rpcAPIs {
+		if slices.Contains(DefaultAuthModules, api.Namespace) || api.Authenticated {
+			apis = append(apis, api)
+			if !slices.Contains(modules, api.Namespace) {
+				modules = append(modules, api.Namespace)
+			}
+		}
+	}
+	return apis, modules
+}
+
 // RegisterHandler mounts a handler on the given path on the canonical HTTP server.
 //
 // The name of the handler is shown in a log message when the HTTP server starts
@@ -623,6 +705,34 @@ func (n *Node) Attach() *rpc.Client {
 	return rpc.DialInProc(n.inprocHandler)
 }
 
+// RPCResponseCache returns the cache of immutable RPC call results, or nil if the
+// cache is disabled. Services must invalidate it if the chain is rolled back past
+// a block they reported as cacheable.
+func (n *Node) RPCResponseCache() *rpc.ResponseCache {
+	return n.responseCache
+}
+
+// RPCPolicies returns the method and parameter restrictions of the public RPC
+// endpoints. Services should set the head function of the policies, so that block
+// ranges given by tags can be checked.
+func (n *Node) RPCPolicies() []*rpc.Policy {
+	var policies []*rpc.Policy
+	for _, policy := range []*rpc.Policy{n.policies.http, n.policies.ws} {
+		if policy != nil {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+// callAuditor returns the auditor of the RPC endpoints, or nil if auditing is disabled.
+func (n *Node) callAuditor() rpc.CallAuditor {
+	if n.audit == nil {
+		return nil
+	}
+	return n.audit
+}
+
 // RPCHandler returns the in-process RPC request handler.
 func (n *Node) RPCHandler() (*rpc.Server, error) {
 	n.lock.Lock()
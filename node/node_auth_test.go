@@ -197,6 +197,76 @@ func TestAuthEndpoints(t *testing.T) {
 	}
 }
 
+type goodbyeRPC string
+
+func (ta goodbyeRPC) GoodbyeWorld() (string, error) {
+	return string(ta), nil
+}
+
+// Tests that authenticated APIs outside of the default auth modules are exposed on
+// the authenticated endpoints, without leaking the rest of their namespace.
+func TestAuthEndpointsCustomNamespace(t *testing.T) {
+	var secret [32]byte
+	if _, err := crand.Read(secret[:]); err != nil {
+		t.Fatalf("failed to create jwt secret: %v", err)
+	}
+	jwtPath := filepath.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(jwtPath, []byte(hexutil.Encode(secret[:])), 0600); err != nil {
+		t.Fatalf("failed to prepare jwt secret file: %v", err)
+	}
+	conf := &Config{
+		HTTPHost:    "127.0.0.1",
+		HTTPPort:    0,
+		AuthAddr:    "127.0.0.1",
+		AuthPort:    0,
+		JWTSecret:   jwtPath,
+		HTTPModules: []string{"debug"},
+	}
+	node, err := New(conf)
+	if err != nil {
+		t.Fatalf("could not create a new node: %v", err)
+	}
+	node.RegisterAPIs([]rpc.API{
+		{
+			Namespace:     "debug",
+			Service:       helloRPC("hello debug"),
+			Authenticated: true,
+		},
+		{
+			Namespace: "debug",
+			Service:   goodbyeRPC("goodbye debug"),
+		},
+	})
+	if err := node.Start(); err != nil {
+		t.Fatalf("failed to start test node: %v", err)
+	}
+	defer node.Close()
+
+	call := func(endpoint string, opts []rpc.ClientOption, method string) error {
+		cl, err := rpc.DialOptions(context.Background(), endpoint, opts...)
+		if err != nil {
+			t.Fatalf("failed to dial rpc endpoint: %v", err)
+		}
+		defer cl.Close()
+
+		var x string
+		return cl.Call(&x, method)
+	}
+	auth := []rpc.ClientOption{rpc.WithHTTPAuth(NewJWTAuth(secret))}
+	if err := call(node.HTTPAuthEndpoint(), auth, "debug_helloWorld"); err != nil {
+		t.Errorf("authenticated method unreachable on auth endpoint: %v", err)
+	}
+	if err := call(node.HTTPAuthEndpoint(), auth, "debug_goodbyeWorld"); err == nil {
+		t.Errorf("unauthenticated method reachable on auth endpoint")
+	}
+	if err := call(node.HTTPEndpoint(), nil, "debug_helloWorld"); err == nil {
+		t.Errorf("authenticated method reachable on open endpoint")
+	}
+	if err := call(node.HTTPEndpoint(), nil, "debug_goodbyeWorld"); err != nil {
+		t.Errorf("unauthenticated method unreachable on open endpoint: %v", err)
+	}
+}
+
 func noneAuth(secret [32]byte) rpc.HTTPAuth {
 	return func(header http.Header) error {
 		token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
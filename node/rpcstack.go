@@ -19,11 +19,13 @@ package node
 import (
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -34,6 +36,8 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/rs/cors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // httpConfig is the JSON-RPC/HTTP configuration.
@@ -42,6 +46,7 @@ type httpConfig struct {
 	CorsAllowedOrigins []string
 	Vhosts             []string
 	prefix             string // path prefix on which to mount http handler
+	connect            bool   // whether to serve the Connect transport next to JSON-RPC
 	rpcEndpointConfig
 }
 
@@ -58,11 +63,61 @@ type rpcEndpointConfig struct {
 	batchItemLimit         int
 	batchResponseSizeLimit int
 	httpBodyLimit          int
+	rateLimiter            *rpc.RateLimiter   // optional per client rate limiter
+	responseCache          *rpc.ResponseCache // optional cache of immutable call results
+	policy                 *rpc.Policy        // optional method and parameter restrictions
+	auditor                rpc.CallAuditor    // optional record of privileged calls
+}
+
+// rpcPolicies are the method and parameter restrictions of the public endpoints.
+type rpcPolicies struct {
+	http *rpc.Policy
+	ws   *rpc.Policy
+}
+
+// loadRPCPolicies reads the RPC policy file, a JSON object holding the policies of
+// the public HTTP and WebSocket endpoints under the "http" and "ws" keys:
+//
+//	{
+//	  "http": {
+//	    "deny":   ["debug_*"],
+//	    "params": [{"method": "eth_getLogs", "param": 0, "maxBlockRange": 10000}]
+//	  }
+//	}
+func loadRPCPolicies(path string) (rpcPolicies, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return rpcPolicies{}, err
+	}
+	defer file.Close()
+
+	var configs struct {
+		HTTP *rpc.PolicyConfig `json:"http"`
+		WS   *rpc.PolicyConfig `json:"ws"`
+	}
+	dec := json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&configs); err != nil {
+		return rpcPolicies{}, fmt.Errorf("invalid RPC policy file %s: %v", path, err)
+	}
+	var policies rpcPolicies
+	if configs.HTTP != nil {
+		if policies.http, err = rpc.NewPolicy(*configs.HTTP); err != nil {
+			return rpcPolicies{}, fmt.Errorf("invalid HTTP RPC policy: %v", err)
+		}
+	}
+	if configs.WS != nil {
+		if policies.ws, err = rpc.NewPolicy(*configs.WS); err != nil {
+			return rpcPolicies{}, fmt.Errorf("invalid WebSocket RPC policy: %v", err)
+		}
+	}
+	return policies, nil
 }
 
 type rpcHandler struct {
 	http.Handler
-	server *rpc.Server
+	server  *rpc.Server
+	connect http.Handler // optional Connect transport handler
 }
 
 type httpServer struct {
@@ -138,8 +193,11 @@ func (h *httpServer) start() error {
 		return nil // already running or not configured
 	}
 
-	// Initialize the server.
+	// Initialize the server, permitting cleartext HTTP/2 for the Connect transport.
 	h.server = &http.Server{Handler: h}
+	if h.httpConfig.connect {
+		h.server.Handler = h2c.NewHandler(h, new(http2.Server))
+	}
 	if h.timeouts != (rpc.HTTPTimeouts{}) {
 		CheckTimeouts(&h.timeouts)
 		h.server.ReadTimeout = h.timeouts.ReadTimeout
@@ -174,6 +232,7 @@ func (h *httpServer) start() error {
 	// Log http endpoint.
 	h.log.Info("HTTP server started",
 		"endpoint", listener.Addr(), "auth", (h.httpConfig.jwtSecret != nil),
+		"connect", h.httpConfig.connect,
 		"prefix", h.httpConfig.prefix,
 		"cors", strings.Join(h.httpConfig.CorsAllowedOrigins, ","),
 		"vhosts", strings.Join(h.httpConfig.Vhosts, ","),
@@ -218,6 +277,10 @@ func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			muxHandler.ServeHTTP(w, r)
 			return
 		}
+		if rpc.connect != nil && isConnect(r, h.httpConfig.prefix) {
+			rpc.connect.ServeHTTP(w, r)
+			return
+		}
 
 		if checkPath(r, h.httpConfig.prefix) {
 			rpc.ServeHTTP(w, r)
@@ -306,6 +369,10 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	// Create RPC server and handler.
 	srv := rpc.NewServer()
 	srv.SetBatchLimits(config.batchItemLimit, config.batchResponseSizeLimit)
+	srv.SetRateLimiter(config.rateLimiter)
+	srv.SetResponseCache(config.responseCache)
+	srv.SetPolicy(config.policy)
+	srv.SetCallAuditor(config.auditor)
 	if config.httpBodyLimit > 0 {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
@@ -313,10 +380,14 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 		return err
 	}
 	h.httpConfig = config
-	h.httpHandler.Store(&rpcHandler{
+	handler := &rpcHandler{
 		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret),
 		server:  srv,
-	})
+	}
+	if config.connect {
+		handler.connect = NewConnectHandlerStack(srv.ConnectHandler(), config.CorsAllowedOrigins, config.Vhosts, config.jwtSecret)
+	}
+	h.httpHandler.Store(handler)
 	return nil
 }
 
@@ -341,6 +412,10 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	// Create RPC server and handler.
 	srv := rpc.NewServer()
 	srv.SetBatchLimits(config.batchItemLimit, config.batchResponseSizeLimit)
+	srv.SetRateLimiter(config.rateLimiter)
+	srv.SetResponseCache(config.responseCache)
+	srv.SetPolicy(config.policy)
+	srv.SetCallAuditor(config.auditor)
 	if config.httpBodyLimit > 0 {
 		srv.SetHTTPBodyLimit(config.httpBodyLimit)
 	}
@@ -393,6 +468,13 @@ func isWebsocket(r *http.Request) bool {
 		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
 }
 
+// isConnect checks whether an http request calls a procedure of the Connect service
+// mounted below the given path prefix.
+func isConnect(r *http.Request, prefix string) bool {
+	path, ok := strings.CutPrefix(r.URL.Path, strings.TrimSuffix(prefix, "/"))
+	return ok && strings.HasPrefix(path, "/"+rpc.ConnectServiceName+"/")
+}
+
 // NewHTTPHandlerStack returns wrapped http-related handlers
 func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, jwtSecret []byte) http.Handler {
 	// Wrap the CORS-handler within a host-handler
@@ -404,6 +486,17 @@ func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, jwtSe
 	return newGzipHandler(handler)
 }
 
+// NewConnectHandlerStack returns a wrapped Connect-related handler. Unlike plain
+// HTTP, responses are not gzipped as the protocol negotiates compression itself.
+func NewConnectHandlerStack(srv http.Handler, cors []string, vhosts []string, jwtSecret []byte) http.Handler {
+	handler := newCorsHandler(srv, cors)
+	handler = newVHostHandler(vhosts, handler)
+	if len(jwtSecret) != 0 {
+		handler = newJWTHandler(jwtSecret, handler)
+	}
+	return handler
+}
+
 // NewWSHandlerStack returns a wrapped ws-related handler.
 func NewWSHandlerStack(srv http.Handler, jwtSecret []byte) http.Handler {
 	if len(jwtSecret) != 0 {
@@ -561,6 +654,12 @@ func (w *gzipResponseWriter) Flush() {
 	}
 }
 
+// Unwrap returns the wrapped response writer, allowing http.ResponseController
+// to reach the underlying connection.
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.resp
+}
+
 func (w *gzipResponseWriter) close() {
 	if w.gz == nil {
 		return
@@ -587,6 +686,7 @@ func newGzipHandler(next http.Handler) http.Handler {
 type ipcServer struct {
 	log      log.Logger
 	endpoint string
+	auditor  rpc.CallAuditor
 
 	mu       sync.Mutex
 	listener net.Listener
@@ -605,7 +705,9 @@ func (is *ipcServer) start(apis []rpc.API) error {
 	if is.listener != nil {
 		return nil // already running
 	}
-	listener, srv, err := rpc.StartIPCEndpoint(is.endpoint, apis)
+	srv := rpc.NewServer()
+	srv.SetCallAuditor(is.auditor)
+	listener, srv, err := rpc.StartIPCEndpointWithServer(is.endpoint, apis, srv)
 	if err != nil {
 		is.log.Warn("IPC opening failed", "url", is.endpoint, "error", err)
 		return err
@@ -18,11 +18,16 @@ package node
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -34,6 +39,8 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 const testMethod = "rpc_modules"
@@ -596,6 +603,98 @@ func TestHTTPWriteTimeout(t *testing.T) {
 	})
 }
 
+// Tests that the Connect transport is served below the path prefix over cleartext
+// HTTP/2, guarded by the same JWT authentication as JSON-RPC.
+func TestConnectHTTP2(t *testing.T) {
+	secret := []byte("secret")
+	srv := createAndStartServer(t, &httpConfig{
+		prefix:            "/rpc",
+		connect:           true,
+		rpcEndpointConfig: rpcEndpointConfig{jwtSecret: secret},
+	}, false, nil, nil)
+	defer srv.stop()
+
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, network, addr)
+		},
+	}}
+	call := func(token string) *http.Response {
+		t.Helper()
+
+		var body []byte
+		body = protowire.AppendTag(body, 1, protowire.BytesType)
+		body = protowire.AppendString(body, "test_greet")
+
+		url := fmt.Sprintf("http://%s/rpc/%s/Call", srv.listenAddr(), rpc.ConnectServiceName)
+		req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		req.Header.Set("content-type", "application/proto")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	if resp := call(""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated call: status mismatch: have %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaim{"iat": time.Now().Unix()}).SignedString(secret)
+	resp := call(token)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("authenticated call: status mismatch: have %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Errorf("protocol mismatch: have %s, want HTTP/2", resp.Proto)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !bytes.Contains(body, []byte(`"Hello"`)) {
+		t.Errorf("unexpected response: %x", body)
+	}
+}
+
+// Tests that the RPC policy file is loaded, and that its policies are enforced on
+// the endpoints.
+func TestRPCPolicyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"grpc": {}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRPCPolicies(path); err == nil {
+		t.Fatal("policy file with unknown endpoint accepted")
+	}
+	if err := os.WriteFile(path, []byte(`{"http": {"deny": ["test_greet"]}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := loadRPCPolicies(path)
+	if err != nil {
+		t.Fatalf("failed to load policy file: %v", err)
+	}
+	if policies.http == nil || policies.ws != nil {
+		t.Fatalf("loaded policies mismatch: %+v", policies)
+	}
+	srv := createAndStartServer(t, &httpConfig{
+		rpcEndpointConfig: rpcEndpointConfig{policy: policies.http},
+	}, false, nil, nil)
+	defer srv.stop()
+
+	for method, want := range map[string]string{
+		"test_greet":  `"code":-32004`,
+		"rpc_modules": `"result"`,
+	} {
+		resp := rpcRequest(t, "http://"+srv.listenAddr(), method)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(string(body), want) {
+			t.Errorf("%s: response mismatch: have %s, want %s", method, body, want)
+		}
+	}
+}
+
 func apis() []rpc.API {
 	return []rpc.API{
 		{
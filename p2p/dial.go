@@ -32,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"golang.org/x/net/quic"
 )
 
 const (
@@ -69,6 +70,30 @@ func (t tcpDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error)
 	return t.d.DialContext(ctx, "tcp", addr.String())
 }
 
+// quicDialer implements NodeDialer using QUIC connections to the nodes announcing
+// a QUIC endpoint. Other nodes, and nodes which can't be reached over QUIC, are
+// dialed with the fallback dialer.
+type quicDialer struct {
+	endpoint *quic.Endpoint
+	config   *quic.Config
+	fallback NodeDialer
+}
+
+func (t quicDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	if addr, ok := dest.QUICEndpoint(); ok {
+		qctx, cancel := context.WithTimeout(ctx, quicDialTimeout)
+		fd, err := dialQUIC(qctx, t.endpoint, t.config, addr)
+		cancel()
+		if err == nil {
+			return fd, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	return t.fallback.Dial(ctx, dest)
+}
+
 // checkDial errors:
 var (
 	errSelf             = errors.New("is self")
@@ -77,6 +102,7 @@ var (
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNetRestrict      = errors.New("not contained in netrestrict list")
 	errNoPort           = errors.New("node does not provide TCP port")
+	errBadReputation    = errors.New("node has bad reputation")
 )
 
 // dialer creates outbound connections and submits them into Server.
@@ -132,6 +158,7 @@ type dialConfig struct {
 	netRestrict    *netutil.Netlist // IP netrestrict list, disabled if nil
 	resolver       nodeResolver
 	dialer         NodeDialer
+	reputation     *reputation // scores of known nodes, nil if not tracked
 	log            log.Logger
 	clock          mclock.Clock
 	rand           *mrand.Rand
@@ -239,7 +266,7 @@ loop:
 
 		select {
 		case node := <-nodesCh:
-			if err := d.checkDial(node); err != nil {
+			if err := d.checkDynamicDial(node); err != nil {
 				d.log.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IPAddr(), "reason", err)
 			} else {
 				d.startDial(newDialTask(node, dynDialedConn))
@@ -394,6 +421,18 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	return nil
 }
 
+// checkDynamicDial returns an error if node n found by discovery should not be
+// dialed. Unlike static nodes, nodes of bad reputation are not dialed.
+func (d *dialScheduler) checkDynamicDial(n *enode.Node) error {
+	if err := d.checkDial(n); err != nil {
+		return err
+	}
+	if d.reputation.bad(n.ID()) {
+		return errBadReputation
+	}
+	return nil
+}
+
 // startStaticDials starts n static dial tasks.
 func (d *dialScheduler) startStaticDials(n int) (started int) {
 	for started = 0; started < n && len(d.staticPool) > 0; started++ {
@@ -0,0 +1,739 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/rand"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/common/prque"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// Advertiser settings.
+	topicAdLifetime       = 15 * time.Minute // how long an ad stays in the topic table
+	topicAdRenewal        = time.Minute      // registrants renew this long before expiry
+	topicQueueLimit       = 100              // max ads per topic
+	topicTableLimit       = 10000            // max ads in total
+	topicIPLimit          = 10               // max ads and reservations per IP
+	topicTicketValidity   = time.Minute      // how long a ticket can be used after its wait time
+	topicQueryResultLimit = 16               // max nodes in TOPICQUERY response
+
+	// Registrant/searcher settings.
+	topicAdvertisers      = 16               // number of nodes a topic is registered with
+	topicLookupInterval   = 5 * time.Minute  // how often advertisers are looked up again
+	topicRetryDelay       = 10 * time.Second // delay after a failed registration attempt
+	topicMaxFails         = 3                // failed attempts before an advertiser is dropped
+	topicQueryNodes       = 8                // nodes asked during topic search
+	topicQueryMinInterval = time.Second
+	topicQueryMaxInterval = time.Minute
+)
+
+var (
+	errTopicTicketInvalid = errors.New("invalid ticket")
+	errTopicTicketEarly   = errors.New("ticket used before wait time")
+)
+
+// Topic identifies a service advertised in discovery v5. It is the hash of a
+// human-readable topic name.
+type Topic [32]byte
+
+// NewTopic creates the topic identifier of the given name.
+func NewTopic(name string) Topic {
+	return Topic(crypto.Keccak256Hash([]byte(name)))
+}
+
+// String returns the topic as hex.
+func (t Topic) String() string {
+	return hexutil.Encode(t[:])
+}
+
+// topicSystem implements topic advertisement and search.
+//
+// The advertiser side stores ads of other nodes in the topic table and is only
+// accessed by the dispatch loop. The registrant side runs one goroutine per
+// registered topic.
+type topicSystem struct {
+	transport *UDPv5
+	table     *topicTable
+
+	mu     sync.Mutex
+	regs   map[Topic]*topicRegistration
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func newTopicSystem(transport *UDPv5) *topicSystem {
+	return &topicSystem{
+		transport: transport,
+		table:     newTopicTable(transport.clock),
+		regs:      make(map[Topic]*topicRegistration),
+	}
+}
+
+// RegisterTopic starts advertising the local node for the given topic. The
+// node keeps registering with the nodes closest to the topic until
+// UnregisterTopic is called.
+func (t *UDPv5) RegisterTopic(topic Topic) {
+	t.topics.register(topic)
+}
+
+// UnregisterTopic stops advertising the local node for the given topic. Ads
+// placed earlier expire on their own.
+func (t *UDPv5) UnregisterTopic(topic Topic) {
+	t.topics.unregister(topic)
+}
+
+// TopicQuery searches the network for nodes advertising the given topic.
+func (t *UDPv5) TopicQuery(topic Topic) []*enode.Node {
+	return t.topics.search(t.closeCtx, topic)
+}
+
+// TopicNodes returns an iterator over the nodes advertising the given topic.
+// The search is repeated when the iterator runs out of nodes.
+func (t *UDPv5) TopicNodes(topic Topic) enode.Iterator {
+	ctx, cancel := context.WithCancel(t.closeCtx)
+	return &topicIterator{sys: t.topics, topic: topic, ctx: ctx, cancel: cancel}
+}
+
+// close stops all registrations and waits for them to exit.
+func (s *topicSystem) close() {
+	s.mu.Lock()
+	s.closed = true
+	for topic, reg := range s.regs {
+		reg.cancel()
+		delete(s.regs, topic)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *topicSystem) register(topic Topic) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.regs[topic] != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(s.transport.closeCtx)
+	reg := &topicRegistration{
+		topic:       topic,
+		cancel:      cancel,
+		advertisers: make(map[enode.ID]*topicAdvertiser),
+	}
+	s.regs[topic] = reg
+	s.wg.Add(1)
+	go s.runRegistration(ctx, reg)
+}
+
+func (s *topicSystem) unregister(topic Topic) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reg := s.regs[topic]; reg != nil {
+		reg.cancel()
+		delete(s.regs, topic)
+	}
+}
+
+// topicRegistration is the registrant state of a topic.
+type topicRegistration struct {
+	topic  Topic
+	cancel context.CancelFunc
+
+	// advertisers is guarded by topicSystem.mu.
+	advertisers map[enode.ID]*topicAdvertiser
+}
+
+// topicAdvertiser is a node the local node registers with.
+type topicAdvertiser struct {
+	node   *enode.Node
+	ticket []byte
+	next   time.Time // time of next registration attempt
+	fails  int
+}
+
+// runRegistration keeps the local node registered for a topic.
+func (s *topicSystem) runRegistration(ctx context.Context, reg *topicRegistration) {
+	defer s.wg.Done()
+
+	var (
+		log        = s.transport.log.New("topic", reg.topic)
+		timer      = time.NewTimer(0)
+		lastLookup time.Time
+	)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+		if time.Since(lastLookup) >= topicLookupInterval {
+			nodes := s.transport.newLookup(ctx, enode.ID(reg.topic)).run()
+			s.updateAdvertisers(reg, nodes)
+			lastLookup = time.Now()
+			log.Debug("Looked up topic advertisers", "n", len(nodes))
+		}
+
+		// Register with all advertisers that are due.
+		s.mu.Lock()
+		var due []*topicAdvertiser
+		for _, a := range reg.advertisers {
+			if !a.next.After(time.Now()) {
+				due = append(due, a)
+			}
+		}
+		s.mu.Unlock()
+		for _, a := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			s.registerWith(reg, a)
+		}
+
+		// Wait until the next attempt is due.
+		next := lastLookup.Add(topicLookupInterval)
+		s.mu.Lock()
+		for id, a := range reg.advertisers {
+			if a.fails >= topicMaxFails {
+				delete(reg.advertisers, id)
+				continue
+			}
+			if a.next.Before(next) {
+				next = a.next
+			}
+		}
+		s.mu.Unlock()
+		timer.Reset(time.Until(next))
+	}
+}
+
+// updateAdvertisers adds the closest nodes of a lookup to the advertiser set.
+// The state of known advertisers is kept.
+func (s *topicSystem) updateAdvertisers(reg *topicRegistration, nodes []*enode.Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range nodes {
+		if len(reg.advertisers) >= topicAdvertisers {
+			break
+		}
+		if reg.advertisers[n.ID()] == nil {
+			reg.advertisers[n.ID()] = &topicAdvertiser{node: n}
+		}
+	}
+}
+
+// registerWith sends REGTOPIC to an advertiser and schedules the next attempt.
+func (s *topicSystem) registerWith(reg *topicRegistration, a *topicAdvertiser) {
+	s.mu.Lock()
+	req := &v5wire.Regtopic{
+		Topic:  reg.topic,
+		ENR:    s.transport.Self().Record(),
+		Ticket: a.ticket,
+	}
+	s.mu.Unlock()
+
+	resp := s.transport.callToNode(a.node, v5wire.TicketMsg, req)
+	defer s.transport.callDone(resp)
+
+	var (
+		ticket *v5wire.Ticket
+		err    error
+	)
+	select {
+	case p := <-resp.ch:
+		ticket = p.(*v5wire.Ticket)
+	case err = <-resp.err:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.transport.log.Debug("Topic registration failed", "topic", reg.topic, "id", a.node.ID(), "err", err)
+		a.ticket = nil
+		a.next = time.Now().Add(topicRetryDelay)
+		a.fails++
+		return
+	}
+	a.ticket = ticket.Ticket
+	a.next = time.Now().Add(ticketWait(ticket.WaitTime))
+	a.fails = 0
+}
+
+// ticketWait converts the wait time of a ticket to the delay of the next attempt.
+// The wait time is chosen by the advertiser, so it is kept within bounds.
+func ticketWait(seconds uint) time.Duration {
+	if seconds >= uint(topicAdLifetime/time.Second) {
+		return topicAdLifetime
+	}
+	return max(time.Duration(seconds)*time.Second, topicRetryDelay)
+}
+
+// handleConfirmation processes a REGCONFIRMATION sent by an advertiser.
+func (s *topicSystem) handleConfirmation(p *v5wire.Regconfirmation, fromID enode.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg := s.regs[p.Topic]
+	if reg == nil || reg.advertisers[fromID] == nil {
+		s.transport.log.Debug("Unsolicited "+p.Name(), "id", fromID, "topic", Topic(p.Topic))
+		return
+	}
+	s.transport.log.Debug("Topic registered", "topic", reg.topic, "id", fromID)
+}
+
+// handleRegtopic processes a REGTOPIC request. The request is always answered
+// with a ticket. If the ad was placed, a confirmation is sent as well.
+func (s *topicSystem) handleRegtopic(p *v5wire.Regtopic, fromID enode.ID, fromAddr netip.AddrPort) {
+	n, err := s.checkRegistrant(p.ENR, fromID, fromAddr)
+	if err != nil {
+		s.transport.log.Debug("Invalid "+p.Name(), "id", fromID, "addr", fromAddr, "err", err)
+		return
+	}
+	wait, ticket, ok := s.table.register(n, p.Topic, p.Ticket)
+	resp := &v5wire.Ticket{
+		ReqID:    p.ReqID,
+		Ticket:   ticket,
+		WaitTime: uint((wait + time.Second - 1) / time.Second),
+	}
+	s.transport.sendResponse(fromID, fromAddr, resp)
+	if ok {
+		s.transport.sendResponse(fromID, fromAddr, &v5wire.Regconfirmation{ReqID: p.ReqID, Topic: p.Topic})
+	}
+}
+
+// checkRegistrant verifies the record in a REGTOPIC request. Nodes can only
+// advertise themselves, on the address the request was sent from.
+func (s *topicSystem) checkRegistrant(r *enr.Record, fromID enode.ID, fromAddr netip.AddrPort) (*enode.Node, error) {
+	if r == nil {
+		return nil, errors.New("missing record")
+	}
+	n, err := enode.New(s.transport.validSchemes, r)
+	if err != nil {
+		return nil, err
+	}
+	if n.ID() != fromID {
+		return nil, errors.New("record does not match sender")
+	}
+	if n.IPAddr() != fromAddr.Addr().Unmap() {
+		return nil, errors.New("record IP does not match sender address")
+	}
+	if n.UDP() <= 1024 {
+		return nil, errLowPort
+	}
+	if s.transport.netrestrict != nil && !s.transport.netrestrict.ContainsAddr(n.IPAddr()) {
+		return nil, errors.New("not contained in netrestrict list")
+	}
+	return n, nil
+}
+
+// handleTopicQuery returns the ads of a topic to the requester.
+func (s *topicSystem) handleTopicQuery(p *v5wire.TopicQuery, fromID enode.ID, fromAddr netip.AddrPort) {
+	nodes := s.table.nodes(p.Topic, fromAddr.Addr(), topicQueryResultLimit)
+	for _, resp := range packNodes(p.ReqID, nodes) {
+		s.transport.sendResponse(fromID, fromAddr, resp)
+	}
+}
+
+// search looks up the nodes closest to the topic and queries them for ads.
+func (s *topicSystem) search(ctx context.Context, topic Topic) []*enode.Node {
+	advertisers := s.transport.newLookup(ctx, enode.ID(topic)).run()
+	if len(advertisers) > topicQueryNodes {
+		advertisers = advertisers[:topicQueryNodes]
+	}
+
+	results := make(chan []*enode.Node, len(advertisers))
+	for _, n := range advertisers {
+		go func() {
+			nodes, err := s.query(n, topic)
+			if err != nil {
+				s.transport.log.Debug("Topic query failed", "topic", topic, "id", n.ID(), "err", err)
+			}
+			results <- nodes
+		}()
+	}
+	var (
+		nodes []*enode.Node
+		seen  = map[enode.ID]bool{s.transport.Self().ID(): true}
+	)
+	for range advertisers {
+		for _, n := range <-results {
+			if !seen[n.ID()] {
+				seen[n.ID()] = true
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes
+}
+
+// query calls TOPICQUERY on a node and waits for the responses.
+func (s *topicSystem) query(n *enode.Node, topic Topic) ([]*enode.Node, error) {
+	resp := s.transport.callToNode(n, v5wire.NodesMsg, &v5wire.TopicQuery{Topic: topic})
+	return s.transport.waitForNodes(resp, nil)
+}
+
+// topicIterator repeatedly searches for the nodes advertising a topic.
+type topicIterator struct {
+	sys    *topicSystem
+	topic  Topic
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	buffer   []*enode.Node
+	searched bool
+	interval time.Duration
+}
+
+// Node returns the current node.
+func (it *topicIterator) Node() *enode.Node {
+	if len(it.buffer) == 0 {
+		return nil
+	}
+	return it.buffer[0]
+}
+
+// Next moves to the next node.
+func (it *topicIterator) Next() bool {
+	if len(it.buffer) > 0 {
+		it.buffer = it.buffer[1:]
+	}
+	for len(it.buffer) == 0 {
+		if it.searched && !it.wait() {
+			it.buffer = nil
+			return false
+		}
+		if it.ctx.Err() != nil {
+			it.buffer = nil
+			return false
+		}
+		it.buffer = it.sys.search(it.ctx, it.topic)
+		it.searched = true
+	}
+	return true
+}
+
+// wait sleeps before the next search. The interval doubles on every search
+// until it reaches the maximum.
+func (it *topicIterator) wait() bool {
+	it.interval = min(max(2*it.interval, topicQueryMinInterval), topicQueryMaxInterval)
+	timer := time.NewTimer(it.interval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-it.ctx.Done():
+		return false
+	}
+}
+
+// Close ends the iterator.
+func (it *topicIterator) Close() {
+	it.cancel()
+}
+
+// topicTable stores the ads placed by other nodes.
+//
+// When a topic queue or the table is full, registrants receive a ticket with the
+// time to wait for a slot, and the slot is reserved for them. Slots freed by
+// expiring ads can only be taken by registrants presenting their ticket after the
+// wait time, not by new ones. Ads can only be renewed with the ticket handed out
+// when placing them.
+type topicTable struct {
+	clock  mclock.Clock
+	key    []byte // ticket MAC key
+	queues map[Topic][]*topicAd
+	count  int
+	ips    map[netip.Addr]int // ads and reservations per registrant IP
+	ads    *prque.Prque[int64, *topicAd]
+
+	waits      map[topicWaitKey]*topicWait
+	topicWaits map[Topic]int
+	waitQueue  *prque.Prque[int64, *topicWait]
+}
+
+// topicAd is an advertisement in the topic table. Ads of a topic queue are
+// ordered by expiry.
+type topicAd struct {
+	node    *enode.Node
+	topic   Topic
+	expires mclock.AbsTime
+	index   int // index in the expiry queue of the table
+}
+
+// topicWait is a slot reserved for a registrant waiting for its ticket.
+type topicWait struct {
+	key     topicWaitKey
+	ip      netip.Addr
+	ticket  []byte
+	usable  mclock.AbsTime // time after which the ticket can be used
+	expires mclock.AbsTime // time after which the ticket is no longer valid
+	index   int            // index in the expiry queue of the table
+}
+
+type topicWaitKey struct {
+	topic Topic
+	id    enode.ID
+}
+
+// topicTicket is the content of a ticket. Tickets are authenticated with a key
+// known only to the advertiser, and are only valid for the node they were
+// issued to.
+type topicTicket struct {
+	Topic  Topic
+	ID     enode.ID
+	Issued uint64
+	Wait   uint64
+}
+
+func newTopicTable(clock mclock.Clock) *topicTable {
+	key := make([]byte, 32)
+	crand.Read(key)
+	return &topicTable{
+		clock:      clock,
+		key:        key,
+		queues:     make(map[Topic][]*topicAd),
+		ips:        make(map[netip.Addr]int),
+		ads:        prque.New[int64](func(ad *topicAd, i int) { ad.index = i }),
+		waits:      make(map[topicWaitKey]*topicWait),
+		topicWaits: make(map[Topic]int),
+		waitQueue:  prque.New[int64](func(w *topicWait, i int) { w.index = i }),
+	}
+}
+
+// register attempts to place an ad for n. It returns the time the registrant
+// should wait before the next attempt, the ticket for that attempt, and
+// whether the ad was placed.
+func (tab *topicTable) register(n *enode.Node, topic Topic, ticket []byte) (time.Duration, []byte, bool) {
+	now := tab.clock.Now()
+	tab.expire(now)
+
+	var matured bool
+	if len(ticket) > 0 {
+		tk, err := tab.decodeTicket(ticket, n.ID(), topic, now)
+		if err == errTopicTicketEarly {
+			// Don't let the registrant reset its wait time.
+			remaining := time.Duration(tk.Wait) - now.Sub(mclock.AbsTime(tk.Issued))
+			return remaining, ticket, false
+		}
+		matured = err == nil
+	}
+	key := topicWaitKey{topic, n.ID()}
+	w := tab.waits[key]
+	if !matured && w != nil {
+		// Don't let the registrant skip its wait by dropping the ticket.
+		return max(w.usable.Sub(now), 0), w.ticket, false
+	}
+	queue := tab.queues[topic]
+	if i := slices.IndexFunc(queue, func(ad *topicAd) bool { return ad.node.ID() == n.ID() }); i >= 0 {
+		if !matured {
+			wait := max(queue[i].expires.Sub(now)-topicAdRenewal, 0)
+			return wait, tab.encodeTicket(n.ID(), topic, now, wait), false
+		}
+		// Renew the existing ad in its slot.
+		tab.removeAd(queue[i])
+		return tab.placeAd(n, topic, now)
+	}
+	// Registrants without a reservation can't take the slots reserved for others.
+	var queueWaits, tableWaits int
+	if w != nil {
+		tab.removeWait(w)
+	} else {
+		queueWaits, tableWaits = tab.topicWaits[topic], len(tab.waits)
+	}
+	var wait time.Duration
+	switch {
+	case tab.ips[n.IPAddr()] >= topicIPLimit:
+		return topicAdLifetime, tab.encodeTicket(n.ID(), topic, now, topicAdLifetime), false
+	case len(queue)+queueWaits >= topicQueueLimit:
+		// Wait for enough ads to expire to serve the registrants waiting already.
+		if i := len(queue) + tab.topicWaits[topic] - topicQueueLimit; i < len(queue) {
+			wait = queue[i].expires.Sub(now)
+		} else {
+			wait = topicAdLifetime
+		}
+	case tab.count+tableWaits >= topicTableLimit:
+		wait = tab.nextExpiry().Sub(now)
+	default:
+		return tab.placeAd(n, topic, now)
+	}
+	return wait, tab.reserve(key, n.IPAddr(), now, wait), false
+}
+
+// placeAd adds an ad for n to the table.
+func (tab *topicTable) placeAd(n *enode.Node, topic Topic, now mclock.AbsTime) (time.Duration, []byte, bool) {
+	ad := &topicAd{node: n, topic: topic, expires: now.Add(topicAdLifetime)}
+	tab.queues[topic] = append(tab.queues[topic], ad)
+	tab.ads.Push(ad, -int64(ad.expires))
+	tab.ips[n.IPAddr()]++
+	tab.count++
+
+	wait := topicAdLifetime - topicAdRenewal
+	return wait, tab.encodeTicket(n.ID(), topic, now, wait), true
+}
+
+// removeAd removes an ad from the table.
+func (tab *topicTable) removeAd(ad *topicAd) {
+	tab.ads.Remove(ad.index)
+	queue := tab.queues[ad.topic]
+	if i := slices.Index(queue, ad); i >= 0 {
+		queue = slices.Delete(queue, i, i+1)
+	}
+	if len(queue) == 0 {
+		delete(tab.queues, ad.topic)
+	} else {
+		tab.queues[ad.topic] = queue
+	}
+	tab.releaseIP(ad.node.IPAddr())
+	tab.count--
+}
+
+// reserve issues a ticket for a registrant which has to wait for a slot, and
+// reserves the slot for it unless there are too many registrants waiting.
+func (tab *topicTable) reserve(key topicWaitKey, ip netip.Addr, now mclock.AbsTime, wait time.Duration) []byte {
+	ticket := tab.encodeTicket(key.id, key.topic, now, wait)
+	if tab.topicWaits[key.topic] >= topicQueueLimit || len(tab.waits) >= topicTableLimit {
+		return ticket
+	}
+	w := &topicWait{
+		key:     key,
+		ip:      ip,
+		ticket:  ticket,
+		usable:  now.Add(wait),
+		expires: now.Add(wait + topicTicketValidity),
+	}
+	tab.waits[key] = w
+	tab.waitQueue.Push(w, -int64(w.expires))
+	tab.topicWaits[key.topic]++
+	tab.ips[ip]++
+	return ticket
+}
+
+// removeWait releases a reserved slot.
+func (tab *topicTable) removeWait(w *topicWait) {
+	tab.waitQueue.Remove(w.index)
+	delete(tab.waits, w.key)
+	if tab.topicWaits[w.key.topic]--; tab.topicWaits[w.key.topic] == 0 {
+		delete(tab.topicWaits, w.key.topic)
+	}
+	tab.releaseIP(w.ip)
+}
+
+// releaseIP decrements the number of ads and reservations of an IP.
+func (tab *topicTable) releaseIP(ip netip.Addr) {
+	if tab.ips[ip]--; tab.ips[ip] == 0 {
+		delete(tab.ips, ip)
+	}
+}
+
+// nodes returns up to limit random ads of a topic.
+func (tab *topicTable) nodes(topic Topic, rip netip.Addr, limit int) []*enode.Node {
+	tab.expire(tab.clock.Now())
+
+	var nodes []*enode.Node
+	queue := tab.queues[topic]
+	for _, i := range rand.Perm(len(queue)) {
+		if netutil.CheckRelayAddr(rip, queue[i].node.IPAddr()) != nil {
+			continue
+		}
+		if nodes = append(nodes, queue[i].node); len(nodes) >= limit {
+			break
+		}
+	}
+	return nodes
+}
+
+// expire removes expired ads and reservations.
+func (tab *topicTable) expire(now mclock.AbsTime) {
+	for !tab.ads.Empty() {
+		ad, _ := tab.ads.Peek()
+		if ad.expires > now {
+			break
+		}
+		tab.removeAd(ad)
+	}
+	for !tab.waitQueue.Empty() {
+		w, _ := tab.waitQueue.Peek()
+		if w.expires >= now {
+			break
+		}
+		tab.removeWait(w)
+	}
+}
+
+// nextExpiry returns the expiry time of the oldest ad in the table.
+func (tab *topicTable) nextExpiry() mclock.AbsTime {
+	if tab.ads.Empty() {
+		return tab.clock.Now().Add(topicAdLifetime)
+	}
+	ad, _ := tab.ads.Peek()
+	return ad.expires
+}
+
+func (tab *topicTable) encodeTicket(id enode.ID, topic Topic, now mclock.AbsTime, wait time.Duration) []byte {
+	enc, _ := rlp.EncodeToBytes(&topicTicket{Topic: topic, ID: id, Issued: uint64(now), Wait: uint64(wait)})
+	mac := hmac.New(sha256.New, tab.key)
+	mac.Write(enc)
+	return mac.Sum(enc)
+}
+
+// decodeTicket verifies a ticket presented by a registrant.
+func (tab *topicTable) decodeTicket(ticket []byte, id enode.ID, topic Topic, now mclock.AbsTime) (*topicTicket, error) {
+	if len(ticket) < sha256.Size {
+		return nil, errTopicTicketInvalid
+	}
+	enc, sum := ticket[:len(ticket)-sha256.Size], ticket[len(ticket)-sha256.Size:]
+	mac := hmac.New(sha256.New, tab.key)
+	mac.Write(enc)
+	if !hmac.Equal(mac.Sum(nil), sum) {
+		return nil, errTopicTicketInvalid
+	}
+	var tk topicTicket
+	if err := rlp.DecodeBytes(enc, &tk); err != nil {
+		return nil, errTopicTicketInvalid
+	}
+	if tk.ID != id || tk.Topic != topic {
+		return nil, errTopicTicketInvalid
+	}
+	usable := mclock.AbsTime(tk.Issued).Add(time.Duration(tk.Wait))
+	switch {
+	case now < usable:
+		return &tk, errTopicTicketEarly
+	case now > usable.Add(topicTicketValidity):
+		return nil, errTopicTicketInvalid
+	}
+	return &tk, nil
+}
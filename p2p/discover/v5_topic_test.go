@@ -0,0 +1,184 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// This test checks that a registered topic can be found by other nodes.
+func TestUDPv5_topicE2E(t *testing.T) {
+	t.Parallel()
+
+	const N = 4
+	var nodes []*UDPv5
+	for i := 0; i < N; i++ {
+		var cfg Config
+		if len(nodes) > 0 {
+			cfg.Bootnodes = []*enode.Node{nodes[0].Self()}
+		}
+		node := startLocalhostV5(t, cfg)
+		nodes = append(nodes, node)
+		defer node.Close()
+	}
+	topic := NewTopic("test")
+	nodes[1].RegisterTopic(topic)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		results := nodes[N-1].TopicQuery(topic)
+		if len(results) == 1 && results[0].ID() == nodes[1].Self().ID() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("topic query returned wrong results: %v", results)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if results := nodes[N-1].TopicQuery(NewTopic("other")); len(results) != 0 {
+		t.Fatalf("query for unregistered topic returned %v", results)
+	}
+}
+
+// This test checks that full topic queues hand out tickets, and that tickets
+// can only be used after their wait time.
+func TestTopicTable(t *testing.T) {
+	var (
+		clock = new(mclock.Simulated)
+		tab   = newTopicTable(clock)
+		topic = NewTopic("test")
+		local = netip.MustParseAddr("127.0.0.1")
+	)
+	for i := 0; i < topicQueueLimit; i++ {
+		n := nodeAtDistance(enode.ID{}, 256, intIP(i))
+		if _, _, ok := tab.register(n, topic, nil); !ok {
+			t.Fatalf("registration %d failed", i)
+		}
+		clock.Run(time.Second)
+	}
+	if nodes := tab.nodes(topic, local, topicQueryResultLimit); len(nodes) != topicQueryResultLimit {
+		t.Fatalf("wrong number of nodes: %d", len(nodes))
+	}
+
+	// The queue is full, registrants have to wait until the first ad expires.
+	n := nodeAtDistance(enode.ID{}, 256, intIP(topicQueueLimit))
+	wait, ticket, ok := tab.register(n, topic, nil)
+	if ok {
+		t.Fatal("registration succeeded in full queue")
+	}
+	if want := topicAdLifetime - topicQueueLimit*time.Second; wait != want {
+		t.Fatalf("wrong wait time %v, want %v", wait, want)
+	}
+
+	// Using the ticket early doesn't reset the wait time.
+	clock.Run(wait / 2)
+	wait2, ticket2, ok := tab.register(n, topic, ticket)
+	if ok || wait2 != wait/2 || string(ticket2) != string(ticket) {
+		t.Fatalf("early ticket accepted: ok=%t wait=%v", ok, wait2)
+	}
+
+	// Dropping the ticket doesn't reset the wait time either.
+	if wait3, ticket3, ok := tab.register(n, topic, nil); ok || wait3 != wait/2 || string(ticket3) != string(ticket) {
+		t.Fatalf("registration without ticket not rejected: ok=%t wait=%v", ok, wait3)
+	}
+
+	// The slot freed by the first ad is reserved for the ticket holder.
+	clock.Run(wait / 2)
+	other := nodeAtDistance(enode.ID{}, 256, intIP(topicQueueLimit+1))
+	if _, _, ok := tab.register(other, topic, nil); ok {
+		t.Fatal("registration without ticket took reserved slot")
+	}
+	if _, _, ok := tab.register(n, topic, ticket); !ok {
+		t.Fatal("registration with ticket failed")
+	}
+	if tab.count != topicQueueLimit {
+		t.Fatalf("wrong ad count %d", tab.count)
+	}
+
+	// Tickets are bound to the node they were issued to.
+	if _, err := tab.decodeTicket(ticket, other.ID(), topic, clock.Now()); err != errTopicTicketInvalid {
+		t.Fatalf("ticket of other node accepted: %v", err)
+	}
+}
+
+// This test checks that ads can only be renewed with the ticket handed out on
+// placement, and that the number of ads per IP is limited.
+func TestTopicTableLimits(t *testing.T) {
+	var (
+		clock = new(mclock.Simulated)
+		tab   = newTopicTable(clock)
+		ip    = intIP(1)
+	)
+	n := nodeAtDistance(enode.ID{}, 256, ip)
+	_, ticket, ok := tab.register(n, NewTopic("0"), nil)
+	if !ok {
+		t.Fatal("registration failed")
+	}
+	wait, _, ok := tab.register(n, NewTopic("0"), nil)
+	if ok || wait != topicAdLifetime-topicAdRenewal {
+		t.Fatalf("renewal without ticket not rejected: ok=%t wait=%v", ok, wait)
+	}
+	clock.Run(wait)
+	if _, _, ok := tab.register(n, NewTopic("0"), ticket); !ok {
+		t.Fatal("renewal with ticket failed")
+	}
+	if ad, _ := tab.ads.Peek(); ad.expires != clock.Now().Add(topicAdLifetime) {
+		t.Fatalf("ad not renewed, expires %v", ad.expires)
+	}
+
+	// Further ads from the same IP are rejected beyond the limit.
+	for i := 1; i < topicIPLimit; i++ {
+		if _, _, ok := tab.register(n, NewTopic(fmt.Sprint(i)), nil); !ok {
+			t.Fatalf("registration %d failed", i)
+		}
+	}
+	if _, _, ok := tab.register(n, NewTopic("last"), nil); ok {
+		t.Fatal("registration beyond IP limit succeeded")
+	}
+
+	// All ads expire from the index.
+	clock.Run(topicAdLifetime)
+	tab.expire(clock.Now())
+	if tab.count != 0 || len(tab.queues) != 0 || len(tab.ips) != 0 {
+		t.Fatalf("ads not expired: count %d, queues %d, ips %d", tab.count, len(tab.queues), len(tab.ips))
+	}
+}
+
+// Tests that the wait times of tickets are kept within bounds.
+func TestTicketWait(t *testing.T) {
+	for _, tt := range []struct {
+		seconds uint
+		want    time.Duration
+	}{
+		{0, topicRetryDelay},
+		{60, time.Minute},
+		{uint(topicAdLifetime / time.Second), topicAdLifetime},
+		{math.MaxInt64/uint(time.Second) + 1, topicAdLifetime},
+		{math.MaxUint, topicAdLifetime},
+	} {
+		if wait := ticketWait(tt.seconds); wait != tt.want {
+			t.Errorf("wait time %d: have %v, want %v", tt.seconds, wait, tt.want)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"net/netip"
 	"os"
 	"sync"
@@ -54,12 +55,16 @@ const (
 	// Local information is keyed by ID only, the full key is "local:<ID>:seq".
 	// Use localItemKey to create those keys.
 	dbLocalSeq = "seq"
+
+	// Reputation scores are keyed by ID only, the full key is "score:<ID>".
+	dbScorePrefix = "score:"
 )
 
 const (
-	dbNodeExpiration = 24 * time.Hour // Time after which an unseen node should be dropped.
-	dbCleanupCycle   = time.Hour      // Time period for running the expiration task.
-	dbVersion        = 9
+	dbNodeExpiration  = 24 * time.Hour     // Time after which an unseen node should be dropped.
+	dbScoreExpiration = 7 * 24 * time.Hour // Time after which unchanged scores should be dropped.
+	dbCleanupCycle    = time.Hour          // Time period for running the expiration task.
+	dbVersion         = 9
 )
 
 var (
@@ -315,6 +320,7 @@ func (db *DB) expirer() {
 		select {
 		case <-tick.C:
 			db.expireNodes()
+			db.expireScores()
 		case <-db.quit:
 			return
 		}
@@ -427,6 +433,69 @@ func (db *DB) UpdateFindFailsV5(id ID, ip netip.Addr, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// nodeScores is the RLP encoding of the reputation scores of a node.
+type nodeScores struct {
+	Scores  []uint64 // IEEE 754 bits of the scores
+	Updated uint64   // Unix time of the last update
+}
+
+// NodeScores retrieves the reputation scores of a node, along with the time of
+// their last update.
+func (db *DB) NodeScores(id ID) ([]float64, time.Time) {
+	blob, err := db.lvl.Get(append([]byte(dbScorePrefix), id[:]...), nil)
+	if err != nil {
+		return nil, time.Time{}
+	}
+	var stored nodeScores
+	if err := rlp.DecodeBytes(blob, &stored); err != nil {
+		return nil, time.Time{}
+	}
+	scores := make([]float64, len(stored.Scores))
+	for i, bits := range stored.Scores {
+		scores[i] = math.Float64frombits(bits)
+	}
+	return scores, time.Unix(int64(stored.Updated), 0)
+}
+
+// UpdateNodeScores stores the reputation scores of a node.
+func (db *DB) UpdateNodeScores(id ID, scores []float64, updated time.Time) error {
+	stored := nodeScores{Scores: make([]uint64, len(scores)), Updated: uint64(updated.Unix())}
+	for i, score := range scores {
+		stored.Scores[i] = math.Float64bits(score)
+	}
+	blob, err := rlp.EncodeToBytes(&stored)
+	if err != nil {
+		return err
+	}
+	return db.lvl.Put(append([]byte(dbScorePrefix), id[:]...), blob, nil)
+}
+
+// ScoredNodes returns the IDs of all nodes with stored reputation scores.
+func (db *DB) ScoredNodes() []ID {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbScorePrefix)), nil)
+	defer it.Release()
+
+	var ids []ID
+	for it.Next() {
+		var id ID
+		if copy(id[:], it.Key()[len(dbScorePrefix):]) == len(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// expireScores deletes the reputation scores which have not been updated for some
+// time, having decayed to insignificance.
+func (db *DB) expireScores() {
+	threshold := time.Now().Add(-dbScoreExpiration)
+	for _, id := range db.ScoredNodes() {
+		if _, updated := db.NodeScores(id); updated.Before(threshold) {
+			db.lvl.Delete(append([]byte(dbScorePrefix), id[:]...), nil)
+		}
+	}
+}
+
 // localSeq retrieves the local record sequence counter, defaulting to the current
 // timestamp if no previous exists. This ensures that wiping all data associated
 // with a node (apart from its key) will not generate already used sequence nums.
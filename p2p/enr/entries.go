@@ -77,6 +77,19 @@ type UDP6 uint16
 
 func (v UDP6) ENRKey() string { return "udp6" }
 
+// RLPxQUIC is the "rlpx-quic" key, which holds the UDP port of RLPx over QUIC.
+// The "quic" key is not used, because it holds the libp2p QUIC port of consensus
+// layer clients, which share the discovery network.
+type RLPxQUIC uint16
+
+func (v RLPxQUIC) ENRKey() string { return "rlpx-quic" }
+
+// RLPxQUIC6 is the "rlpx-quic6" key, which holds the IPv6-specific UDP port of
+// RLPx over QUIC.
+type RLPxQUIC6 uint16
+
+func (v RLPxQUIC6) ENRKey() string { return "rlpx-quic6" }
+
 // ID is the "id" key, which holds the name of the identity scheme.
 type ID string
 
@@ -0,0 +1,172 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package netsim
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Metrics records when nodes see items like blocks or transactions, and how
+// the chain head of each node advances.
+type Metrics struct {
+	clock mclock.Clock
+
+	mu    sync.Mutex
+	items map[common.Hash]*itemRecord
+	heads map[enode.ID][]headRecord
+}
+
+type itemRecord struct {
+	first mclock.AbsTime
+	seen  map[enode.ID]mclock.AbsTime
+}
+
+type headRecord struct {
+	number uint64
+	time   mclock.AbsTime
+}
+
+// Propagation summarizes how quickly an item spread through the network. The
+// delays are measured from the time the first node saw the item.
+type Propagation struct {
+	Nodes int // number of nodes that have seen the item
+	P50   time.Duration
+	P95   time.Duration
+	Max   time.Duration
+}
+
+func (p Propagation) String() string {
+	return fmt.Sprintf("nodes=%d p50=%v p95=%v max=%v", p.Nodes, p.P50, p.P95, p.Max)
+}
+
+// NewMetrics creates a metrics collector which measures time on the given clock.
+func NewMetrics(clock mclock.Clock) *Metrics {
+	return &Metrics{
+		clock: clock,
+		items: make(map[common.Hash]*itemRecord),
+		heads: make(map[enode.ID][]headRecord),
+	}
+}
+
+// Observe records that a node has seen an item. Only the first observation of
+// an item by a node is recorded.
+func (m *Metrics) Observe(id enode.ID, item common.Hash) {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec := m.items[item]
+	if rec == nil {
+		rec = &itemRecord{first: now, seen: make(map[enode.ID]mclock.AbsTime)}
+		m.items[item] = rec
+	}
+	if _, ok := rec.seen[id]; !ok {
+		rec.seen[id] = now
+	}
+}
+
+// ObserveHead records the chain head of a node.
+func (m *Metrics) ObserveHead(id enode.ID, number uint64) {
+	now := m.clock.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.heads[id] = append(m.heads[id], headRecord{number, now})
+}
+
+// Seen returns the number of nodes that have seen an item.
+func (m *Metrics) Seen(item common.Hash) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rec := m.items[item]; rec != nil {
+		return len(rec.seen)
+	}
+	return 0
+}
+
+// Propagation returns the propagation statistics of an item.
+func (m *Metrics) Propagation(item common.Hash) Propagation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec := m.items[item]
+	if rec == nil {
+		return Propagation{}
+	}
+	times := make([]mclock.AbsTime, 0, len(rec.seen))
+	for _, t := range rec.seen {
+		times = append(times, t)
+	}
+	return newPropagation(rec.first, times)
+}
+
+// Sync returns statistics on when nodes reached the given block number. The
+// delays are measured from the time the first node reached it.
+func (m *Metrics) Sync(number uint64) Propagation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var times []mclock.AbsTime
+	for _, heads := range m.heads {
+		i := slices.IndexFunc(heads, func(h headRecord) bool { return h.number >= number })
+		if i >= 0 {
+			times = append(times, heads[i].time)
+		}
+	}
+	if len(times) == 0 {
+		return Propagation{}
+	}
+	return newPropagation(slices.Min(times), times)
+}
+
+// Head returns the last recorded chain head of a node.
+func (m *Metrics) Head(id enode.ID) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if heads := m.heads[id]; len(heads) > 0 {
+		return heads[len(heads)-1].number
+	}
+	return 0
+}
+
+func newPropagation(start mclock.AbsTime, times []mclock.AbsTime) Propagation {
+	delays := make([]time.Duration, len(times))
+	for i, t := range times {
+		delays[i] = t.Sub(start)
+	}
+	slices.Sort(delays)
+	percentile := func(p int) time.Duration {
+		return delays[(len(delays)-1)*p/100]
+	}
+	return Propagation{
+		Nodes: len(delays),
+		P50:   percentile(50),
+		P95:   percentile(95),
+		Max:   delays[len(delays)-1],
+	}
+}
@@ -0,0 +1,564 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package netsim runs many in-process p2p servers on a simulated network.
+//
+// Nodes are connected by virtual links with configurable latency and packet loss,
+// and the network can be partitioned. Link timing and the timers of the p2p
+// servers run on the network clock. When the clock is an *mclock.Simulated, time
+// only moves forward in Network.Run, and each step waits until the nodes have
+// read all data delivered in it. Link delays and losses are drawn from the
+// network randomness, which is seeded with Config.Seed.
+//
+// Simulations are not deterministic. Nodes act on received data in their own
+// goroutines, and protocol code may use the system clock, so Run paces the
+// network clock in real time to give nodes time to respond. The timing of
+// protocol messages, and therefore the results of a scenario, can vary between
+// runs with the same seed.
+package netsim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/nat"
+	"github.com/ethereum/go-ethereum/p2p/pipes"
+)
+
+const (
+	defaultTick              = time.Millisecond
+	defaultSettleTimeout     = time.Second
+	defaultRetransmitTimeout = 200 * time.Millisecond
+
+	// simPort is the TCP port of all nodes.
+	simPort = 30303
+)
+
+var (
+	errUnknownNode   = errors.New("unknown node")
+	errUnreachable   = errors.New("node unreachable")
+	errNodeClosed    = errors.New("node closed")
+	errNotAttached   = errors.New("node has no server")
+	errNetworkClosed = errors.New("network closed")
+)
+
+// Config is the configuration of a simulated network.
+type Config struct {
+	Clock mclock.Clock // network clock, defaults to a new *mclock.Simulated
+	Seed  int64        // seed of the network randomness
+	Link  Link         // properties of links between nodes
+
+	// Settings for simulated clocks. Speed is the ratio of virtual time to real
+	// time in Run, and defaults to one. Higher values run the simulation faster,
+	// but leave less time for nodes to process messages.
+	Speed         float64
+	Tick          time.Duration // step size of Run
+	SettleTimeout time.Duration // max real time to wait for nodes to read delivered data
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Clock == nil {
+		cfg.Clock = new(mclock.Simulated)
+	}
+	if cfg.Speed == 0 {
+		cfg.Speed = 1
+	}
+	if cfg.Tick == 0 {
+		cfg.Tick = defaultTick
+	}
+	if cfg.SettleTimeout == 0 {
+		cfg.SettleTimeout = defaultSettleTimeout
+	}
+	return cfg
+}
+
+// Link describes the properties of the connections between two nodes.
+type Link struct {
+	Latency time.Duration // one-way delay
+	Jitter  time.Duration // max random delay added to latency
+
+	// Loss is the probability that a write is lost, in the range [0, 1).
+	// Lost writes are delivered after the retransmit timeout.
+	Loss              float64
+	RetransmitTimeout time.Duration
+}
+
+// Traffic contains the totals of data sent on the network.
+type Traffic struct {
+	Conns    int // connections established
+	Segments int // writes
+	Bytes    int
+	Lost     int // lost writes
+	Stalls   int // steps of Run in which nodes didn't read delivered data in time
+}
+
+// Network is a simulated network.
+type Network struct {
+	cfg     Config
+	clock   mclock.Clock
+	metrics *Metrics
+
+	read chan struct{} // signaled when data is read or a connection closes
+	quit chan struct{}
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	nodes   []*Node
+	byID    map[enode.ID]*Node
+	links   map[[2]enode.ID]Link
+	groups  map[enode.ID]int // partition groups
+	conns   map[*conn]struct{}
+	traffic Traffic
+	closed  bool
+}
+
+// New creates a network.
+func New(cfg Config) *Network {
+	cfg = cfg.withDefaults()
+	return &Network{
+		cfg:     cfg,
+		clock:   cfg.Clock,
+		metrics: NewMetrics(cfg.Clock),
+		read:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		rand:    rand.New(rand.NewSource(cfg.Seed)),
+		byID:    make(map[enode.ID]*Node),
+		links:   make(map[[2]enode.ID]Link),
+		groups:  make(map[enode.ID]int),
+		conns:   make(map[*conn]struct{}),
+	}
+}
+
+// Clock returns the network clock.
+func (n *Network) Clock() mclock.Clock {
+	return n.clock
+}
+
+// Metrics returns the metrics collector of the network.
+func (n *Network) Metrics() *Metrics {
+	return n.metrics
+}
+
+// Nodes returns all nodes in the order they were created.
+func (n *Network) Nodes() []*Node {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]*Node(nil), n.nodes...)
+}
+
+// Traffic returns the traffic totals.
+func (n *Network) Traffic() Traffic {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.traffic
+}
+
+// NewNode adds a node to the network and configures cfg to use it, including
+// the network clock. A private key is generated if cfg has none. The server created from cfg must be
+// attached to the node with Node.Attach after it has started.
+func (n *Network) NewNode(cfg *p2p.Config) *Node {
+	if cfg.PrivateKey == nil {
+		cfg.PrivateKey, _ = crypto.GenerateKey()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	index := len(n.nodes) + 1
+	node := &Node{
+		network: n,
+		id:      enode.PubkeyToIDV4(&cfg.PrivateKey.PublicKey),
+		addr:    &net.TCPAddr{IP: net.IP{10, byte(index >> 16), byte(index >> 8), byte(index)}, Port: simPort},
+		accept:  make(chan net.Conn),
+		closed:  make(chan struct{}),
+	}
+	n.nodes = append(n.nodes, node)
+	n.byID[node.id] = node
+
+	cfg.Clock = n.clock
+	cfg.Dialer = node
+	cfg.Listener = node
+	cfg.NAT = nat.ExtIP(node.addr.IP)
+	cfg.NoDiscovery = true
+	return node
+}
+
+// NewServer creates a node running a p2p server with the given configuration.
+func (n *Network) NewServer(cfg p2p.Config) (*Node, error) {
+	node := n.NewNode(&cfg)
+	if cfg.Logger == nil {
+		cfg.Logger = log.Root().With("node", node.id.TerminalString())
+	}
+	srv := &p2p.Server{Config: cfg}
+	if err := srv.Start(); err != nil {
+		return nil, err
+	}
+	node.Attach(srv)
+	return node, nil
+}
+
+// SetLink sets the properties of the links between nodes a and b, overriding
+// the default link configuration.
+func (n *Network) SetLink(a, b enode.ID, link Link) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.links[linkKey(a, b)] = link
+}
+
+func linkKey(a, b enode.ID) [2]enode.ID {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return [2]enode.ID{a, b}
+}
+
+// Partition splits the network into the given groups. Nodes in different groups
+// can't reach each other, and their connections are closed. Nodes which are not
+// contained in any group form another group.
+func (n *Network) Partition(groups ...[]enode.ID) {
+	n.mu.Lock()
+	clear(n.groups)
+	for i, group := range groups {
+		for _, id := range group {
+			n.groups[id] = i + 1
+		}
+	}
+	var broken []*conn
+	for c := range n.conns {
+		if !n.reachable(c.from, c.to) {
+			broken = append(broken, c)
+		}
+	}
+	n.mu.Unlock()
+
+	for _, c := range broken {
+		c.Close()
+		c.peer.Close()
+	}
+}
+
+// Heal removes all partitions.
+func (n *Network) Heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	clear(n.groups)
+}
+
+func (n *Network) reachable(a, b enode.ID) bool {
+	return n.groups[a] == n.groups[b]
+}
+
+// Run advances the network clock by d. With a simulated clock, time moves
+// forward in steps of the configured tick, and the network waits for nodes to
+// read the data delivered in each step. Steps are also paced in real time, to
+// give the nodes time to act on the data. With any other clock, Run sleeps.
+func (n *Network) Run(d time.Duration) {
+	sim, ok := n.clock.(*mclock.Simulated)
+	if !ok {
+		n.clock.Sleep(d)
+		return
+	}
+	for d > 0 {
+		var (
+			step  = min(d, n.cfg.Tick)
+			start = time.Now()
+		)
+		sim.Run(step)
+		n.settle()
+		if wait := time.Duration(float64(step)/n.cfg.Speed) - time.Since(start); wait > 0 {
+			time.Sleep(wait)
+		}
+		d -= step
+	}
+}
+
+// settle waits until all delivered data has been read, or the network is closed.
+// If nodes stop reading, it gives up after the settle timeout and reports the
+// connections holding unread data.
+func (n *Network) settle() {
+	timeout := time.NewTimer(n.cfg.SettleTimeout)
+	defer timeout.Stop()
+
+	for n.buffered() > 0 {
+		select {
+		case <-n.read:
+		case <-n.quit:
+			return
+		case <-timeout.C:
+			n.reportStuck()
+			return
+		}
+	}
+}
+
+// reportStuck logs the connections with unread data.
+func (n *Network) reportStuck() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.traffic.Stalls++
+	for c := range n.conns {
+		if size := c.Buffered(); size > 0 {
+			log.Warn("Simulated node not reading delivered data", "node", c.from, "peer", c.to, "buffered", size, "timeout", n.cfg.SettleTimeout)
+		}
+	}
+}
+
+// signalRead wakes up settle.
+func (n *Network) signalRead() {
+	select {
+	case n.read <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Network) buffered() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var total int
+	for c := range n.conns {
+		total += c.Buffered()
+	}
+	return total
+}
+
+// Close shuts down all nodes and closes all connections.
+func (n *Network) Close() {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.quit)
+	}
+	nodes := n.nodes
+	conns := make([]*conn, 0, len(n.conns))
+	for c := range n.conns {
+		conns = append(conns, c)
+	}
+	n.mu.Unlock()
+
+	// Close connections first to abort handshakes which wait for the clock.
+	for _, c := range conns {
+		c.Close()
+	}
+	for _, node := range nodes {
+		node.Close()
+	}
+	for _, node := range nodes {
+		if srv := node.Server(); srv != nil {
+			srv.Stop()
+		}
+	}
+}
+
+// dial creates a connection between two nodes.
+func (n *Network) dial(from *Node, to enode.ID) (*conn, *conn, *Node, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch {
+	case n.closed:
+		return nil, nil, nil, errNetworkClosed
+	case n.byID[to] == nil:
+		return nil, nil, nil, errUnknownNode
+	case from.isClosed() || n.byID[to].isClosed():
+		return nil, nil, nil, errNodeClosed
+	case !n.reachable(from.id, to):
+		return nil, nil, nil, errUnreachable
+	}
+	dest := n.byID[to]
+	link, ok := n.links[linkKey(from.id, to)]
+	if !ok {
+		link = n.cfg.Link
+	}
+	p1, p2 := pipes.VirtualPipe(n.clock, from.addr, dest.addr, n.delayFunc(link))
+	c1 := &conn{VirtualConn: p1, network: n, from: from.id, to: to}
+	c2 := &conn{VirtualConn: p2, network: n, from: to, to: from.id, peer: c1}
+	c1.peer = c2
+	n.conns[c1] = struct{}{}
+	n.conns[c2] = struct{}{}
+	n.traffic.Conns++
+	return c1, c2, dest, nil
+}
+
+// delayFunc returns the transmission delay function of a link.
+func (n *Network) delayFunc(link Link) func(int) time.Duration {
+	rto := link.RetransmitTimeout
+	if rto == 0 {
+		rto = defaultRetransmitTimeout
+	}
+	return func(size int) time.Duration {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+
+		n.traffic.Segments++
+		n.traffic.Bytes += size
+		delay := link.Latency
+		if link.Jitter > 0 {
+			delay += time.Duration(n.rand.Int63n(int64(link.Jitter)))
+		}
+		for link.Loss > 0 && n.rand.Float64() < link.Loss {
+			n.traffic.Lost++
+			delay += rto
+		}
+		return delay
+	}
+}
+
+// conn is a connection between two nodes.
+type conn struct {
+	*pipes.VirtualConn
+	network  *Network
+	from, to enode.ID
+	peer     *conn
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	n, err := c.VirtualConn.Read(b)
+	c.network.signalRead()
+	return n, err
+}
+
+func (c *conn) Close() error {
+	c.network.mu.Lock()
+	delete(c.network.conns, c)
+	c.network.mu.Unlock()
+	err := c.VirtualConn.Close()
+	c.network.signalRead()
+	return err
+}
+
+// Node is a node of the simulated network. It implements the dialer and
+// listener used by the node's p2p server.
+type Node struct {
+	network *Network
+	id      enode.ID
+	addr    *net.TCPAddr
+	accept  chan net.Conn
+
+	mu        sync.Mutex
+	srv       *p2p.Server
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// ID returns the node ID.
+func (n *Node) ID() enode.ID {
+	return n.id
+}
+
+// Attach sets the running server of the node.
+func (n *Node) Attach(srv *p2p.Server) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.srv = srv
+}
+
+// Server returns the server attached to the node.
+func (n *Node) Server() *p2p.Server {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.srv
+}
+
+// Connect makes the node connect to another node, and keep the connection.
+func (n *Node) Connect(to *Node) error {
+	srv, dest := n.Server(), to.Server()
+	if srv == nil || dest == nil {
+		return errNotAttached
+	}
+	srv.AddPeer(dest.Self())
+	return nil
+}
+
+// Dial connects to a node. This implements p2p.NodeDialer.
+func (n *Node) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	local, remote, target, err := n.network.dial(n, dest.ID())
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case target.accept <- remote:
+		return local, nil
+	case <-target.closed:
+		err = errNodeClosed
+	case <-n.closed:
+		err = errNodeClosed
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	local.Close()
+	remote.Close()
+	return nil, err
+}
+
+// Disconnect closes all connections of the node. The node's server sees the
+// connections drop, like on a network failure.
+func (n *Node) Disconnect() {
+	n.network.mu.Lock()
+	var conns []*conn
+	for c := range n.network.conns {
+		if c.from == n.id {
+			conns = append(conns, c)
+		}
+	}
+	n.network.mu.Unlock()
+
+	for _, c := range conns {
+		c.Close()
+		c.peer.Close()
+	}
+}
+
+// Accept waits for inbound connections. This implements net.Listener.
+func (n *Node) Accept() (net.Conn, error) {
+	select {
+	case c := <-n.accept:
+		return c, nil
+	case <-n.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting connections. This implements net.Listener.
+func (n *Node) Close() error {
+	n.closeOnce.Do(func() { close(n.closed) })
+	return nil
+}
+
+func (n *Node) isClosed() bool {
+	select {
+	case <-n.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Addr returns the node address. This implements net.Listener.
+func (n *Node) Addr() net.Addr {
+	return n.addr
+}
@@ -0,0 +1,180 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package netsim
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+)
+
+// recvProtocol records received items in the network metrics. The message
+// writers of connected peers are sent on the peers channel.
+func recvProtocol(node **Node, metrics *Metrics, peers chan<- p2p.MsgWriter) p2p.Protocol {
+	return p2p.Protocol{
+		Name:    "recv",
+		Version: 1,
+		Length:  1,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			peers <- rw
+			for {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				var item common.Hash
+				if err := msg.Decode(&item); err != nil {
+					return err
+				}
+				metrics.Observe((*node).ID(), item)
+			}
+		},
+	}
+}
+
+// newTestNetwork creates a network of nodes running recvProtocol. It returns
+// the peers channels of the nodes.
+func newTestNetwork(t *testing.T, size int, link Link) (*Network, []chan p2p.MsgWriter) {
+	network := New(Config{Seed: 1, Link: link})
+	t.Cleanup(network.Close)
+
+	var peers []chan p2p.MsgWriter
+	for i := 0; i < size; i++ {
+		var node *Node
+		ch := make(chan p2p.MsgWriter, size)
+		cfg := p2p.Config{
+			MaxPeers:  size,
+			Protocols: []p2p.Protocol{recvProtocol(&node, network.Metrics(), ch)},
+			Logger:    testlog.Logger(t, log.LevelError),
+		}
+		var err error
+		if node, err = network.NewServer(cfg); err != nil {
+			t.Fatal(err)
+		}
+		peers = append(peers, ch)
+	}
+	return network, peers
+}
+
+func waitPeers(t *testing.T, network *Network, peers int) {
+	t.Helper()
+	ok := network.WaitFor(10*time.Second, 10*time.Millisecond, func() bool {
+		for _, node := range network.Nodes() {
+			if node.Server().PeerCount() != peers {
+				return false
+			}
+		}
+		return true
+	})
+	if !ok {
+		t.Fatal("nodes did not connect")
+	}
+}
+
+// Tests that nodes connect and exchange messages with the configured latency.
+func TestNetworkLatency(t *testing.T) {
+	network, peers := newTestNetwork(t, 2, Link{Latency: 50 * time.Millisecond})
+	nodes := network.Nodes()
+	nodes[0].Connect(nodes[1])
+	waitPeers(t, network, 1)
+
+	// Send an item from node 0 and wait for node 1 to receive it.
+	item := common.Hash{1}
+	network.Metrics().Observe(nodes[0].ID(), item)
+	sent := network.Clock().Now()
+	if err := p2p.Send(<-peers[0], 0, item); err != nil {
+		t.Fatal(err)
+	}
+	if !network.WaitFor(time.Second, time.Millisecond, func() bool { return network.Metrics().Seen(item) == 2 }) {
+		t.Fatal("item not delivered")
+	}
+	if d := network.Clock().Now().Sub(sent); d < 50*time.Millisecond || d > 60*time.Millisecond {
+		t.Errorf("wrong delivery time %v", d)
+	}
+	if p := network.Metrics().Propagation(item); p.Nodes != 2 || p.Max < 50*time.Millisecond {
+		t.Errorf("wrong propagation: %v", p)
+	}
+}
+
+// Tests that partitions disconnect nodes and prevent new connections.
+func TestNetworkPartition(t *testing.T) {
+	network, _ := newTestNetwork(t, 3, Link{Latency: 10 * time.Millisecond})
+	if err := ConnectRing()(network); err != nil {
+		t.Fatal(err)
+	}
+	waitPeers(t, network, 2)
+
+	nodes := network.Nodes()
+	network.Partition(nil, []enode.ID{nodes[2].ID()})
+	ok := network.WaitFor(10*time.Second, 10*time.Millisecond, func() bool {
+		return nodes[0].Server().PeerCount() == 1 && nodes[2].Server().PeerCount() == 0
+	})
+	if !ok {
+		t.Fatal("partitioned nodes still connected")
+	}
+	if _, err := nodes[2].Dial(context.Background(), nodes[0].Server().Self()); err != errUnreachable {
+		t.Fatalf("wrong dial error across partition: %v", err)
+	}
+	network.Heal()
+	c, err := nodes[2].Dial(context.Background(), nodes[0].Server().Self())
+	if err != nil {
+		t.Fatalf("dial failed after heal: %v", err)
+	}
+	c.Close()
+}
+
+// Tests that the network clock advances even if a node doesn't read the data
+// delivered to it.
+func TestNetworkSettleTimeout(t *testing.T) {
+	network := New(Config{Seed: 1, Link: Link{Latency: 10 * time.Millisecond}, SettleTimeout: 50 * time.Millisecond})
+	defer network.Close()
+
+	var (
+		a, b     = network.NewNode(new(p2p.Config)), network.NewNode(new(p2p.Config))
+		accepted = make(chan net.Conn, 1)
+	)
+	go func() {
+		c, _ := b.Accept()
+		accepted <- c
+	}()
+	c, err := a.Dial(context.Background(), enode.SignNull(new(enr.Record), b.ID()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	defer (<-accepted).Close()
+
+	if _, err := c.Write([]byte("unread")); err != nil {
+		t.Fatal(err)
+	}
+	start := network.Clock().Now()
+	network.Run(20 * time.Millisecond)
+	if d := network.Clock().Now().Sub(start); d != 20*time.Millisecond {
+		t.Errorf("wrong clock advance: have %v, want %v", d, 20*time.Millisecond)
+	}
+	if stalls := network.Traffic().Stalls; stalls == 0 {
+		t.Error("stall not reported")
+	}
+}
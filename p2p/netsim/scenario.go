@@ -0,0 +1,160 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package netsim
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Scenario is a script of actions on a network.
+type Scenario struct {
+	Name  string
+	Steps []Step
+	End   time.Duration // run time after the start, at least the time of the last step
+}
+
+// Step is an action of a scenario.
+type Step struct {
+	At     time.Duration // time after the start of the scenario
+	Name   string
+	Action func(*Network) error
+}
+
+// Run executes the scenario on the network. Steps run in order of their time,
+// and the network clock is advanced between them. Run returns when a step fails
+// or the scenario has ended.
+func (s *Scenario) Run(n *Network) error {
+	steps := slices.Clone(s.Steps)
+	slices.SortStableFunc(steps, func(a, b Step) int {
+		return cmp.Compare(a.At, b.At)
+	})
+
+	var (
+		logger  = log.Root().New("scenario", s.Name)
+		start   = n.clock.Now()
+		elapsed = func() time.Duration { return n.clock.Now().Sub(start) }
+	)
+	for _, step := range steps {
+		if d := step.At - elapsed(); d > 0 {
+			n.Run(d)
+		}
+		logger.Debug("Running scenario step", "step", step.Name, "time", elapsed())
+		if err := step.Action(n); err != nil {
+			return fmt.Errorf("step %q: %w", step.Name, err)
+		}
+	}
+	if d := s.End - elapsed(); d > 0 {
+		n.Run(d)
+	}
+	return nil
+}
+
+// WaitFor advances the network clock until cond returns true, checking it
+// every interval. It returns false if cond doesn't become true within timeout.
+func (n *Network) WaitFor(timeout, interval time.Duration, cond func() bool) bool {
+	for waited := time.Duration(0); ; waited += interval {
+		if cond() {
+			return true
+		}
+		if waited >= timeout {
+			return false
+		}
+		n.Run(interval)
+	}
+}
+
+// ConnectRing returns an action which connects every node to the next one,
+// and the last node to the first.
+func ConnectRing() func(*Network) error {
+	return func(n *Network) error {
+		nodes := n.Nodes()
+		if len(nodes) < 2 {
+			return nil
+		}
+		for i, node := range nodes {
+			if err := node.Connect(nodes[(i+1)%len(nodes)]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ConnectRandom returns an action which connects every node to degree random
+// other nodes. Every pair of nodes is connected at most once, so nodes may end
+// up with more peers. Peers are chosen using the network randomness.
+func ConnectRandom(degree int) func(*Network) error {
+	return func(n *Network) error {
+		var (
+			nodes     = n.Nodes()
+			connected = make(map[[2]enode.ID]bool)
+		)
+		for _, node := range nodes {
+			n.mu.Lock()
+			perm := n.rand.Perm(len(nodes))
+			n.mu.Unlock()
+
+			count := 0
+			for _, i := range perm {
+				if count >= degree {
+					break
+				}
+				if nodes[i] == node {
+					continue
+				}
+				count++
+				key := linkKey(node.ID(), nodes[i].ID())
+				if connected[key] {
+					continue
+				}
+				connected[key] = true
+				if err := node.Connect(nodes[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// PartitionNodes returns an action which partitions the network into groups.
+func PartitionNodes(groups ...[]*Node) func(*Network) error {
+	return func(n *Network) error {
+		ids := make([][]enode.ID, len(groups))
+		for i, group := range groups {
+			for _, node := range group {
+				ids[i] = append(ids[i], node.ID())
+			}
+		}
+		n.Partition(ids...)
+		return nil
+	}
+}
+
+// HealPartitions returns an action which removes all partitions.
+func HealPartitions() func(*Network) error {
+	return func(n *Network) error {
+		n.Heal()
+		return nil
+	}
+}
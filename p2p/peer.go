@@ -118,6 +118,8 @@ type Peer struct {
 	// events receives message send / receive events if set
 	events   *event.Feed
 	testPipe *MsgPipeRW // for testing
+
+	reputation *reputation // scores of the peer, nil if not tracked
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -505,7 +507,8 @@ type PeerInfo struct {
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
-	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Reputation *PeerReputation        `json:"reputation,omitempty"` // Scores on the quality of service
+	Protocols  map[string]interface{} `json:"protocols"`            // Sub-protocol specific metadata fields
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -531,6 +534,9 @@ func (p *Peer) Info() *PeerInfo {
 	info.Network.Inbound = p.rw.is(inboundConn)
 	info.Network.Trusted = p.rw.is(trustedConn)
 	info.Network.Static = p.rw.is(staticDialedConn)
+	if p.reputation != nil {
+		info.Reputation = p.reputation.info(p.ID())
+	}
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
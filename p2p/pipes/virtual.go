@@ -0,0 +1,249 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pipes
+
+import (
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+// VirtualPipe creates an in process full duplex pipe which transfers data on the
+// given clock. Data written to one end becomes readable on the other end after the
+// duration returned by the delay function. Data is always delivered in order.
+//
+// Writes never block. Deadlines are relative to the current time, and expire when
+// the same duration has passed on the clock.
+func VirtualPipe(clock mclock.Clock, addr1, addr2 net.Addr, delay func(size int) time.Duration) (*VirtualConn, *VirtualConn) {
+	b1, b2 := newVirtualBuffer(clock), newVirtualBuffer(clock)
+	c1 := &VirtualConn{clock: clock, delay: delay, local: addr1, remote: addr2, in: b1, out: b2}
+	c2 := &VirtualConn{clock: clock, delay: delay, local: addr2, remote: addr1, in: b2, out: b1}
+	return c1, c2
+}
+
+// VirtualConn is one end of a virtual pipe.
+type VirtualConn struct {
+	clock         mclock.Clock
+	delay         func(int) time.Duration
+	local, remote net.Addr
+	in, out       *virtualBuffer
+
+	mu            sync.Mutex
+	closed        bool
+	writeDeadline mclock.AbsTime
+}
+
+// Read reads data delivered by the remote end.
+func (c *VirtualConn) Read(b []byte) (int, error) {
+	return c.in.read(b)
+}
+
+// Write sends data to the remote end.
+func (c *VirtualConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	closed, deadline := c.closed, c.writeDeadline
+	c.mu.Unlock()
+
+	switch {
+	case closed:
+		return 0, io.ErrClosedPipe
+	case deadline != 0 && c.clock.Now() >= deadline:
+		return 0, os.ErrDeadlineExceeded
+	case len(b) == 0:
+		return 0, nil
+	}
+	c.out.push(append([]byte(nil), b...), c.delay(len(b)))
+	return len(b), nil
+}
+
+// Close closes the connection. The remote end reads io.EOF once all data sent
+// before Close has been delivered.
+func (c *VirtualConn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.in.close()
+	c.out.push(nil, c.delay(0))
+	return nil
+}
+
+// Buffered returns the number of bytes delivered to this end which have not
+// been read yet.
+func (c *VirtualConn) Buffered() int {
+	c.in.mu.Lock()
+	defer c.in.mu.Unlock()
+	return len(c.in.data)
+}
+
+func (c *VirtualConn) LocalAddr() net.Addr  { return c.local }
+func (c *VirtualConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *VirtualConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	c.SetWriteDeadline(t)
+	return nil
+}
+
+func (c *VirtualConn) SetReadDeadline(t time.Time) error {
+	c.in.setDeadline(virtualDeadline(c.clock, t))
+	return nil
+}
+
+func (c *VirtualConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDeadline = virtualDeadline(c.clock, t)
+	c.mu.Unlock()
+	return nil
+}
+
+// virtualDeadline converts a deadline to the clock.
+func virtualDeadline(clock mclock.Clock, t time.Time) mclock.AbsTime {
+	if t.IsZero() {
+		return 0
+	}
+	return clock.Now().Add(max(time.Until(t), 0))
+}
+
+// virtualBuffer holds the data received by one end of a virtual pipe.
+type virtualBuffer struct {
+	clock mclock.Clock
+
+	mu       sync.Mutex
+	queue    []virtualSegment // in flight
+	last     mclock.AbsTime   // delivery time of last queued segment
+	data     []byte           // delivered, unread
+	eof      bool             // remote end closed
+	closed   bool             // local end closed
+	deadline mclock.AbsTime
+	notify   chan struct{}
+}
+
+// virtualSegment is data in flight. A nil segment signals the end of the stream.
+type virtualSegment struct {
+	data []byte
+	at   mclock.AbsTime
+}
+
+func newVirtualBuffer(clock mclock.Clock) *virtualBuffer {
+	return &virtualBuffer{clock: clock, notify: make(chan struct{}, 1)}
+}
+
+// push schedules delivery of a segment.
+func (b *virtualBuffer) push(data []byte, delay time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	// Segments must not overtake each other.
+	now := b.clock.Now()
+	at := max(now.Add(delay), b.last)
+	b.last = at
+	b.queue = append(b.queue, virtualSegment{data, at})
+	b.clock.AfterFunc(at.Sub(now), b.deliver)
+}
+
+// deliver moves all segments which have arrived into the read buffer.
+func (b *virtualBuffer) deliver() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	n := 0
+	for ; n < len(b.queue) && b.queue[n].at <= now; n++ {
+		if b.queue[n].data == nil {
+			b.eof = true
+		} else if !b.eof {
+			b.data = append(b.data, b.queue[n].data...)
+		}
+	}
+	if n > 0 {
+		b.queue = b.queue[n:]
+		b.wakeup()
+	}
+}
+
+func (b *virtualBuffer) read(p []byte) (int, error) {
+	for {
+		b.mu.Lock()
+		switch {
+		case b.closed:
+			b.mu.Unlock()
+			return 0, io.ErrClosedPipe
+		case len(b.data) > 0:
+			n := copy(p, b.data)
+			b.data = b.data[n:]
+			b.mu.Unlock()
+			return n, nil
+		case b.eof:
+			b.mu.Unlock()
+			return 0, io.EOF
+		case b.deadline != 0 && b.clock.Now() >= b.deadline:
+			b.mu.Unlock()
+			return 0, os.ErrDeadlineExceeded
+		}
+		var (
+			timer   mclock.ChanTimer
+			timeout <-chan mclock.AbsTime
+		)
+		if b.deadline != 0 {
+			timer = b.clock.NewTimer(b.deadline.Sub(b.clock.Now()))
+			timeout = timer.C()
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-b.notify:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+func (b *virtualBuffer) setDeadline(t mclock.AbsTime) {
+	b.mu.Lock()
+	b.deadline = t
+	b.wakeup()
+	b.mu.Unlock()
+}
+
+func (b *virtualBuffer) close() {
+	b.mu.Lock()
+	b.closed = true
+	b.queue, b.data = nil, nil
+	b.wakeup()
+	b.mu.Unlock()
+}
+
+func (b *virtualBuffer) wakeup() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,514 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"golang.org/x/net/quic"
+)
+
+// RLPx connections can be carried over QUIC instead of TCP. This is experimental.
+// Nodes announce the UDP port of QUIC in the "rlpx-quic" entry of their record.
+//
+// A QUIC connection carries one RLPx session per stream. The dialer opens the control
+// stream, which runs the encryption and protocol handshakes and carries the messages
+// of the base protocol. Once the handshakes are done, the dialer opens a stream for
+// each shared subprotocol, running another encryption handshake with the same node
+// key on each. Subprotocols exchange their messages on their own stream, so packet
+// loss on one stream doesn't hold back the messages of the others.
+//
+// Every stream starts with a byte holding its index: zero for the control stream, and
+// the position of the subprotocol in the message code space for the others.
+//
+// Nodes use self-signed certificates for the TLS handshake of QUIC, which doesn't
+// authenticate them. The RLPx handshakes authenticate the remote node and encrypt the
+// streams just like on TCP.
+const (
+	quicALPN        = "devp2p"
+	quicDialTimeout = 5 * time.Second  // Timeout of QUIC dials, before falling back to TCP
+	quicIdleTimeout = 30 * time.Second // Time after which silent connections are closed
+	quicKeepAlive   = 10 * time.Second // Interval of keep-alive packets on idle connections
+)
+
+var errQUICStream = errors.New("invalid QUIC stream index")
+
+// newQUICConfig creates the QUIC configuration of the server, for both accepted and
+// dialed connections, with a fresh self-signed certificate.
+func newQUICConfig() (*quic.Config, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	if err != nil {
+		return nil, err
+	}
+	return &quic.Config{
+		TLSConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS13,
+			Certificates:       []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: priv}},
+			NextProtos:         []string{quicALPN},
+			InsecureSkipVerify: true, // Nodes are authenticated by the RLPx handshake
+		},
+		MaxIdleTimeout:  quicIdleTimeout,
+		KeepAlivePeriod: quicKeepAlive,
+	}, nil
+}
+
+// setupQUIC starts listening for QUIC connections.
+func (srv *Server) setupQUIC() error {
+	config, err := newQUICConfig()
+	if err != nil {
+		return err
+	}
+	endpoint, err := quic.Listen("udp", srv.QUICAddr, config)
+	if err != nil {
+		return err
+	}
+	srv.quic, srv.quicConfig = endpoint, config
+
+	laddr := endpoint.LocalAddr()
+	srv.QUICAddr = laddr.String()
+	srv.localnode.Set(enr.RLPxQUIC(laddr.Port()))
+	if ip := laddr.Addr(); !ip.IsLoopback() && !ip.IsPrivate() {
+		srv.portMappingRegister <- &portMapping{
+			protocol: "UDP",
+			name:     "ethereum p2p quic",
+			port:     int(laddr.Port()),
+		}
+	}
+
+	srv.loopWG.Add(1)
+	go srv.quicListenLoop()
+	return nil
+}
+
+// quicListenLoop runs in its own goroutine and accepts inbound QUIC connections.
+func (srv *Server) quicListenLoop() {
+	defer srv.loopWG.Done()
+	srv.log.Debug("QUIC listener up", "addr", srv.quic.LocalAddr())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-srv.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The slots channel limits the connections being set up. Wait for the slots to
+	// be returned on exit, so that all setup goroutines are done.
+	tokens := defaultMaxPendingPeers
+	if srv.MaxPendingPeers > 0 {
+		tokens = srv.MaxPendingPeers
+	}
+	slots := make(chan struct{}, tokens)
+	defer func() {
+		for i := 0; i < cap(slots); i++ {
+			slots <- struct{}{}
+		}
+	}()
+
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		conn, err := srv.quic.Accept(ctx)
+		if err != nil {
+			srv.log.Debug("QUIC accept error", "err", err)
+			<-slots
+			return
+		}
+		go func() {
+			srv.setupQUICConn(conn)
+			<-slots
+		}()
+	}
+}
+
+// setupQUICConn runs the handshakes of an inbound QUIC connection.
+func (srv *Server) setupQUICConn(conn *quic.Conn) {
+	remote := conn.RemoteAddr()
+	if err := srv.checkInboundConn(remote.Addr()); err != nil {
+		srv.log.Debug("Rejected inbound QUIC connection", "conn", conn, "err", err)
+		conn.Abort(nil)
+		return
+	}
+	fd, err := acceptQUICStream(conn, srv.quic.LocalAddr(), remote)
+	if err != nil {
+		srv.log.Trace("Failed to accept QUIC control stream", "addr", remote, "err", err)
+		conn.Abort(nil)
+		return
+	}
+	serveMeter.Mark(1)
+	srv.log.Trace("Accepted QUIC connection", "addr", remote)
+	srv.SetupConn(newMeteredConn(fd), inboundConn, nil)
+}
+
+// dialQUIC connects to a node over QUIC, returning its control stream.
+func dialQUIC(ctx context.Context, endpoint *quic.Endpoint, config *quic.Config, addr netip.AddrPort) (*quicStreamConn, error) {
+	conn, err := endpoint.Dial(ctx, "udp", addr.String(), config)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := conn.NewStream(ctx)
+	if err != nil {
+		conn.Abort(nil)
+		return nil, err
+	}
+	// The index is sent along with the first handshake message.
+	stream.WriteByte(0)
+	return newQUICStreamConn(conn, stream, net.UDPAddrFromAddrPort(endpoint.LocalAddr()), net.UDPAddrFromAddrPort(addr), true), nil
+}
+
+// acceptQUICStream waits for the control stream of an inbound QUIC connection.
+func acceptQUICStream(conn *quic.Conn, local, remote netip.AddrPort) (*quicStreamConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream.SetReadContext(ctx)
+	index, err := stream.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if index != 0 {
+		return nil, errQUICStream
+	}
+	return newQUICStreamConn(conn, stream, net.UDPAddrFromAddrPort(local), net.UDPAddrFromAddrPort(remote), true), nil
+}
+
+// quicStreamConn is a net.Conn on a stream of a QUIC connection. Deadlines are
+// implemented with the contexts of the stream.
+type quicStreamConn struct {
+	*quic.Stream
+	conn          *quic.Conn
+	local, remote net.Addr
+	control       bool // Closing the control stream closes the connection
+
+	mu                      sync.Mutex
+	readDeadline            time.Time
+	writeDeadline           time.Time
+	cancelRead, cancelWrite context.CancelFunc
+}
+
+func newQUICStreamConn(conn *quic.Conn, stream *quic.Stream, local, remote net.Addr, control bool) *quicStreamConn {
+	stream.SetReadContext(context.Background())
+	stream.SetWriteContext(context.Background())
+	return &quicStreamConn{Stream: stream, conn: conn, local: local, remote: remote, control: control}
+}
+
+// Write writes data to the stream and sends it immediately.
+func (c *quicStreamConn) Write(b []byte) (int, error) {
+	n, err := c.Stream.Write(b)
+	if err == nil {
+		c.Stream.Flush()
+	}
+	return n, err
+}
+
+// Close closes the stream. Closing the control stream closes the connection, once
+// the data written was received or the write deadline passed.
+func (c *quicStreamConn) Close() error {
+	if !c.control {
+		c.Stream.CloseRead()
+		c.Stream.CloseWrite()
+		return nil
+	}
+	c.SetWriteDeadline(time.Now().Add(discWriteTimeout))
+	err := c.Stream.Close()
+	c.conn.Abort(nil)
+	return err
+}
+
+func (c *quicStreamConn) LocalAddr() net.Addr  { return c.local }
+func (c *quicStreamConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *quicStreamConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *quicStreamConn) SetReadDeadline(t time.Time) error {
+	c.setDeadline(&c.readDeadline, &c.cancelRead, c.Stream.SetReadContext, t)
+	return nil
+}
+
+func (c *quicStreamConn) SetWriteDeadline(t time.Time) error {
+	c.setDeadline(&c.writeDeadline, &c.cancelWrite, c.Stream.SetWriteContext, t)
+	return nil
+}
+
+// setDeadline replaces a stream context with one expiring at the deadline.
+func (c *quicStreamConn) setDeadline(deadline *time.Time, cancel *context.CancelFunc, set func(context.Context), t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t.Equal(*deadline) {
+		return
+	}
+	var (
+		ctx       = context.Background()
+		newCancel context.CancelFunc
+	)
+	if !t.IsZero() {
+		ctx, newCancel = context.WithDeadline(ctx, t)
+	}
+	set(ctx)
+	if *cancel != nil {
+		(*cancel)()
+	}
+	*deadline, *cancel = t, newCancel
+}
+
+// quicConnOf returns the QUIC stream under a connection, or nil if the connection
+// isn't a QUIC stream.
+func quicConnOf(fd net.Conn) *quicStreamConn {
+	if m, ok := fd.(*meteredConn); ok {
+		fd = m.Conn
+	}
+	c, _ := fd.(*quicStreamConn)
+	return c
+}
+
+// newRLPXTransport creates the transport of a connection: RLPx over the streams of
+// the QUIC connection for QUIC control streams, RLPx on the connection otherwise.
+func (srv *Server) newRLPXTransport(fd net.Conn, dialDest *ecdsa.PublicKey) transport {
+	if c := quicConnOf(fd); c != nil {
+		return newQUICTransport(fd, c, dialDest, srv.Protocols)
+	}
+	return newRLPX(fd, dialDest)
+}
+
+// quicTransport runs RLPx over the streams of a QUIC connection. The embedded
+// transport runs on the control stream.
+type quicTransport struct {
+	*rlpxTransport
+	fd        *quicStreamConn
+	dialDest  *ecdsa.PublicKey
+	protocols []Protocol
+
+	prv    *ecdsa.PrivateKey // Local node key, set by the encryption handshake
+	remote *ecdsa.PublicKey  // Remote node key, set by the encryption handshake
+	snappy bool
+
+	lanes     []quicLane    // Subprotocol streams, ordered by message code offset
+	in        chan quicRead // Messages read from all streams
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// quicLane is the stream of a subprotocol.
+type quicLane struct {
+	offset, length uint64
+	rw             *rlpxTransport
+}
+
+// carries reports whether a message code belongs to the subprotocol of the lane.
+func (l *quicLane) carries(code uint64) bool {
+	return code >= l.offset && code < l.offset+l.length
+}
+
+// quicRead is the result of reading a message from a stream.
+type quicRead struct {
+	msg Msg
+	err error
+}
+
+func newQUICTransport(conn net.Conn, fd *quicStreamConn, dialDest *ecdsa.PublicKey, protocols []Protocol) *quicTransport {
+	return &quicTransport{
+		rlpxTransport: &rlpxTransport{conn: rlpx.NewConn(conn, dialDest)},
+		fd:            fd,
+		dialDest:      dialDest,
+		protocols:     protocols,
+		in:            make(chan quicRead),
+		closed:        make(chan struct{}),
+	}
+}
+
+func (t *quicTransport) doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
+	remote, err := t.rlpxTransport.doEncHandshake(prv)
+	if err != nil {
+		return nil, err
+	}
+	t.prv, t.remote = prv, remote
+	return remote, nil
+}
+
+func (t *quicTransport) doProtoHandshake(our *protoHandshake) (*protoHandshake, error) {
+	their, err := t.rlpxTransport.doProtoHandshake(our)
+	if err != nil {
+		return nil, err
+	}
+	t.snappy = their.Version >= snappyProtocolVersion
+
+	// Set up the streams of the shared subprotocols.
+	for _, proto := range matchProtocols(t.protocols, their.Caps, nil) {
+		t.lanes = append(t.lanes, quicLane{offset: proto.offset, length: proto.Length})
+	}
+	slices.SortFunc(t.lanes, func(a, b quicLane) int {
+		return cmp.Compare(a.offset, b.offset)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	if t.dialDest != nil {
+		err = t.openLanes(ctx)
+	} else {
+		err = t.acceptLanes(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Start reading from all streams.
+	go t.readLoop(t.rlpxTransport, frameReadTimeout, nil)
+	for i := range t.lanes {
+		go t.readLoop(t.lanes[i].rw, 0, &t.lanes[i])
+	}
+	return their, nil
+}
+
+// openLanes opens the streams of the subprotocols.
+func (t *quicTransport) openLanes(ctx context.Context) error {
+	for i := range t.lanes {
+		stream, err := t.fd.conn.NewStream(ctx)
+		if err != nil {
+			return err
+		}
+		// The index is sent along with the first handshake message.
+		stream.WriteByte(byte(i + 1))
+		if err := t.handshakeLane(i, stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acceptLanes accepts the streams of the subprotocols, in any order.
+func (t *quicTransport) acceptLanes(ctx context.Context) error {
+	for range t.lanes {
+		stream, err := t.fd.conn.AcceptStream(ctx)
+		if err != nil {
+			return err
+		}
+		stream.SetReadContext(ctx)
+		index, err := stream.ReadByte()
+		if err != nil {
+			return err
+		}
+		if index == 0 || int(index) > len(t.lanes) || t.lanes[index-1].rw != nil {
+			return errQUICStream
+		}
+		if err := t.handshakeLane(int(index-1), stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handshakeLane runs the encryption handshake on the stream of a subprotocol,
+// checking that the remote node is the one of the control stream.
+func (t *quicTransport) handshakeLane(i int, stream *quic.Stream) error {
+	fd := newQUICStreamConn(t.fd.conn, stream, t.fd.local, t.fd.remote, false)
+	rw := &rlpxTransport{conn: rlpx.NewConn(newMeteredConn(fd), t.dialDest)}
+	remote, err := rw.doEncHandshake(t.prv)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(crypto.FromECDSAPub(remote), crypto.FromECDSAPub(t.remote)) {
+		return DiscUnexpectedIdentity
+	}
+	rw.conn.SetSnappy(t.snappy)
+	t.lanes[i].rw = rw
+	return nil
+}
+
+// readLoop passes the messages read from a stream to ReadMsg, until the first error.
+// Only the control stream has a read timeout, as the base protocol pings regularly.
+// Messages on the stream of a subprotocol which belong to another one are dropped.
+func (t *quicTransport) readLoop(rw *rlpxTransport, timeout time.Duration, lane *quicLane) {
+	for {
+		msg, err := rw.readMsg(timeout)
+		if err == nil && lane != nil && !lane.carries(msg.Code) {
+			msg.Discard()
+			continue
+		}
+		select {
+		case t.in <- quicRead{msg, err}:
+		case <-t.closed:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ReadMsg returns the next message read from any stream.
+func (t *quicTransport) ReadMsg() (Msg, error) {
+	select {
+	case r := <-t.in:
+		return r.msg, r.err
+	case <-t.closed:
+		return Msg{}, net.ErrClosed
+	}
+}
+
+// WriteMsg sends a message on the stream of its subprotocol, or on the control
+// stream for base protocol messages.
+func (t *quicTransport) WriteMsg(msg Msg) error {
+	for _, lane := range t.lanes {
+		if lane.carries(msg.Code) && lane.rw != nil {
+			return lane.rw.WriteMsg(msg)
+		}
+	}
+	return t.rlpxTransport.WriteMsg(msg)
+}
+
+func (t *quicTransport) close(err error) {
+	t.closeOnce.Do(func() { close(t.closed) })
+	t.rlpxTransport.close(err)
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that servers supporting QUIC connect over QUIC, and that subprotocols
+// exchange messages on their streams.
+func TestServerQUIC(t *testing.T) {
+	received := make(chan string, 8)
+	protocol := func(name string) Protocol {
+		return Protocol{
+			Name:    name,
+			Version: 1,
+			Length:  2,
+			Run: func(p *Peer, rw MsgReadWriter) error {
+				if err := SendItems(rw, 1, name); err != nil {
+					return err
+				}
+				for {
+					msg, err := rw.ReadMsg()
+					if err != nil {
+						return err
+					}
+					var content []string
+					if err := msg.Decode(&content); err != nil {
+						return err
+					}
+					received <- fmt.Sprintf("%s:%d:%v", name, msg.Code, content)
+				}
+			},
+		}
+	}
+	newServer := func(name string) *Server {
+		srv := &Server{Config: Config{
+			Name:        name,
+			PrivateKey:  newkey(),
+			MaxPeers:    10,
+			NoDiscovery: true,
+			ListenAddr:  "127.0.0.1:0",
+			QUICAddr:    "127.0.0.1:0",
+			Protocols:   []Protocol{protocol("a"), protocol("b")},
+			Logger:      testlog.Logger(t, log.LvlTrace).With("server", name),
+		}}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("could not start server %s: %v", name, err)
+		}
+		t.Cleanup(srv.Stop)
+		return srv
+	}
+	srv1, srv2 := newServer("1"), newServer("2")
+	if _, ok := srv1.Self().QUICEndpoint(); !ok {
+		t.Fatal("QUIC endpoint not announced")
+	}
+	srv2.AddPeer(srv1.Self())
+
+	var have []string
+	for len(have) < 4 {
+		select {
+		case msg := <-received:
+			have = append(have, msg)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for messages, have %v", have)
+		}
+	}
+	sort.Strings(have)
+	if want := "[a:1:[a] a:1:[a] b:1:[b] b:1:[b]]"; fmt.Sprint(have) != want {
+		t.Errorf("received messages mismatch: have %v, want %v", have, want)
+	}
+	for _, srv := range []*Server{srv1, srv2} {
+		peers := srv.Peers()
+		if len(peers) != 1 {
+			t.Fatalf("server %s has %d peers, want 1", srv.Name, len(peers))
+		}
+		if _, ok := peers[0].RemoteAddr().(*net.UDPAddr); !ok {
+			t.Errorf("server %s: peer connected over %v, want QUIC", srv.Name, peers[0].RemoteAddr())
+		}
+	}
+	// Messages of a subprotocol sent on the stream of another should be dropped.
+	lanes := srv2.Peers()[0].rw.transport.(*quicTransport).lanes
+	send := func(lane quicLane, code uint64, content string) {
+		size, r, _ := rlp.EncodeToReader([]string{content})
+		if err := lane.rw.WriteMsg(Msg{Code: code, Size: uint32(size), Payload: r}); err != nil {
+			t.Fatalf("failed to send %q: %v", content, err)
+		}
+	}
+	// The stream is ordered, so the message following it flushes it out.
+	send(lanes[0], lanes[1].offset, "misrouted")
+	send(lanes[0], lanes[0].offset, "routed")
+
+	have = nil
+	timeout := time.After(5 * time.Second)
+	for len(have) == 0 || have[len(have)-1] != "a:0:[routed]" {
+		select {
+		case msg := <-received:
+			have = append(have, msg)
+		case <-timeout:
+			t.Fatalf("timed out waiting for routed message, have %v", have)
+		}
+	}
+	select {
+	case msg := <-received:
+		have = append(have, msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if want := "[a:0:[routed]]"; fmt.Sprint(have) != want {
+		t.Errorf("received messages mismatch: have %v, want %v", have, want)
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/msgrate"
+)
+
+// Peers are scored on the quality of the service they provide, as reported by the
+// protocols running on them: the latency of their responses, the validity of the
+// data they serve and the usefulness of the transactions they propagate. Scores
+// decay towards zero over time, and are stored in the node database, so they are
+// remembered across connections and restarts.
+//
+// The scores are used to dial the best known nodes first when the server starts,
+// to refuse connections with nodes of bad reputation, and to evict the worst peer
+// when all peer slots are taken.
+const (
+	reputationHalfLife      = 24 * time.Hour // Time taken for scores to decay by half
+	reputationMaxScore      = 100            // Bound of each score component
+	reputationBadScore      = -50            // Nodes scoring below this are not connected to
+	reputationEvictScore    = -5             // Peers scoring below this are evicted when full
+	reputationEvictInterval = time.Minute    // Time between checks for peers to evict
+	reputationDialSeeds     = 30             // Number of best known nodes dialed on startup
+
+	reputationInvalidData     = -20 // Score change for serving invalid data
+	reputationValidData       = 1   // Score change for serving valid data
+	reputationUsefulTx        = 0.1 // Score change per transaction new to the local pool
+	reputationInvalidTx       = -1  // Score change per invalid transaction
+	reputationInvalidTxCap    = -5  // Bound of the score change for the invalid transactions of a delivery
+	reputationLatencyMinDelta = -2  // Score change for a response at three times the target latency or worse
+)
+
+// Score components.
+const (
+	scoreLatency = iota
+	scoreData
+	scoreTransactions
+	numScores
+)
+
+// PeerReputation is the reputation of a peer, as reported by admin_peers.
+type PeerReputation struct {
+	Score        float64 `json:"score"`        // Sum of the components below
+	Latency      float64 `json:"latency"`      // Responsiveness to requests
+	Data         float64 `json:"data"`         // Validity of the data served
+	Transactions float64 `json:"transactions"` // Usefulness of the transactions propagated
+}
+
+// peerScores holds the score components of a node.
+type peerScores struct {
+	scores  [numScores]float64
+	updated time.Time
+}
+
+// decay lowers the scores according to the time passed since the last update.
+func (s *peerScores) decay(now time.Time) {
+	if elapsed := now.Sub(s.updated); elapsed > 0 {
+		factor := math.Pow(0.5, float64(elapsed)/float64(reputationHalfLife))
+		for i := range s.scores {
+			s.scores[i] *= factor
+		}
+	}
+	s.updated = now
+}
+
+// total returns the sum of the score components.
+func (s *peerScores) total() float64 {
+	var total float64
+	for _, score := range s.scores {
+		total += score
+	}
+	return total
+}
+
+// reputation tracks the scores of the connected peers, and persists them in the
+// node database on disconnection.
+type reputation struct {
+	db    *enode.DB
+	rates *msgrate.Trackers // Message rates of the peers, setting the target latency
+	log   log.Logger
+
+	lock  sync.Mutex
+	peers map[enode.ID]*peerScores
+}
+
+func newReputation(db *enode.DB, logger log.Logger) *reputation {
+	return &reputation{
+		db:    db,
+		rates: msgrate.NewTrackers(logger),
+		log:   logger,
+		peers: make(map[enode.ID]*peerScores),
+	}
+}
+
+// load retrieves the decayed scores of a node from the database.
+func (r *reputation) load(id enode.ID, now time.Time) *peerScores {
+	stored, updated := r.db.NodeScores(id)
+	s := &peerScores{updated: updated}
+	copy(s.scores[:], stored)
+	s.decay(now)
+	return s
+}
+
+// connected starts tracking the scores of a newly connected peer.
+func (r *reputation) connected(id enode.ID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.peers[id] = r.load(id, time.Now())
+	r.rates.Track(id.String(), msgrate.NewTracker(r.rates.MeanCapacities(), r.rates.MedianRoundTrip()))
+}
+
+// disconnected stores the scores of a peer which disconnected.
+func (r *reputation) disconnected(id enode.ID) {
+	r.lock.Lock()
+	s := r.peers[id]
+	delete(r.peers, id)
+	r.lock.Unlock()
+
+	r.rates.Untrack(id.String())
+	if s == nil {
+		return
+	}
+	if err := r.db.UpdateNodeScores(id, s.scores[:], s.updated); err != nil {
+		r.log.Warn("Failed to store peer reputation", "id", id, "err", err)
+	}
+}
+
+// add changes a score component of a connected peer.
+func (r *reputation) add(id enode.ID, component int, delta float64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	s := r.peers[id]
+	if s == nil {
+		return
+	}
+	s.decay(time.Now())
+	s.scores[component] = max(-reputationMaxScore, min(reputationMaxScore, s.scores[component]+delta))
+}
+
+// response scores a peer on the latency of a response delivering the given number
+// of items. Responses within the target round trip time of the peers raise the
+// score, slower ones lower it.
+func (r *reputation) response(id enode.ID, kind uint64, elapsed time.Duration, items int) {
+	r.rates.Update(id.String(), kind, elapsed, items)
+	target := r.rates.TargetRoundTrip()
+
+	delta := float64(reputationLatencyMinDelta)
+	if items > 0 {
+		delta = max(delta, 1-float64(elapsed)/float64(target))
+	}
+	r.add(id, scoreLatency, delta)
+}
+
+// score returns the total score of a node.
+func (r *reputation) score(id enode.ID) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if s := r.peers[id]; s != nil {
+		return s.total()
+	}
+	return r.load(id, time.Now()).total()
+}
+
+// bad reports whether the node has a reputation too bad to connect to it.
+func (r *reputation) bad(id enode.ID) bool {
+	return r != nil && r.score(id) < reputationBadScore
+}
+
+// info returns the reputation of a connected peer.
+func (r *reputation) info(id enode.ID) *PeerReputation {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	s := r.peers[id]
+	if s == nil {
+		return nil
+	}
+	s.decay(time.Now())
+	return &PeerReputation{
+		Score:        s.total(),
+		Latency:      s.scores[scoreLatency],
+		Data:         s.scores[scoreData],
+		Transactions: s.scores[scoreTransactions],
+	}
+}
+
+// seeds returns the known nodes of the best reputation, best first.
+func (r *reputation) seeds(n int) []*enode.Node {
+	type scored struct {
+		node  *enode.Node
+		score float64
+	}
+	var (
+		now   = time.Now()
+		nodes []scored
+	)
+	for _, id := range r.db.ScoredNodes() {
+		score := r.load(id, now).total()
+		if score <= 0 {
+			continue
+		}
+		if node := r.db.Node(id); node != nil && node.TCP() != 0 {
+			nodes = append(nodes, scored{node, score})
+		}
+	}
+	slices.SortFunc(nodes, func(a, b scored) int {
+		return cmp.Compare(b.score, a.score)
+	})
+	seeds := make([]*enode.Node, 0, min(n, len(nodes)))
+	for i := 0; i < len(nodes) && i < n; i++ {
+		seeds = append(seeds, nodes[i].node)
+	}
+	return seeds
+}
+
+// evictable returns the worst scoring peer, if it scores low enough to be evicted.
+// Trusted and static peers are never evicted.
+func (r *reputation) evictable(peers map[enode.ID]*Peer) *Peer {
+	var (
+		worst      *Peer
+		worstScore float64 = reputationEvictScore
+	)
+	for id, p := range peers {
+		if p.rw.is(trustedConn) || p.rw.is(staticDialedConn) {
+			continue
+		}
+		if score := r.score(id); score < worstScore {
+			worst, worstScore = p, score
+		}
+	}
+	return worst
+}
+
+// ReportResponse scores the peer on the latency of a response to a request of the
+// given message kind, delivering the given number of items. Zero items denote an
+// empty response.
+func (p *Peer) ReportResponse(kind uint64, elapsed time.Duration, items int) {
+	if p.reputation != nil {
+		p.reputation.response(p.ID(), kind, elapsed, items)
+	}
+}
+
+// ReportData scores the peer on the validity of data it served.
+func (p *Peer) ReportData(valid bool) {
+	if p.reputation == nil {
+		return
+	}
+	if valid {
+		p.reputation.add(p.ID(), scoreData, reputationValidData)
+	} else {
+		p.reputation.add(p.ID(), scoreData, reputationInvalidData)
+	}
+}
+
+// ReportTransactions scores the peer on the transactions it propagated, given the
+// number of transactions new to the local pool and the number of invalid ones.
+// The penalty for the invalid transactions of a single delivery is capped, so a
+// peer is only considered bad after repeatedly propagating invalid ones.
+func (p *Peer) ReportTransactions(useful, invalid int) {
+	if p.reputation != nil {
+		penalty := max(float64(invalid)*reputationInvalidTx, reputationInvalidTxCap)
+		p.reputation.add(p.ID(), scoreTransactions, float64(useful)*reputationUsefulTx+penalty)
+	}
+}
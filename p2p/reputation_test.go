@@ -0,0 +1,155 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// Tests that peer scores are stored on disconnection and decay while the node is
+// not connected.
+func TestReputationPersistence(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		rep  = newReputation(db, log.Root())
+		peer = &Peer{rw: &conn{node: newNode(uintID(1), "127.0.0.1:30303")}, reputation: rep}
+	)
+	rep.connected(peer.ID())
+	peer.ReportData(false)
+	peer.ReportTransactions(10, 0)
+	if info := rep.info(peer.ID()); info == nil || math.Abs(info.Score-(reputationInvalidData+10*reputationUsefulTx)) > 0.01 {
+		t.Fatalf("wrong reputation of connected peer: %+v", info)
+	}
+	rep.disconnected(peer.ID())
+
+	// Age the stored scores by a half life.
+	scores, updated := db.NodeScores(peer.ID())
+	if err := db.UpdateNodeScores(peer.ID(), scores, updated.Add(-reputationHalfLife)); err != nil {
+		t.Fatal(err)
+	}
+	want := (reputationInvalidData + 10*reputationUsefulTx) / 2
+	if score := rep.score(peer.ID()); math.Abs(score-want) > 0.01 {
+		t.Fatalf("wrong decayed score: have %f, want %f", score, want)
+	}
+}
+
+// Tests that the penalty for the invalid transactions of a single delivery is
+// capped, so that one batch can't get a peer refused.
+func TestReputationInvalidTxCap(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		rep  = newReputation(db, log.Root())
+		peer = &Peer{rw: &conn{node: newNode(uintID(1), "127.0.0.1:30303")}, reputation: rep}
+	)
+	rep.connected(peer.ID())
+	peer.ReportTransactions(0, 1000)
+	if info := rep.info(peer.ID()); info == nil || math.Abs(info.Transactions-reputationInvalidTxCap) > 0.01 {
+		t.Fatalf("wrong transaction score after invalid delivery: %+v", info)
+	}
+	if rep.bad(peer.ID()) {
+		t.Fatal("peer refused after a single invalid delivery")
+	}
+}
+
+// Tests that the best scoring known nodes are used as dial seeds, and that nodes
+// of bad reputation are not dialed.
+func TestReputationDial(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	rep := newReputation(db, log.Root())
+	nodes := make([]*enode.Node, 4)
+	for i, score := range []float64{5, 50, -10, -80} {
+		nodes[i] = newNode(uintID(uint16(i+1)), "127.0.0.1:30303")
+		db.UpdateNode(nodes[i])
+		db.UpdateNodeScores(nodes[i].ID(), []float64{score}, time.Now())
+	}
+	seeds := rep.seeds(reputationDialSeeds)
+	if len(seeds) != 2 || seeds[0].ID() != nodes[1].ID() || seeds[1].ID() != nodes[0].ID() {
+		t.Errorf("wrong dial seeds: %v", seeds)
+	}
+	d := &dialScheduler{dialConfig: dialConfig{reputation: rep}, dialing: make(map[enode.ID]*dialTask), peers: make(map[enode.ID]struct{})}
+	for i, want := range []error{nil, nil, nil, errBadReputation} {
+		if err := d.checkDynamicDial(nodes[i]); err != want {
+			t.Errorf("node %d: dial check mismatch: have %v, want %v", i, err, want)
+		}
+	}
+}
+
+// Tests that the worst scoring peer is evicted, unless it is trusted or static.
+func TestReputationEvict(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		rep   = newReputation(db, log.Root())
+		peers = make(map[enode.ID]*Peer)
+	)
+	for i, flags := range []connFlag{dynDialedConn, inboundConn, trustedConn | inboundConn, staticDialedConn} {
+		p := &Peer{rw: &conn{node: newNode(uintID(uint16(i+1)), ""), flags: flags}, reputation: rep}
+		peers[p.ID()] = p
+		rep.connected(p.ID())
+		p.ReportData(true)
+	}
+	if p := rep.evictable(peers); p != nil {
+		t.Fatalf("evicted peer %v of good reputation", p.ID())
+	}
+	peers[uintID(2)].ReportData(false)
+	peers[uintID(3)].ReportData(false)
+	peers[uintID(3)].ReportData(false)
+	if p := rep.evictable(peers); p == nil || p.ID() != uintID(2) {
+		t.Fatalf("wrong peer evicted: %v", p)
+	}
+}
+
+// Tests that connections of nodes with bad reputation are refused after the
+// handshake, unless the node is trusted or static.
+func TestReputationPostHandshake(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	srv := &Server{
+		Config:     Config{MaxPeers: 10},
+		localnode:  enode.NewLocalNode(db, newkey()),
+		reputation: newReputation(db, log.Root()),
+	}
+	for i, tt := range []struct {
+		flags connFlag
+		want  error
+	}{
+		{dynDialedConn, DiscUselessPeer},
+		{inboundConn, DiscUselessPeer},
+		{trustedConn | inboundConn, nil},
+		{staticDialedConn, nil},
+	} {
+		node := newNode(uintID(uint16(i+1)), "127.0.0.1:30303")
+		db.UpdateNodeScores(node.ID(), []float64{-80}, time.Now())
+		c := &conn{node: node, flags: tt.flags}
+		if err := srv.postHandshakeChecks(make(map[enode.ID]*Peer), 0, c); err != tt.want {
+			t.Errorf("conn %v: check mismatch: have %v, want %v", tt.flags, err, tt.want)
+		}
+	}
+}
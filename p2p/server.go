@@ -20,6 +20,7 @@ package p2p
 import (
 	"bytes"
 	"cmp"
+	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
@@ -41,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"golang.org/x/net/quic"
 )
 
 const (
@@ -102,6 +104,10 @@ type Config struct {
 	// protocol should be started or not.
 	DiscoveryV5 bool `toml:",omitempty"`
 
+	// DiscoveryV5Topics is the list of discovery v5 topics the node advertises
+	// itself for. Nodes advertising these topics are also used as dial candidates.
+	DiscoveryV5Topics []string `toml:",omitempty"`
+
 	// Name sets the node name of this server.
 	Name string `toml:"-"`
 
@@ -148,6 +154,12 @@ type Config struct {
 	// for TCP and DiscAddr for the UDP discovery protocol.
 	DiscAddr string
 
+	// If QUICAddr is set to a non-nil value, the server also listens for RLPx
+	// connections over QUIC on this UDP address, and dials over QUIC the nodes
+	// announcing a QUIC endpoint. It must differ from the discovery address.
+	// QUIC support is experimental.
+	QUICAddr string `toml:",omitempty"`
+
 	// If set to a non-nil value, the given NAT port mapper
 	// is used to make the listening port available to the
 	// Internet.
@@ -157,6 +169,10 @@ type Config struct {
 	// is used to dial outbound peer connections.
 	Dialer NodeDialer `toml:"-"`
 
+	// If Listener is set to a non-nil value, inbound peer connections
+	// are accepted on it instead of listening on ListenAddr.
+	Listener net.Listener `toml:"-"`
+
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
@@ -167,6 +183,10 @@ type Config struct {
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
+	// If Clock is set to a non-nil value, the server uses it for
+	// its timers instead of the system clock.
+	Clock mclock.Clock `toml:"-"`
+
 	clock mclock.Clock
 }
 
@@ -185,17 +205,20 @@ type Server struct {
 	running bool
 
 	listener     net.Listener
+	quic         *quic.Endpoint
+	quicConfig   *quic.Config
 	ourHandshake *protoHandshake
 	loopWG       sync.WaitGroup // loop, listenLoop
 	peerFeed     event.Feed
 	log          log.Logger
 
-	nodedb    *enode.DB
-	localnode *enode.LocalNode
-	discv4    *discover.UDPv4
-	discv5    *discover.UDPv5
-	discmix   *enode.FairMix
-	dialsched *dialScheduler
+	nodedb     *enode.DB
+	reputation *reputation
+	localnode  *enode.LocalNode
+	discv4     *discover.UDPv4
+	discv5     *discover.UDPv5
+	discmix    *enode.FairMix
+	dialsched  *dialScheduler
 
 	// This is read by the NAT port mapping loop.
 	portMappingRegister chan *portMapping
@@ -428,6 +451,12 @@ func (srv *Server) Stop() {
 	close(srv.quit)
 	srv.lock.Unlock()
 	srv.loopWG.Wait()
+
+	if srv.quic != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), discWriteTimeout)
+		srv.quic.Close(ctx)
+		cancel()
+	}
 }
 
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
@@ -469,10 +498,13 @@ func (srv *Server) Start() (err error) {
 	if srv.log == nil {
 		srv.log = log.Root()
 	}
+	if srv.clock == nil {
+		srv.clock = srv.Clock
+	}
 	if srv.clock == nil {
 		srv.clock = mclock.System{}
 	}
-	if srv.NoDial && srv.ListenAddr == "" {
+	if srv.NoDial && srv.ListenAddr == "" && srv.Listener == nil {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
 
@@ -481,7 +513,7 @@ func (srv *Server) Start() (err error) {
 		return errors.New("Server.PrivateKey must be set to a non-nil key")
 	}
 	if srv.newTransport == nil {
-		srv.newTransport = newRLPX
+		srv.newTransport = srv.newRLPXTransport
 	}
 	if srv.listenFunc == nil {
 		srv.listenFunc = net.Listen
@@ -500,11 +532,16 @@ func (srv *Server) Start() (err error) {
 	}
 	srv.setupPortMapping()
 
-	if srv.ListenAddr != "" {
+	if srv.ListenAddr != "" || srv.Listener != nil {
 		if err := srv.setupListening(); err != nil {
 			return err
 		}
 	}
+	if srv.QUICAddr != "" {
+		if err := srv.setupQUIC(); err != nil {
+			return err
+		}
+	}
 	if err := srv.setupDiscovery(); err != nil {
 		return err
 	}
@@ -530,6 +567,7 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.reputation = newReputation(db, srv.log)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	// TODO: check conflicts
@@ -544,6 +582,11 @@ func (srv *Server) setupLocalNode() error {
 func (srv *Server) setupDiscovery() error {
 	srv.discmix = enode.NewFairMix(discmixTimeout)
 
+	// Dial the nodes of the best reputation first.
+	if seeds := srv.reputation.seeds(reputationDialSeeds); len(seeds) > 0 {
+		srv.discmix.AddSource(enode.IterNodes(seeds))
+	}
+
 	// Don't listen on UDP endpoint if DHT is disabled.
 	if srv.NoDiscovery {
 		return nil
@@ -591,6 +634,11 @@ func (srv *Server) setupDiscovery() error {
 		if err != nil {
 			return err
 		}
+		for _, name := range srv.Config.DiscoveryV5Topics {
+			topic := discover.NewTopic(name)
+			srv.discv5.RegisterTopic(topic)
+			srv.discmix.AddSource(srv.discv5.TopicNodes(topic))
+		}
 	}
 
 	// Add protocol-specific discovery sources.
@@ -612,6 +660,7 @@ func (srv *Server) setupDialScheduler() {
 		log:            srv.Logger,
 		netRestrict:    srv.NetRestrict,
 		dialer:         srv.Dialer,
+		reputation:     srv.reputation,
 		clock:          srv.clock,
 	}
 	if srv.discv4 != nil {
@@ -620,6 +669,9 @@ func (srv *Server) setupDialScheduler() {
 	if config.dialer == nil {
 		config.dialer = tcpDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
+	if srv.quic != nil {
+		config.dialer = quicDialer{srv.quic, srv.quicConfig, config.dialer}
+	}
 	srv.dialsched = newDialScheduler(config, srv.discmix, srv.SetupConn)
 	for _, n := range srv.StaticNodes {
 		srv.dialsched.addStatic(n)
@@ -647,9 +699,12 @@ func (srv *Server) maxDialedConns() (limit int) {
 
 func (srv *Server) setupListening() error {
 	// Launch the listener.
-	listener, err := srv.listenFunc("tcp", srv.ListenAddr)
-	if err != nil {
-		return err
+	listener := srv.Listener
+	if listener == nil {
+		var err error
+		if listener, err = srv.listenFunc("tcp", srv.ListenAddr); err != nil {
+			return err
+		}
 	}
 	srv.listener = listener
 	srv.ListenAddr = listener.Addr().String()
@@ -723,7 +778,9 @@ func (srv *Server) run() {
 		peers        = make(map[enode.ID]*Peer)
 		inboundCount = 0
 		trusted      = make(map[enode.ID]bool, len(srv.TrustedNodes))
+		evict        = srv.clock.NewTimer(reputationEvictInterval)
 	)
+	defer evict.Stop()
 	// Put trusted nodes into a map to speed up checks.
 	// Trusted peers are loaded on startup or added via AddTrustedPeer RPC.
 	for _, n := range srv.TrustedNodes {
@@ -776,6 +833,7 @@ running:
 			err := srv.addPeerChecks(peers, inboundCount, c)
 			if err == nil {
 				// The handshakes are done and it passed all checks.
+				srv.reputation.connected(c.node.ID())
 				p := srv.launchPeer(c)
 				peers[c.node.ID()] = p
 				srv.log.Debug("Adding p2p peer", "peercount", len(peers), "id", p.ID(), "conn", c.flags, "addr", p.RemoteAddr(), "name", p.Name())
@@ -796,6 +854,7 @@ running:
 			// A peer disconnected.
 			d := common.PrettyDuration(mclock.Now() - pd.created)
 			delete(peers, pd.ID())
+			srv.reputation.disconnected(pd.ID())
 			srv.log.Debug("Removing p2p peer", "peercount", len(peers), "id", pd.ID(), "duration", d, "req", pd.requested, "err", pd.err)
 			srv.dialsched.peerRemoved(pd.rw)
 			if pd.Inbound() {
@@ -805,6 +864,18 @@ running:
 				activeOutboundPeerGauge.Dec(1)
 			}
 			activePeerGauge.Dec(1)
+
+		case <-evict.C():
+			// Make room for new peers if all slots are taken, evicting the peer
+			// of the worst reputation.
+			evict.Reset(reputationEvictInterval)
+			if len(peers) < srv.MaxPeers {
+				continue
+			}
+			if p := srv.reputation.evictable(peers); p != nil {
+				srv.log.Debug("Evicting p2p peer of bad reputation", "id", p.ID(), "score", srv.reputation.score(p.ID()))
+				p.Disconnect(DiscUselessPeer)
+			}
 		}
 	}
 
@@ -828,6 +899,7 @@ running:
 		p := <-srv.delpeer
 		p.log.Trace("<-delpeer (spindown)")
 		delete(peers, p.ID())
+		srv.reputation.disconnected(p.ID())
 	}
 }
 
@@ -841,6 +913,8 @@ func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount in
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case !c.is(trustedConn) && !c.is(staticDialedConn) && srv.reputation.bad(c.node.ID()):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -1025,9 +1099,11 @@ func (srv *Server) setupConn(c *conn, dialDest *enode.Node) error {
 func nodeFromConn(pubkey *ecdsa.PublicKey, conn net.Conn) *enode.Node {
 	var ip net.IP
 	var port int
-	if tcp, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
-		ip = tcp.IP
-		port = tcp.Port
+	switch addr := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		ip, port = addr.IP, addr.Port
+	case *net.UDPAddr:
+		ip, port = addr.IP, addr.Port
 	}
 	return enode.NewV4(pubkey, ip, port, port)
 }
@@ -1045,6 +1121,7 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 
 func (srv *Server) launchPeer(c *conn) *Peer {
 	p := newPeer(srv.log, c, srv.Protocols)
+	p.reputation = srv.reputation
 	if srv.EnableMsgEvents {
 		// If message events are enabled, pass the peerFeed
 		// to the peer.
@@ -1095,8 +1172,9 @@ type NodeInfo struct {
 	ENR   string `json:"enr"`   // Ethereum Node Record
 	IP    string `json:"ip"`    // IP address of the node
 	Ports struct {
-		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
-		Listener  int `json:"listener"`  // TCP listening port for RLPx
+		Discovery int `json:"discovery"`      // UDP listening port for discovery protocol
+		Listener  int `json:"listener"`       // TCP listening port for RLPx
+		QUIC      int `json:"quic,omitempty"` // UDP listening port for RLPx over QUIC
 	} `json:"ports"`
 	ListenAddr string                 `json:"listenAddr"`
 	Protocols  map[string]interface{} `json:"protocols"`
@@ -1116,6 +1194,9 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	}
 	info.Ports.Discovery = node.UDP()
 	info.Ports.Listener = node.TCP()
+	if quic, ok := node.QUICEndpoint(); ok {
+		info.Ports.QUIC = int(quic.Port())
+	}
 	info.ENR = node.String()
 
 	// Gather all the running protocol infos (only once per protocol type)
@@ -57,11 +57,21 @@ func newRLPX(conn net.Conn, dialDest *ecdsa.PublicKey) transport {
 }
 
 func (t *rlpxTransport) ReadMsg() (Msg, error) {
+	return t.readMsg(frameReadTimeout)
+}
+
+// readMsg reads a message, failing if none arrives within the timeout. A zero
+// timeout waits for the next message indefinitely.
+func (t *rlpxTransport) readMsg(timeout time.Duration) (Msg, error) {
 	t.rmu.Lock()
 	defer t.rmu.Unlock()
 
 	var msg Msg
-	t.conn.SetReadDeadline(time.Now().Add(frameReadTimeout))
+	if timeout > 0 {
+		t.conn.SetReadDeadline(time.Now().Add(timeout))
+	} else {
+		t.conn.SetReadDeadline(time.Time{})
+	}
 	code, data, wireSize, err := t.conn.Read()
 	if err == nil {
 		// Protocol messages are dispatched to subprotocol handlers asynchronously,
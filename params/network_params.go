@@ -28,6 +28,13 @@ const (
 	// considered probably final and its rotated bits are calculated.
 	BloomConfirms = 256
 
+	// LogIndexBlocks is the number of blocks a single log index section contains.
+	LogIndexBlocks uint64 = 4096
+
+	// LogIndexConfirms is the number of confirmation blocks before a log index
+	// section is considered probably final and its postings are generated.
+	LogIndexConfirms = 256
+
 	// FullImmutabilityThreshold is the number of blocks after which a chain segment is
 	// considered immutable (i.e. soft finality). It is used by the downloader as a
 	// hard limit against deep ancestors, by the blockchain against deep reorgs, by
@@ -0,0 +1,66 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// CallRecord describes a call served by the server.
+type CallRecord struct {
+	Method   string          // Name of the called method
+	Params   json.RawMessage // Parameters as sent by the client
+	Start    time.Time       // Time the server started handling the call
+	Duration time.Duration   // Time taken to serve the call
+
+	// Err is the error returned to the client, nil if the call succeeded. It
+	// implements Error, and DataError if the error carries data.
+	Err error
+}
+
+// CallAuditor keeps a record of the calls served by a server. Information about
+// the caller is available from the context passed to RecordCall, see
+// PeerInfoFromContext.
+//
+// Audited calls are recorded once served, including calls rejected by the server
+// before reaching the method, e.g. by its policy or rate limits.
+type CallAuditor interface {
+	// Audits reports whether calls of the method should be recorded.
+	Audits(method string) bool
+
+	// RecordCall records a served call. It is invoked synchronously before the
+	// response is sent, and should not block for long.
+	RecordCall(ctx context.Context, call *CallRecord)
+}
+
+type authClaimsContextKey struct{}
+
+// NewContextWithAuthClaims creates a new context carrying the token claims of the
+// authenticated client. HTTP middlewares verifying the identity of a client can
+// use it on the request context to make the claims available in PeerInfo.
+func NewContextWithAuthClaims(ctx context.Context, claims map[string]interface{}) context.Context {
+	return context.WithValue(ctx, authClaimsContextKey{}, claims)
+}
+
+// authClaims returns the token claims of the client issuing an HTTP request.
+func authClaims(r *http.Request) map[string]interface{} {
+	claims, _ := r.Context().Value(authClaimsContextKey{}).(map[string]interface{})
+	return claims
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testAuditor records the calls of the test namespace.
+type testAuditor struct {
+	mu    sync.Mutex
+	calls []*CallRecord
+	peers []PeerInfo
+}
+
+func (a *testAuditor) Audits(method string) bool {
+	return strings.HasPrefix(method, "test_")
+}
+
+func (a *testAuditor) RecordCall(ctx context.Context, call *CallRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.calls = append(a.calls, call)
+	a.peers = append(a.peers, PeerInfoFromContext(ctx))
+}
+
+// Tests that the audited calls are recorded along with the identity of the caller,
+// including the calls rejected by the server.
+func TestCallAuditor(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy(PolicyConfig{Deny: []string{"test_block"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditor := new(testAuditor)
+	server := newTestServer()
+	server.SetPolicy(policy)
+	server.SetCallAuditor(auditor)
+	defer server.Stop()
+
+	claims := map[string]interface{}{"sub": "operator"}
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(w, r.WithContext(NewContextWithAuthClaims(r.Context(), claims)))
+	}))
+	defer httpsrv.Close()
+
+	client, err := Dial(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var result interface{}
+	client.Call(&result, "test_echo", "x", 1)
+	client.Call(&result, "test_returnError")
+	client.Call(&result, "test_block")
+	client.Call(&result, "rpc_modules")
+
+	want := []struct {
+		method string
+		code   int
+	}{
+		{"test_echo", 0},
+		{"test_returnError", testError{}.ErrorCode()},
+		{"test_block", errcodeMethodNotSupported},
+	}
+	if len(auditor.calls) != len(want) {
+		t.Fatalf("recorded call count mismatch: have %d, want %d", len(auditor.calls), len(want))
+	}
+	for i, call := range auditor.calls {
+		if call.Method != want[i].method {
+			t.Errorf("call %d: method mismatch: have %s, want %s", i, call.Method, want[i].method)
+		}
+		var code int
+		if call.Err != nil {
+			code = call.Err.(Error).ErrorCode()
+		}
+		if code != want[i].code {
+			t.Errorf("call %d: error code mismatch: have %d, want %d", i, code, want[i].code)
+		}
+		if peer := auditor.peers[i]; peer.Transport != "http" || peer.AuthClaims["sub"] != "operator" {
+			t.Errorf("call %d: peer info mismatch: %+v", i, peer)
+		}
+	}
+	if string(auditor.calls[0].Params) != `["x",1]` {
+		t.Errorf("params mismatch: have %s", auditor.calls[0].Params)
+	}
+}
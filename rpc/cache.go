@@ -0,0 +1,198 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+)
+
+// ResponseCache stores the results of method calls which are known not to change
+// anymore, such as queries against finalized blocks. Methods opt into caching by
+// calling CacheResponse while handling a call.
+//
+// Cached results are anchored to the block they were derived from, so they can be
+// invalidated if the chain is ever rolled back beyond that block.
+type ResponseCache struct {
+	maxSize    int    // Maximum number of bytes to cache
+	size       int    // Current number of bytes cached
+	generation uint64 // Incremented on every invalidation
+
+	entries lru.BasicLRU[string, *cachedResponse]
+	lock    sync.Mutex
+}
+
+// cachedResponse is the result of a method call, along with the block number it
+// was derived from.
+type cachedResponse struct {
+	result json.RawMessage
+	number uint64
+}
+
+// NewResponseCache creates a response cache holding at most maxSize bytes worth of
+// results.
+func NewResponseCache(maxSize int) *ResponseCache {
+	return &ResponseCache{
+		maxSize: maxSize,
+		entries: lru.NewBasicLRU[string, *cachedResponse](math.MaxInt),
+	}
+}
+
+// get retrieves the cached result for the given key, if any.
+func (c *ResponseCache) get(key string) (json.RawMessage, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries.Get(key)
+	if !ok {
+		responseCacheMissMeter.Mark(1)
+		return nil, false
+	}
+	responseCacheHitMeter.Mark(1)
+	return entry.result, true
+}
+
+// add inserts a result into the cache, evicting the least recently used ones to
+// stay within the memory budget. The result is discarded if the cache has been
+// invalidated since the given generation, as it might be derived from stale data.
+func (c *ResponseCache) add(key string, result json.RawMessage, number uint64, generation uint64) {
+	size := len(key) + len(result)
+	if size > c.maxSize {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	if old, ok := c.entries.Peek(key); ok {
+		c.size -= len(key) + len(old.result)
+	}
+	c.entries.Add(key, &cachedResponse{result: result, number: number})
+	c.size += size
+
+	for c.size > c.maxSize {
+		oldKey, oldEntry, _ := c.entries.RemoveOldest()
+		c.size -= len(oldKey) + len(oldEntry.result)
+	}
+	responseCacheSizeGauge.Update(int64(c.size))
+}
+
+// currentGeneration returns the number of invalidations done on the cache.
+func (c *ResponseCache) currentGeneration() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.generation
+}
+
+// Invalidate drops all the cached results derived from blocks at or above the
+// given number. It must be called whenever the chain is rolled back to below a
+// block previously reported as cacheable.
+func (c *ResponseCache) Invalidate(number uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+	for _, key := range c.entries.Keys() {
+		if entry, ok := c.entries.Peek(key); ok && entry.number >= number {
+			c.entries.Remove(key)
+			c.size -= len(key) + len(entry.result)
+		}
+	}
+	responseCacheSizeGauge.Update(int64(c.size))
+}
+
+// cacheAnchor is carried in the context of a call to collect the block number the
+// result is derived from, if the method deems it cacheable.
+type cacheAnchor struct {
+	number uint64
+	set    bool
+}
+
+type cacheAnchorKey struct{}
+
+// CanCacheResponse returns whether the response to the call being handled with the
+// given context may be cached. Methods can use it to avoid the checks needed to
+// decide whether a result is immutable if it would not be cached anyway.
+func CanCacheResponse(ctx context.Context) bool {
+	_, ok := ctx.Value(cacheAnchorKey{}).(*cacheAnchor)
+	return ok
+}
+
+// CacheResponse marks the response to the call being handled with the given context
+// as cacheable. The result must be derived from the finalized block with the given
+// number, and must not change unless the chain is rolled back below it.
+//
+// The call is ignored if the server handling the call has no response cache.
+func CacheResponse(ctx context.Context, number uint64) {
+	if anchor, ok := ctx.Value(cacheAnchorKey{}).(*cacheAnchor); ok {
+		anchor.number, anchor.set = number, true
+	}
+}
+
+// responseCacheKey derives the cache key of a call from the method name and the
+// canonical encoding of its parameters. Equivalent hex strings differing only in
+// case and trailing null parameters are normalized away.
+func responseCacheKey(msg *jsonrpcMessage) (string, bool) {
+	var params []interface{}
+	if len(msg.Params) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(msg.Params))
+		dec.UseNumber()
+		if err := dec.Decode(&params); err != nil {
+			return "", false
+		}
+	}
+	for len(params) > 0 && params[len(params)-1] == nil {
+		params = params[:len(params)-1]
+	}
+	for i := range params {
+		params[i] = canonicalizeParam(params[i])
+	}
+	enc, err := json.Marshal(params)
+	if err != nil {
+		return "", false
+	}
+	return msg.Method + string(enc), true
+}
+
+// canonicalizeParam lowercases all the hex strings within a decoded parameter.
+// Object keys are sorted by the JSON encoder.
+func canonicalizeParam(param interface{}) interface{} {
+	switch v := param.(type) {
+	case string:
+		if strings.HasPrefix(v, "0x") || strings.HasPrefix(v, "0X") {
+			return strings.ToLower(v)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = canonicalizeParam(v[i])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = canonicalizeParam(v[key])
+		}
+	}
+	return param
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// cacheTestService counts its invocations, marking the results for blocks up to
+// the finalized one as cacheable.
+type cacheTestService struct {
+	finalized uint64
+	calls     atomic.Int64
+}
+
+func (s *cacheTestService) Block(ctx context.Context, hash string, number uint64) string {
+	s.calls.Add(1)
+	if number <= s.finalized {
+		CacheResponse(ctx, number)
+	}
+	return strings.Repeat("x", 10) + hash
+}
+
+// Tests that cacheable results are served from the cache regardless of the param
+// encoding, that uncacheable ones are not, and that invalidation drops results
+// derived from rolled back blocks.
+func TestResponseCache(t *testing.T) {
+	t.Parallel()
+
+	service := &cacheTestService{finalized: 10}
+	server := NewServer()
+	server.SetResponseCache(NewResponseCache(1024))
+	if err := server.RegisterName("test", service); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	call := func(hash string, number uint64, wantCalls int64) {
+		t.Helper()
+
+		var result string
+		if err := client.Call(&result, "test_block", hash, number); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		if want := strings.Repeat("x", 10) + strings.ToLower(hash); result != want {
+			t.Fatalf("result mismatch: have %q, want %q", result, want)
+		}
+		if calls := service.calls.Load(); calls != wantCalls {
+			t.Fatalf("method invocation count mismatch: have %d, want %d", calls, wantCalls)
+		}
+	}
+	call("0xab", 5, 1)
+	call("0xab", 5, 1)  // cached
+	call("0xAB", 5, 1)  // cached, hex case is irrelevant
+	call("0xcd", 11, 2) // not finalized
+	call("0xcd", 11, 3)
+
+	// Invalidating above the anchor should retain the cached result
+	server.responseCache.Invalidate(6)
+	call("0xab", 5, 3)
+
+	// Invalidating at the anchor should drop it
+	server.responseCache.Invalidate(5)
+	call("0xab", 5, 4)
+	call("0xab", 5, 4)
+}
+
+// Tests that the response cache respects its memory budget, evicting the least
+// recently used results first.
+func TestResponseCacheEviction(t *testing.T) {
+	t.Parallel()
+
+	cache := NewResponseCache(120)
+	blob := []byte(strings.Repeat("x", 30))
+
+	for i, key := range []string{"key0", "key1", "key2"} {
+		cache.add(key, blob, uint64(i), 0)
+	}
+	if _, ok := cache.get("key0"); !ok {
+		t.Fatal("key0 missing")
+	}
+	cache.add("key3", blob, 3, 0)
+	if _, ok := cache.get("key1"); ok {
+		t.Error("least recently used key1 not evicted")
+	}
+	for _, key := range []string{"key0", "key2", "key3"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("%s missing", key)
+		}
+	}
+	if cache.size > 120 {
+		t.Errorf("cache size %d exceeds budget", cache.size)
+	}
+	// Results added after an invalidation started must be discarded
+	generation := cache.currentGeneration()
+	cache.Invalidate(10)
+	cache.add("key4", blob, 4, generation)
+	if _, ok := cache.get("key4"); ok {
+		t.Error("stale result cached")
+	}
+}
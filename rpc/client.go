@@ -46,6 +46,10 @@ const (
 	defaultDialTimeout = 10 * time.Second // used if context has no deadline
 	subscribeTimeout   = 10 * time.Second // overall timeout eth_subscribe, rpc_modules calls
 	unsubscribeTimeout = 10 * time.Second // timeout for *_unsubscribe calls
+
+	// Backoff between attempts to resume subscriptions
+	resumeMinBackoff = 100 * time.Millisecond
+	resumeMaxBackoff = 5 * time.Second
 )
 
 const (
@@ -89,6 +93,11 @@ type Client struct {
 	// config fields
 	batchItemLimit       int
 	batchResponseMaxSize int
+	rateLimiter          *RateLimiter
+	responseCache        *ResponseCache
+	policy               *Policy
+	auditor              CallAuditor
+	resumeTimeout        time.Duration // time allowed for resuming subscriptions, 0 = disabled
 
 	// writeConn is used for writing to the connection on the caller's goroutine. It should
 	// only be accessed outside of dispatch, with the write lock held. The write lock is
@@ -120,7 +129,7 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, clientContextKey{}, c)
 	ctx = context.WithValue(ctx, peerInfoContextKey{}, conn.peerInfo())
-	handler := newHandler(ctx, conn, c.idgen, c.services, c.batchItemLimit, c.batchResponseMaxSize)
+	handler := newHandler(ctx, conn, c.idgen, c.services, c.batchItemLimit, c.batchResponseMaxSize, c.rateLimiter, c.responseCache, c.policy, c.auditor)
 	return &clientConn{conn, handler}
 }
 
@@ -141,6 +150,7 @@ type requestOp struct {
 	err         error
 	resp        chan []*jsonrpcMessage // the response goes here
 	sub         *ClientSubscription    // set for Subscribe requests.
+	resume      bool                   // set for requests resuming sub
 	hadResponse bool                   // true when the request was responded to
 }
 
@@ -248,6 +258,11 @@ func initClient(conn ServerCodec, services *serviceRegistry, cfg *clientConfig)
 		idgen:                cfg.idgen,
 		batchItemLimit:       cfg.batchItemLimit,
 		batchResponseMaxSize: cfg.batchResponseLimit,
+		rateLimiter:          cfg.rateLimiter,
+		responseCache:        cfg.responseCache,
+		policy:               cfg.policy,
+		auditor:              cfg.auditor,
+		resumeTimeout:        cfg.resumeTimeout,
 		writeConn:            conn,
 		close:                make(chan struct{}),
 		closing:              make(chan struct{}),
@@ -525,7 +540,7 @@ func (c *Client) Subscribe(ctx context.Context, namespace string, channel interf
 	op := &requestOp{
 		ids:  []json.RawMessage{msg.ID},
 		resp: make(chan []*jsonrpcMessage, 1),
-		sub:  newClientSubscription(c, namespace, chanVal),
+		sub:  newClientSubscription(c, namespace, chanVal, args),
 	}
 
 	// Send the subscription request.
@@ -539,6 +554,26 @@ func (c *Client) Subscribe(ctx context.Context, namespace string, channel interf
 	return op.sub, nil
 }
 
+// resubscribe re-establishes a subscription on the current connection, resuming
+// it from the given cursor.
+func (c *Client) resubscribe(ctx context.Context, sub *ClientSubscription, cursor string) error {
+	msg, err := c.newMessage(sub.namespace+resubscribeMethodSuffix, append([]interface{}{cursor}, sub.args...)...)
+	if err != nil {
+		return err
+	}
+	op := &requestOp{
+		ids:    []json.RawMessage{msg.ID},
+		resp:   make(chan []*jsonrpcMessage, 1),
+		sub:    sub,
+		resume: true,
+	}
+	if err := c.send(ctx, op, msg); err != nil {
+		return err
+	}
+	_, err = op.wait(ctx, c)
+	return err
+}
+
 // SupportsSubscriptions reports whether subscriptions are supported by the client
 // transport. When this returns false, Subscribe and related methods will return
 // ErrNotificationsUnsupported.
@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -41,6 +42,13 @@ type clientConfig struct {
 	idgen              func() ID
 	batchItemLimit     int
 	batchResponseLimit int
+	rateLimiter        *RateLimiter
+	responseCache      *ResponseCache
+	policy             *Policy
+	auditor            CallAuditor
+
+	// Subscription options
+	resumeTimeout time.Duration
 }
 
 func (cfg *clientConfig) initHeaders() {
@@ -121,6 +129,20 @@ func WithHTTPAuth(a HTTPAuth) ClientOption {
 // auth information to the request.
 type HTTPAuth func(h http.Header) error
 
+// WithSubscriptionResumption enables resuming subscriptions after the connection to
+// the server was lost. Subscriptions whose notifications carry cursors are then
+// re-established on a new connection from the cursor of the last notification
+// received, and the server sends the notifications missed in between. Reconnecting
+// is attempted for up to the given timeout, after which the subscription fails.
+//
+// Note that the server may only be able to resume from recent cursors. Subscriptions
+// fail with the error returned by the server if resuming is not possible.
+func WithSubscriptionResumption(timeout time.Duration) ClientOption {
+	return optionFunc(func(cfg *clientConfig) {
+		cfg.resumeTimeout = timeout
+	})
+}
+
 // WithBatchItemLimit changes the maximum number of items allowed in batch requests.
 //
 // Note: this option applies when processing incoming batch requests. It does not affect
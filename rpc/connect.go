@@ -0,0 +1,441 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// ConnectServiceName is the fully qualified name of the Connect service serving
+	// the registered methods. The schema of the service is defined in connect.proto.
+	ConnectServiceName = "geth.rpc.v1.RPCService"
+
+	connectCallProcedure      = "/" + ConnectServiceName + "/Call"
+	connectSubscribeProcedure = "/" + ConnectServiceName + "/Subscribe"
+
+	connectUnaryContentType  = "application/proto"
+	connectStreamContentType = "application/connect+proto"
+
+	connectEnvelopeSize  = 5    // Size of the flags and length prefix of streamed messages
+	connectFlagCompress  = 0x01 // Envelope flag of compressed messages
+	connectFlagEndStream = 0x02 // Envelope flag of the end of stream message
+)
+
+var errConnectStreamClosed = errors.New("connect stream closed")
+
+// ConnectHandler returns an http.Handler serving the registered methods over the
+// Connect protocol. Subscriptions, including resumed ones, are served as server
+// streams.
+//
+// Calls and their results are framed with protobuf instead of JSON-RPC envelopes,
+// but parameters and results themselves stay JSON encoded. This saves parsing the
+// request envelope and escaping the result into the response, not the encoding of
+// the result: for large results such as blocks and receipts, the cost of Connect
+// calls is about the same as over HTTP. See BenchmarkConnectCall.
+//
+// Both HTTP/1.1 and HTTP/2 are supported by the protocol, the latter needs to be
+// enabled on the serving http.Server for cleartext connections.
+func (s *Server) ConnectHandler() http.Handler {
+	return http.HandlerFunc(s.serveConnect)
+}
+
+// serveConnect validates a Connect request, dispatching it to the unary or the
+// streaming procedure.
+func (s *Server) serveConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var (
+		stream      bool
+		contentType = connectUnaryContentType
+		encoding    = r.Header.Get("content-encoding")
+	)
+	switch {
+	case strings.HasSuffix(r.URL.Path, connectCallProcedure):
+	case strings.HasSuffix(r.URL.Path, connectSubscribeProcedure):
+		stream = true
+		contentType = connectStreamContentType
+		encoding = r.Header.Get("connect-content-encoding")
+	default:
+		writeConnectError(w, http.StatusNotFound, "unimplemented", "unknown procedure "+r.URL.Path)
+		return
+	}
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("content-type")); err != nil || mt != contentType {
+		w.Header().Set("accept-post", contentType)
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+	if encoding != "" && encoding != "identity" {
+		writeConnectError(w, http.StatusNotImplemented, "unimplemented", "unsupported compression "+encoding)
+		return
+	}
+	// Read the request message, unwrapping it from the envelope if streaming
+	limit := s.httpBodyLimit
+	if stream {
+		limit += connectEnvelopeSize
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+	if err != nil {
+		writeConnectError(w, http.StatusBadRequest, "invalid_argument", "read error")
+		return
+	}
+	if len(body) > limit {
+		writeConnectError(w, http.StatusTooManyRequests, "resource_exhausted", fmt.Sprintf("message too large (>%d)", s.httpBodyLimit))
+		return
+	}
+	if stream {
+		if body, err = unwrapConnectEnvelope(body); err != nil {
+			writeConnectError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+			return
+		}
+	}
+	var req connectRequest
+	if err := req.unmarshal(body); err != nil {
+		writeConnectError(w, http.StatusBadRequest, "invalid_argument", "invalid request: "+err.Error())
+		return
+	}
+	msg := &jsonrpcMessage{Version: vsn, ID: json.RawMessage("1"), Method: req.method, Params: req.params}
+	if stream && !msg.isSubscribe() && !msg.isResubscribe() {
+		writeConnectError(w, http.StatusBadRequest, "invalid_argument", "not a subscription method: "+req.method)
+		return
+	}
+	// Create the request-scoped peer info
+	info := PeerInfo{Transport: "connect", RemoteAddr: r.RemoteAddr}
+	info.HTTP.Version = r.Proto
+	info.HTTP.Host = r.Host
+	info.HTTP.Origin = r.Header.Get("Origin")
+	info.HTTP.UserAgent = r.Header.Get("User-Agent")
+	info.AuthClaims = authClaims(r)
+	if s.rateLimiter != nil {
+		info.ClientID = s.rateLimiter.clientID(r)
+	}
+	if stream {
+		s.serveConnectStream(w, r, msg, info)
+	} else {
+		s.serveConnectUnary(w, r, msg, info)
+	}
+}
+
+// serveConnectUnary runs a single method call, writing back the response.
+func (s *Server) serveConnectUnary(w http.ResponseWriter, r *http.Request, msg *jsonrpcMessage, info PeerInfo) {
+	ctx := context.WithValue(r.Context(), peerInfoContextKey{}, info)
+	ctx = newContextWithTraceparent(ctx, r.Header)
+	if timeout := r.Header.Get("connect-timeout-ms"); timeout != "" {
+		ms, err := strconv.ParseUint(timeout, 10, 63)
+		if err != nil {
+			writeConnectError(w, http.StatusBadRequest, "invalid_argument", "invalid timeout")
+			return
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+		defer cancel()
+	}
+	codec := &connectUnaryCodec{connectConn: connectConn{info: info, request: msg, closeCh: make(chan interface{})}}
+	defer codec.close()
+
+	s.serveSingleRequest(ctx, codec)
+
+	codec.lock.Lock()
+	response := codec.response
+	codec.lock.Unlock()
+
+	if response == nil {
+		writeConnectError(w, http.StatusServiceUnavailable, "unavailable", "server stopped")
+		return
+	}
+	w.Header().Set("content-type", connectUnaryContentType)
+	w.Write(newConnectResponse(response).marshal())
+}
+
+// serveConnectStream runs a subscription, streaming back its notifications until
+// either the client goes away or the server is stopped.
+func (s *Server) serveConnectStream(w http.ResponseWriter, r *http.Request, msg *jsonrpcMessage, info PeerInfo) {
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{}) // Subscriptions are long lived
+
+	w.Header().Set("content-type", connectStreamContentType)
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	codec := &connectStreamCodec{
+		connectConn: connectConn{info: info, request: msg, closeCh: make(chan interface{})},
+		w:           w,
+		rc:          rc,
+	}
+	go func() {
+		select {
+		case <-r.Context().Done():
+			codec.close()
+		case <-codec.closed():
+		}
+	}()
+	s.ServeCodec(codec, 0)
+	codec.finish()
+}
+
+// connectConn is the common part of the server codecs of the Connect transport,
+// handing the single request of a Connect call to the handler.
+type connectConn struct {
+	info    PeerInfo
+	request *jsonrpcMessage
+	read    atomic.Bool // Whether the request was handed to the handler
+
+	closer  sync.Once
+	closeCh chan interface{}
+}
+
+func (c *connectConn) peerInfo() PeerInfo {
+	return c.info
+}
+
+func (c *connectConn) remoteAddr() string {
+	return c.info.RemoteAddr
+}
+
+// readBatch returns the request of the call once, blocking afterwards until the
+// codec is closed.
+func (c *connectConn) readBatch() ([]*jsonrpcMessage, bool, error) {
+	if c.read.CompareAndSwap(false, true) {
+		return []*jsonrpcMessage{c.request}, false, nil
+	}
+	<-c.closeCh
+	return nil, false, io.EOF
+}
+
+func (c *connectConn) close() {
+	c.closer.Do(func() { close(c.closeCh) })
+}
+
+func (c *connectConn) closed() <-chan interface{} {
+	return c.closeCh
+}
+
+// connectUnaryCodec captures the response of a unary call.
+type connectUnaryCodec struct {
+	connectConn
+
+	response *jsonrpcMessage
+	lock     sync.Mutex
+}
+
+func (c *connectUnaryCodec) writeJSON(ctx context.Context, v interface{}, isError bool) error {
+	msg, ok := v.(*jsonrpcMessage)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.response == nil {
+		c.response = msg
+	}
+	return nil
+}
+
+// connectStreamCodec writes the subscription response and notifications into a
+// server stream, each in its own envelope.
+type connectStreamCodec struct {
+	connectConn
+
+	w    http.ResponseWriter
+	rc   *http.ResponseController
+	done bool // Whether the end of stream was written
+	lock sync.Mutex
+}
+
+func (c *connectStreamCodec) writeJSON(ctx context.Context, v interface{}, isError bool) error {
+	var resp *connectResponse
+	switch msg := v.(type) {
+	case *jsonrpcMessage:
+		resp = newConnectResponse(msg)
+	case *jsonrpcSubscriptionNotification:
+		result, err := json.Marshal(msg.Params.Result)
+		if err != nil {
+			return err
+		}
+		resp = &connectResponse{result: result, subscription: msg.Params.ID, cursor: msg.Params.Cursor}
+	default:
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.done {
+		return errConnectStreamClosed
+	}
+	if err := c.writeEnvelope(0, resp.marshal()); err != nil {
+		return err
+	}
+	// A failed subscription has nothing more to stream
+	if resp.err != nil {
+		c.close()
+	}
+	return nil
+}
+
+// finish terminates the stream with an end of stream message. Writes to the codec
+// fail afterwards.
+func (c *connectStreamCodec) finish() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.done {
+		c.writeEnvelope(connectFlagEndStream, []byte("{}"))
+		c.done = true
+	}
+}
+
+// writeEnvelope writes and flushes a single message with the given flags. The lock
+// is assumed to be held.
+func (c *connectStreamCodec) writeEnvelope(flags byte, data []byte) error {
+	var header [connectEnvelopeSize]byte
+	header[0] = flags
+	binary.BigEndian.PutUint32(header[1:], uint32(len(data)))
+
+	if _, err := c.w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := c.w.Write(data); err != nil {
+		return err
+	}
+	return c.rc.Flush()
+}
+
+// unwrapConnectEnvelope extracts the single message from an enveloped stream.
+func unwrapConnectEnvelope(data []byte) ([]byte, error) {
+	if len(data) < connectEnvelopeSize {
+		return nil, errors.New("truncated message envelope")
+	}
+	if data[0]&connectFlagCompress != 0 {
+		return nil, errors.New("compressed messages not supported")
+	}
+	size := binary.BigEndian.Uint32(data[1:connectEnvelopeSize])
+	if uint64(len(data)-connectEnvelopeSize) != uint64(size) {
+		return nil, errors.New("message envelope size mismatch")
+	}
+	return data[connectEnvelopeSize:], nil
+}
+
+// connectError is the JSON encoded error of failed Connect calls.
+type connectError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// writeConnectError responds to a Connect call which could not be dispatched.
+func writeConnectError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&connectError{Code: code, Message: message})
+}
+
+// connectRequest is the Request message of connect.proto.
+type connectRequest struct {
+	method string
+	params json.RawMessage
+}
+
+// unmarshal decodes a protobuf encoded request, skipping unknown fields.
+func (req *connectRequest) unmarshal(data []byte) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			req.method, n = protowire.ConsumeString(data)
+		case num == 2 && typ == protowire.BytesType:
+			req.params, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+	}
+	if req.method == "" {
+		return errors.New("missing method")
+	}
+	return nil
+}
+
+// connectResponse is the Response message of connect.proto.
+type connectResponse struct {
+	result       json.RawMessage
+	err          *jsonError
+	subscription string
+	cursor       string
+}
+
+// newConnectResponse converts a JSON-RPC response into a Connect one.
+func newConnectResponse(msg *jsonrpcMessage) *connectResponse {
+	return &connectResponse{result: msg.Result, err: msg.Error}
+}
+
+// marshal encodes the response with protobuf.
+func (resp *connectResponse) marshal() []byte {
+	var data []byte
+	if len(resp.result) > 0 {
+		data = protowire.AppendTag(data, 1, protowire.BytesType)
+		data = protowire.AppendBytes(data, resp.result)
+	}
+	if resp.err != nil {
+		var enc []byte
+		enc = protowire.AppendTag(enc, 1, protowire.VarintType)
+		enc = protowire.AppendVarint(enc, uint64(int64(resp.err.Code)))
+		enc = protowire.AppendTag(enc, 2, protowire.BytesType)
+		enc = protowire.AppendString(enc, resp.err.Message)
+		if resp.err.Data != nil {
+			if blob, err := json.Marshal(resp.err.Data); err == nil {
+				enc = protowire.AppendTag(enc, 3, protowire.BytesType)
+				enc = protowire.AppendBytes(enc, blob)
+			}
+		}
+		data = protowire.AppendTag(data, 2, protowire.BytesType)
+		data = protowire.AppendBytes(data, enc)
+	}
+	if resp.subscription != "" {
+		data = protowire.AppendTag(data, 3, protowire.BytesType)
+		data = protowire.AppendString(data, resp.subscription)
+	}
+	if resp.cursor != "" {
+		data = protowire.AppendTag(data, 4, protowire.BytesType)
+		data = protowire.AppendString(data, resp.cursor)
+	}
+	return data
+}
@@ -0,0 +1,303 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// encodeConnectRequest creates a protobuf encoded Connect request.
+func encodeConnectRequest(method string, params string) []byte {
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.BytesType)
+	data = protowire.AppendString(data, method)
+	data = protowire.AppendTag(data, 2, protowire.BytesType)
+	data = protowire.AppendString(data, params)
+	return data
+}
+
+// decodeConnectResponse parses a protobuf encoded Connect response.
+func decodeConnectResponse(t *testing.T, data []byte) *connectResponse {
+	t.Helper()
+
+	resp := new(connectResponse)
+	for len(data) > 0 {
+		num, _, n := protowire.ConsumeTag(data)
+		data = data[n:]
+		field, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			t.Fatalf("invalid response field %d: %v", num, protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		switch num {
+		case 1:
+			resp.result = field
+		case 2:
+			resp.err = new(jsonError)
+			for len(field) > 0 {
+				num, typ, n := protowire.ConsumeTag(field)
+				field = field[n:]
+				if typ == protowire.VarintType {
+					code, n := protowire.ConsumeVarint(field)
+					resp.err.Code, field = int(int64(code)), field[n:]
+					continue
+				}
+				value, n := protowire.ConsumeBytes(field)
+				field = field[n:]
+				if num == 2 {
+					resp.err.Message = string(value)
+				} else {
+					resp.err.Data = string(value)
+				}
+			}
+		case 3:
+			resp.subscription = string(field)
+		case 4:
+			resp.cursor = string(field)
+		}
+	}
+	return resp
+}
+
+// readConnectEnvelope reads a single enveloped message from a Connect stream.
+func readConnectEnvelope(t *testing.T, r io.Reader) (byte, []byte) {
+	t.Helper()
+
+	var header [connectEnvelopeSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		t.Fatalf("failed to read envelope: %v", err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		t.Fatalf("failed to read message: %v", err)
+	}
+	return header[0], data
+}
+
+// postConnectStream starts a streaming Connect call.
+func postConnectStream(t *testing.T, url string, method, params string) *http.Response {
+	t.Helper()
+
+	msg := encodeConnectRequest(method, params)
+	body := make([]byte, connectEnvelopeSize, connectEnvelopeSize+len(msg))
+	binary.BigEndian.PutUint32(body[1:], uint32(len(msg)))
+	body = append(body, msg...)
+
+	resp, err := http.Post(url+connectSubscribeProcedure, connectStreamContentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	return resp
+}
+
+// Tests that unary calls are dispatched to the registered methods, and that the
+// results and errors are relayed back.
+func TestConnectCall(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer()
+	defer server.Stop()
+
+	httpsrv := httptest.NewServer(server.ConnectHandler())
+	defer httpsrv.Close()
+
+	call := func(method, params string) *connectResponse {
+		t.Helper()
+
+		body := bytes.NewReader(encodeConnectRequest(method, params))
+		resp, err := http.Post(httpsrv.URL+connectCallProcedure, connectUnaryContentType, body)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", method, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status mismatch: have %d, want %d", method, resp.StatusCode, http.StatusOK)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return decodeConnectResponse(t, data)
+	}
+	if resp := call("test_echo", `["hello", 10, {"S": "world"}]`); string(resp.result) != `{"String":"hello","Int":10,"Args":{"S":"world"}}` || resp.err != nil {
+		t.Errorf("echo response mismatch: result %s, error %v", resp.result, resp.err)
+	}
+	if resp := call("test_returnError", `[]`); resp.err == nil || resp.err.Code != 444 || resp.err.Data != `"testError data"` {
+		t.Errorf("error response mismatch: %+v", resp.err)
+	}
+	if resp := call("test_missing", `[]`); resp.err == nil || resp.err.Code != -32601 {
+		t.Errorf("missing method response mismatch: %+v", resp.err)
+	}
+	if resp := call("nftest_subscribe", `["someSubscription", 1, 1]`); resp.err == nil {
+		t.Error("subscription served over unary call")
+	}
+	// Check that invalid calls are rejected by the transport
+	for _, tt := range []struct {
+		path        string
+		contentType string
+		status      int
+	}{
+		{connectCallProcedure, "application/json", http.StatusUnsupportedMediaType},
+		{connectSubscribeProcedure, connectUnaryContentType, http.StatusUnsupportedMediaType},
+		{"/" + ConnectServiceName + "/Missing", connectUnaryContentType, http.StatusNotFound},
+	} {
+		body := bytes.NewReader(encodeConnectRequest("test_echo", `[]`))
+		resp, err := http.Post(httpsrv.URL+tt.path, tt.contentType, body)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s (%s): status mismatch: have %d, want %d", tt.path, tt.contentType, resp.StatusCode, tt.status)
+		}
+	}
+}
+
+// Tests that subscriptions are streamed back, and that the stream is terminated
+// when the server stops.
+func TestConnectSubscribe(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer()
+	httpsrv := httptest.NewServer(server.ConnectHandler())
+	defer httpsrv.Close()
+
+	resp := postConnectStream(t, httpsrv.URL, "nftest_subscribe", `["someSubscription", 3, 10]`)
+	defer resp.Body.Close()
+
+	// The first message carries the subscription ID, the rest the notifications
+	_, data := readConnectEnvelope(t, resp.Body)
+	sub := decodeConnectResponse(t, data)
+	if sub.err != nil {
+		t.Fatalf("subscription failed: %v", sub.err)
+	}
+	for i := 0; i < 3; i++ {
+		_, data := readConnectEnvelope(t, resp.Body)
+		notif := decodeConnectResponse(t, data)
+		if want := fmt.Sprintf(`"%s"`, notif.subscription); want != string(sub.result) {
+			t.Errorf("notification %d: subscription mismatch: have %s, want %s", i, want, sub.result)
+		}
+		if want := fmt.Sprint(10 + i); string(notif.result) != want {
+			t.Errorf("notification %d: result mismatch: have %s, want %s", i, notif.result, want)
+		}
+	}
+	server.Stop()
+
+	flags, data := readConnectEnvelope(t, resp.Body)
+	if flags != connectFlagEndStream || string(data) != "{}" {
+		t.Errorf("end of stream mismatch: flags %#x, data %s", flags, data)
+	}
+}
+
+// Tests that subscriptions can be resumed over a stream, and that the cursors of
+// the notifications are relayed.
+func TestConnectResubscribe(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer()
+	server.RegisterName("resume", new(resumeTestService))
+	defer server.Stop()
+
+	httpsrv := httptest.NewServer(server.ConnectHandler())
+	defer httpsrv.Close()
+
+	resp := postConnectStream(t, httpsrv.URL, "resume_resubscribe", `["1", "counter"]`)
+	defer resp.Body.Close()
+
+	_, data := readConnectEnvelope(t, resp.Body)
+	if sub := decodeConnectResponse(t, data); sub.err != nil {
+		t.Fatalf("resubscription failed: %v", sub.err)
+	}
+	for i := 2; i < 5; i++ {
+		_, data := readConnectEnvelope(t, resp.Body)
+		notif := decodeConnectResponse(t, data)
+		if want := fmt.Sprint(i); string(notif.result) != want || notif.cursor != want {
+			t.Errorf("notification mismatch: result %s, cursor %q, want %s", notif.result, notif.cursor, want)
+		}
+	}
+}
+
+// connectBenchService serves a result shaped like a block with its transactions.
+type connectBenchService struct {
+	block map[string]interface{}
+}
+
+func (s *connectBenchService) Block() map[string]interface{} {
+	return s.block
+}
+
+func newConnectBenchService(txs int) *connectBenchService {
+	var (
+		hash = "0x" + strings.Repeat("ab", 32)
+		addr = "0x" + strings.Repeat("cd", 20)
+		list = make([]map[string]interface{}, txs)
+	)
+	for i := range list {
+		list[i] = map[string]interface{}{
+			"blockHash": hash, "blockNumber": "0x1234", "from": addr, "to": addr,
+			"gas": "0x5208", "gasPrice": "0x3b9aca00", "hash": hash, "input": "0x" + strings.Repeat("ef", 68),
+			"nonce": fmt.Sprintf("%#x", i), "transactionIndex": fmt.Sprintf("%#x", i), "value": "0xde0b6b3a7640000",
+			"type": "0x2", "chainId": "0x1", "v": "0x1", "r": hash, "s": hash,
+		}
+	}
+	block := map[string]interface{}{
+		"hash": hash, "parentHash": hash, "stateRoot": hash, "miner": addr,
+		"number": "0x1234", "gasLimit": "0x1c9c380", "gasUsed": "0xe4e1c0",
+		"transactions": list,
+	}
+	return &connectBenchService{block: block}
+}
+
+// BenchmarkConnectCall compares serving a block sized result over JSON-RPC on HTTP
+// and over Connect. Results are JSON encoded on both transports, so the difference
+// is only the framing of the request and response.
+func BenchmarkConnectCall(b *testing.B) {
+	server := NewServer()
+	server.RegisterName("bench", newConnectBenchService(200))
+	defer server.Stop()
+
+	run := func(b *testing.B, url, contentType string, body []byte) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resp, err := http.Post(url, contentType, bytes.NewReader(body))
+			if err != nil {
+				b.Fatal(err)
+			}
+			n, _ := io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			b.SetBytes(n)
+		}
+	}
+	b.Run("jsonrpc", func(b *testing.B) {
+		httpsrv := httptest.NewServer(server)
+		defer httpsrv.Close()
+		run(b, httpsrv.URL, "application/json", []byte(`{"jsonrpc":"2.0","id":1,"method":"bench_block","params":[]}`))
+	})
+	b.Run("connect", func(b *testing.B) {
+		httpsrv := httptest.NewServer(server.ConnectHandler())
+		defer httpsrv.Close()
+		run(b, httpsrv.URL+connectCallProcedure, connectUnaryContentType, encodeConnectRequest("bench_block", `[]`))
+	})
+}
@@ -19,8 +19,14 @@ package rpc
 import (
 	"context"
 	"net/http"
+
+	"github.com/ethereum/go-ethereum/internal/telemetry"
 )
 
+// traceparentHeader is the W3C trace context header propagating the span of the
+// caller of a request.
+const traceparentHeader = "Traceparent"
+
 type mdHeaderKey struct{}
 
 // NewContextWithHeaders wraps the given context, adding HTTP headers. These headers will
@@ -54,3 +60,29 @@ func setHeaders(dst http.Header, src http.Header) http.Header {
 	}
 	return dst
 }
+
+// newContextWithTraceparent returns a copy of the context with the span of the
+// remote caller, received in the trace context headers, set as the parent of the
+// spans started while serving the request. Malformed headers are ignored.
+func newContextWithTraceparent(ctx context.Context, h http.Header) context.Context {
+	value := h.Get(traceparentHeader)
+	if value == "" {
+		return ctx
+	}
+	sc, err := telemetry.ParseTraceparent(value)
+	if err != nil {
+		return ctx
+	}
+	return telemetry.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// setTraceparent propagates the current span of the context in the trace context
+// headers of an outgoing request, unless the caller already set them.
+func setTraceparent(dst http.Header, ctx context.Context) {
+	if dst.Get(traceparentHeader) != "" {
+		return
+	}
+	if sc, ok := telemetry.SpanContextFromContext(ctx); ok {
+		dst.Set(traceparentHeader, sc.Traceparent())
+	}
+}
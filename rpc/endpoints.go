@@ -25,9 +25,14 @@ import (
 
 // StartIPCEndpoint starts an IPC endpoint.
 func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
+	return StartIPCEndpointWithServer(ipcEndpoint, apis, NewServer())
+}
+
+// StartIPCEndpointWithServer starts an IPC endpoint serving the APIs on the given
+// server, which may be configured before being passed in.
+func StartIPCEndpointWithServer(ipcEndpoint string, apis []API, handler *Server) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
 	var (
-		handler    = NewServer()
 		regMap     = make(map[string]struct{})
 		registered []string
 	)
@@ -16,7 +16,11 @@
 
 package rpc
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"time"
+)
 
 // HTTPError is returned by client operations when the HTTP status code of the
 // response is not a 2xx status.
@@ -55,14 +59,19 @@ var (
 	_ Error = new(invalidMessageError)
 	_ Error = new(invalidParamsError)
 	_ Error = new(internalServerError)
+	_ Error = new(rateLimitError)
+	_ Error = new(policyError)
 )
 
 const (
-	errcodeDefault          = -32000
-	errcodeTimeout          = -32002
-	errcodeResponseTooLarge = -32003
-	errcodePanic            = -32603
-	errcodeMarshalError     = -32603
+	errcodeDefault            = -32000
+	errcodeTimeout            = -32002
+	errcodeResponseTooLarge   = -32003
+	errcodeMethodNotSupported = -32004
+	errcodeLimitExceeded      = -32005
+	errcodeInvalidParams      = -32602
+	errcodePanic              = -32603
+	errcodeMarshalError       = -32603
 
 	legacyErrcodeNotificationsUnsupported = -32001
 )
@@ -154,3 +163,25 @@ type internalServerError struct {
 func (e *internalServerError) ErrorCode() int { return e.code }
 
 func (e *internalServerError) Error() string { return e.message }
+
+// rateLimitError is returned if a client exceeds the rate limit of a method.
+type rateLimitError struct {
+	method     string
+	retryAfter time.Duration // Zero if the call will never be permitted
+}
+
+// rateLimitErrorData is the error data of a rate limited call, telling the client
+// how many seconds to wait before retrying.
+type rateLimitErrorData struct {
+	RetryAfter uint64 `json:"retryAfter,omitempty"`
+}
+
+func (e *rateLimitError) ErrorCode() int { return errcodeLimitExceeded }
+
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded for %s", e.method)
+}
+
+func (e *rateLimitError) ErrorData() interface{} {
+	return rateLimitErrorData{RetryAfter: uint64(math.Ceil(e.retryAfter.Seconds()))}
+}
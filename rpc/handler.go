@@ -28,6 +28,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/internal/telemetry"
 	"github.com/ethereum/go-ethereum/log"
 )
 
@@ -65,6 +66,10 @@ type handler struct {
 	allowSubscribe       bool
 	batchRequestLimit    int
 	batchResponseMaxSize int
+	rateLimiter          *RateLimiter   // optional per client method rate limits
+	responseCache        *ResponseCache // optional cache of immutable call results
+	policy               *Policy        // optional method and parameter restrictions
+	auditor              CallAuditor    // optional record of the calls served
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -75,7 +80,7 @@ type callProc struct {
 	notifiers []*Notifier
 }
 
-func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry, batchRequestLimit, batchResponseMaxSize int) *handler {
+func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *serviceRegistry, batchRequestLimit, batchResponseMaxSize int, rateLimiter *RateLimiter, responseCache *ResponseCache, policy *Policy, auditor CallAuditor) *handler {
 	rootCtx, cancelRoot := context.WithCancel(connCtx)
 	h := &handler{
 		reg:                  reg,
@@ -90,6 +95,10 @@ func newHandler(connCtx context.Context, conn jsonWriter, idgen func() ID, reg *
 		log:                  log.Root(),
 		batchRequestLimit:    batchRequestLimit,
 		batchResponseMaxSize: batchResponseMaxSize,
+		rateLimiter:          rateLimiter,
+		responseCache:        responseCache,
+		policy:               policy,
+		auditor:              auditor,
 	}
 	if conn.remoteAddr() != "" {
 		h.log = h.log.New("conn", conn.remoteAddr())
@@ -410,10 +419,15 @@ func (h *handler) handleResponses(batch []*jsonrpcMessage, handleCall func(*json
 			if msg.Error != nil {
 				op.err = msg.Error
 			} else {
-				op.err = json.Unmarshal(msg.Result, &op.sub.subid)
+				var subid string
+				op.err = json.Unmarshal(msg.Result, &subid)
 				if op.err == nil {
-					go op.sub.run()
-					h.clientSubs[op.sub.subid] = op.sub
+					// Resumed subscriptions are running already.
+					op.sub.setID(subid)
+					if !op.resume {
+						go op.sub.run()
+					}
+					h.clientSubs[subid] = op.sub
 				}
 			}
 		}
@@ -456,7 +470,7 @@ func (h *handler) handleSubscriptionResult(msg *jsonrpcMessage) {
 		return
 	}
 	if h.clientSubs[result.ID] != nil {
-		h.clientSubs[result.ID].deliver(result.Result)
+		h.clientSubs[result.ID].deliver(&result)
 	}
 }
 
@@ -493,8 +507,47 @@ func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage) *jsonrpcMess
 }
 
 // handleCall processes method calls.
-func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
-	if msg.isSubscribe() {
+func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) (answer *jsonrpcMessage) {
+	ctx, span := telemetry.StartServerSpan(cp.ctx, msg.Method,
+		telemetry.String("rpc.system", "jsonrpc"),
+		telemetry.String("rpc.method", msg.Method),
+	)
+	defer func() {
+		if answer != nil && answer.Error != nil {
+			span.SetAttributes(telemetry.Int64("rpc.jsonrpc.error_code", int64(answer.Error.Code)))
+			span.RecordError(answer.Error)
+		}
+		span.End()
+	}()
+	if h.auditor != nil && h.auditor.Audits(msg.Method) {
+		start := time.Now()
+		defer func() {
+			call := &CallRecord{Method: msg.Method, Params: msg.Params, Start: start, Duration: time.Since(start)}
+			if answer != nil && answer.Error != nil {
+				call.Err = answer.Error
+			}
+			h.auditor.RecordCall(ctx, call)
+		}()
+	}
+
+	method, params, err := msg.checkedCall()
+	if err != nil {
+		return msg.errorResponse(&invalidParamsError{err.Error()})
+	}
+	if h.policy != nil {
+		if err := h.policy.check(method, params); err != nil {
+			policyRejectedMeter.Mark(1)
+			h.log.Info("Rejected RPC call by policy", "method", method, "reason", err.reason)
+			return msg.errorResponse(err)
+		}
+	}
+	if h.rateLimiter != nil && !msg.isUnsubscribe() {
+		if retry, ok := h.rateLimiter.allow(ctx, method); !ok {
+			updateRateLimitedMeter(method)
+			return msg.errorResponse(&rateLimitError{method: method, retryAfter: retry})
+		}
+	}
+	if msg.isSubscribe() || msg.isResubscribe() {
 		return h.handleSubscribe(cp, msg)
 	}
 	var callb *callback
@@ -512,7 +565,11 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args)
+	if h.responseCache != nil && callb != h.unsubscribeCb {
+		answer = h.runCachedMethod(ctx, msg, callb, args)
+	} else {
+		answer = h.runMethod(ctx, msg, callb, args)
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
@@ -530,14 +587,26 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	return answer
 }
 
-// handleSubscribe processes *_subscribe method calls.
+// handleSubscribe processes *_subscribe and *_resubscribe method calls.
 func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage {
 	if !h.allowSubscribe {
 		return msg.errorResponse(ErrNotificationsUnsupported)
 	}
 
+	// Resubscriptions pass the cursor to resume from before the subscription
+	// arguments.
+	var (
+		cursor string
+		params = msg.Params
+		err    error
+	)
+	if msg.isResubscribe() {
+		if cursor, params, err = parseResumeCursor(msg.Params); err != nil {
+			return msg.errorResponse(&invalidParamsError{err.Error()})
+		}
+	}
 	// Subscription method name is first argument.
-	name, err := parseSubscriptionName(msg.Params)
+	name, err := parseSubscriptionName(params)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
@@ -549,14 +618,14 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 
 	// Parse subscription name arg too, but remove it before calling the callback.
 	argTypes := append([]reflect.Type{stringType}, callb.argTypes...)
-	args, err := parsePositionalArguments(msg.Params, argTypes)
+	args, err := parsePositionalArguments(params, argTypes)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
 	args = args[1:]
 
 	// Install notifier in context so the subscription handler can find it.
-	n := &Notifier{h: h, namespace: namespace}
+	n := &Notifier{h: h, namespace: namespace, resumeCursor: cursor}
 	cp.notifiers = append(cp.notifiers, n)
 	ctx := context.WithValue(cp.ctx, notifierKey{}, n)
 
@@ -572,6 +641,27 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 	return msg.response(result)
 }
 
+// runCachedMethod serves a method call from the response cache if possible. Else
+// it runs the method, caching the result if the method marked it as cacheable.
+func (h *handler) runCachedMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value) *jsonrpcMessage {
+	key, ok := responseCacheKey(msg)
+	if !ok {
+		return h.runMethod(ctx, msg, callb, args)
+	}
+	if result, ok := h.responseCache.get(key); ok {
+		return &jsonrpcMessage{Version: vsn, ID: msg.ID, Result: result}
+	}
+	var (
+		anchor     = new(cacheAnchor)
+		generation = h.responseCache.currentGeneration()
+	)
+	answer := h.runMethod(context.WithValue(ctx, cacheAnchorKey{}, anchor), msg, callb, args)
+	if answer.Error == nil && anchor.set {
+		h.responseCache.add(key, answer.Result, anchor.number, generation)
+	}
+	return answer
+}
+
 // unsubscribe is the callback function for all *_unsubscribe calls.
 func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	h.subLock.Lock()
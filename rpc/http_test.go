@@ -23,6 +23,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/internal/telemetry"
 )
 
 func confirmStatusCode(t *testing.T, got, want int) {
@@ -243,3 +245,54 @@ func TestNewContextWithHeaders(t *testing.T) {
 		t.Error("call failed:", err)
 	}
 }
+
+type traceService struct{}
+
+func (traceService) SpanContext(ctx context.Context) telemetry.SpanContext {
+	sc, _ := telemetry.SpanContextFromContext(ctx)
+	return sc
+}
+
+// Tests that the trace context of the caller is propagated over HTTP, and that
+// method calls are served within a child span of the caller.
+func TestHTTPTraceparent(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+
+	if err := telemetry.Enable(telemetry.Config{Endpoint: collector.URL, SampleRatio: 1}); err != nil {
+		t.Fatal(err)
+	}
+	defer telemetry.Disable()
+
+	s := NewServer()
+	defer s.Stop()
+	s.RegisterName("trace", traceService{})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	c, err := Dial(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	parent, _ := telemetry.ParseTraceparent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx := telemetry.ContextWithRemoteSpanContext(context.Background(), parent)
+
+	var sc telemetry.SpanContext
+	if err := c.CallContext(ctx, &sc, "trace_spanContext"); err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID != parent.TraceID || sc.SpanID == parent.SpanID || !sc.Sampled {
+		t.Errorf("server span context mismatch: have %+v, want child of %+v", sc, parent)
+	}
+	// Explicitly set trace headers take precedence over the context.
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	if err := c.CallContext(NewContextWithHeaders(ctx, header), &sc, "trace_spanContext"); err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.Sampled {
+		t.Errorf("server span context mismatch: have %+v", sc)
+	}
+}
@@ -33,6 +33,7 @@ const (
 	vsn                      = "2.0"
 	serviceMethodSeparator   = "_"
 	subscribeMethodSuffix    = "_subscribe"
+	resubscribeMethodSuffix  = "_resubscribe"
 	unsubscribeMethodSuffix  = "_unsubscribe"
 	notificationMethodSuffix = "_subscription"
 
@@ -44,11 +45,13 @@ var null = json.RawMessage("null")
 type subscriptionResult struct {
 	ID     string          `json:"subscription"`
 	Result json.RawMessage `json:"result,omitempty"`
+	Cursor string          `json:"cursor,omitempty"`
 }
 
 type subscriptionResultEnc struct {
 	ID     string `json:"subscription"`
 	Result any    `json:"result"`
+	Cursor string `json:"cursor,omitempty"`
 }
 
 type jsonrpcSubscriptionNotification struct {
@@ -92,10 +95,28 @@ func (msg *jsonrpcMessage) isSubscribe() bool {
 	return strings.HasSuffix(msg.Method, subscribeMethodSuffix)
 }
 
+func (msg *jsonrpcMessage) isResubscribe() bool {
+	return strings.HasSuffix(msg.Method, resubscribeMethodSuffix)
+}
+
 func (msg *jsonrpcMessage) isUnsubscribe() bool {
 	return strings.HasSuffix(msg.Method, unsubscribeMethodSuffix)
 }
 
+// checkedCall returns the method and parameters which the policy and rate limits
+// apply to. Resubscriptions are checked as the subscriptions they resume, without
+// the cursor.
+func (msg *jsonrpcMessage) checkedCall() (string, json.RawMessage, error) {
+	if !msg.isResubscribe() {
+		return msg.Method, msg.Params, nil
+	}
+	_, params, err := parseResumeCursor(msg.Params)
+	if err != nil {
+		return "", nil, err
+	}
+	return msg.namespace() + subscribeMethodSuffix, params, nil
+}
+
 func (msg *jsonrpcMessage) namespace() string {
 	before, _, _ := strings.Cut(msg.Method, serviceMethodSeparator)
 	return before
@@ -354,6 +375,21 @@ func parseArgumentArray(dec *json.Decoder, types []reflect.Type) ([]reflect.Valu
 	return args, err
 }
 
+// parseResumeCursor splits the cursor to resume a subscription from, given as first
+// argument of *_resubscribe calls, from the subscription arguments.
+func parseResumeCursor(rawArgs json.RawMessage) (string, json.RawMessage, error) {
+	var args []json.RawMessage
+	if err := json.Unmarshal(rawArgs, &args); err != nil {
+		return "", nil, errors.New("non-array args")
+	}
+	var cursor string
+	if len(args) == 0 || json.Unmarshal(args[0], &cursor) != nil || cursor == "" {
+		return "", nil, errors.New("expected subscription cursor as first argument")
+	}
+	params, err := json.Marshal(args[1:])
+	return cursor, params, err
+}
+
 // parseSubscriptionName extracts the subscription name from an encoded argument array.
 func parseSubscriptionName(rawArgs json.RawMessage) (string, error) {
 	dec := json.NewDecoder(bytes.NewReader(rawArgs))
@@ -32,6 +32,18 @@ var (
 	serveTimeHistName = "rpc/duration"
 
 	rpcServingTimer = metrics.NewRegisteredTimer("rpc/duration/all", nil)
+
+	// rateLimitedName is the prefix of the per-method rate limited call meters.
+	rateLimitedName = "rpc/ratelimited"
+
+	rateLimitedMeter      = metrics.NewRegisteredMeter("rpc/ratelimited/all", nil)
+	rateLimitBucketsGauge = metrics.NewRegisteredGauge("rpc/ratelimit/buckets", nil)
+
+	responseCacheHitMeter  = metrics.NewRegisteredMeter("rpc/cache/hit", nil)
+	responseCacheMissMeter = metrics.NewRegisteredMeter("rpc/cache/miss", nil)
+	responseCacheSizeGauge = metrics.NewRegisteredGauge("rpc/cache/size", nil)
+
+	policyRejectedMeter = metrics.NewRegisteredMeter("rpc/policy/rejected", nil)
 )
 
 // updateServeTimeHistogram tracks the serving time of a remote RPC call.
@@ -48,3 +60,9 @@ func updateServeTimeHistogram(method string, success bool, elapsed time.Duration
 	}
 	metrics.GetOrRegisterHistogramLazy(h, nil, sampler).Update(elapsed.Nanoseconds())
 }
+
+// updateRateLimitedMeter tracks a call rejected due to exceeding the rate limit.
+func updateRateLimitedMeter(method string) {
+	rateLimitedMeter.Mark(1)
+	metrics.GetOrRegisterMeter(fmt.Sprintf("%s/%s", rateLimitedName, method), nil).Mark(1)
+}
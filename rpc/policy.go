@@ -0,0 +1,218 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PolicyConfig is the configuration of a Policy.
+type PolicyConfig struct {
+	// Allow lists the methods which may be called. If empty, all methods not
+	// denied may be called. Entries are method names (e.g. "eth_call"), or
+	// prefixes ending in a '*' wildcard (e.g. "eth_*").
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny lists the methods which may not be called, taking precedence over
+	// the allowed ones. Entries are matched like the allowed ones.
+	Deny []string `json:"deny,omitempty"`
+
+	// Params lists the constraints on the parameters of the allowed methods.
+	Params []ParamRule `json:"params,omitempty"`
+}
+
+// ParamRule is a constraint on a positional parameter of the matching methods.
+type ParamRule struct {
+	Method string `json:"method"` // Method name or prefix ending in a '*' wildcard
+	Param  int    `json:"param"`  // Index of the constrained parameter
+
+	// Forbidden disallows passing the parameter, e.g. to prevent state overrides
+	// in eth_call. Omitted and null parameters are permitted.
+	Forbidden bool `json:"forbidden,omitempty"`
+
+	// MaxBlockRange limits the number of blocks spanned by a filter parameter,
+	// e.g. of eth_getLogs, as given by its fromBlock and toBlock fields. Filters
+	// selecting a single block by hash are always permitted.
+	MaxBlockRange uint64 `json:"maxBlockRange,omitempty"`
+}
+
+// Policy restricts the methods which may be called on a server, and the parameters
+// they may be called with.
+type Policy struct {
+	allow  []string
+	deny   []string
+	params []ParamRule
+	head   atomic.Pointer[func() uint64]
+}
+
+// NewPolicy creates a policy enforcing the given configuration.
+func NewPolicy(config PolicyConfig) (*Policy, error) {
+	for _, rule := range config.Params {
+		if rule.Method == "" {
+			return nil, errors.New("parameter rule without method")
+		}
+		if rule.Param < 0 {
+			return nil, fmt.Errorf("invalid parameter index %d for %s", rule.Param, rule.Method)
+		}
+		if !rule.Forbidden && rule.MaxBlockRange == 0 {
+			return nil, fmt.Errorf("parameter rule for %s without constraint", rule.Method)
+		}
+	}
+	return &Policy{
+		allow:  config.Allow,
+		deny:   config.Deny,
+		params: config.Params,
+	}, nil
+}
+
+// SetHeadFunc sets the function reporting the current head block number, which
+// is used to resolve block tags when checking block ranges. Until set, ranges
+// bounded by tags other than "earliest" are not checked.
+func (p *Policy) SetHeadFunc(head func() uint64) {
+	p.head.Store(&head)
+}
+
+// matchMethod reports whether the method matches a name or '*' suffixed prefix.
+func matchMethod(pattern, method string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(method, prefix)
+	}
+	return pattern == method
+}
+
+// check verifies that a call of the method with the given parameters is
+// permitted by the policy.
+func (p *Policy) check(method string, params json.RawMessage) *policyError {
+	if p.denies(method) {
+		return &policyError{code: errcodeMethodNotSupported, method: method, reason: "method not allowed"}
+	}
+	var args []json.RawMessage
+	for i, rule := range p.params {
+		if !matchMethod(rule.Method, method) {
+			continue
+		}
+		if args == nil {
+			if err := json.Unmarshal(params, &args); err != nil {
+				return nil // Malformed parameters are rejected by the handler
+			}
+		}
+		if rule.Param >= len(args) || string(args[rule.Param]) == "null" {
+			continue
+		}
+		if reason := p.checkParam(&p.params[i], args[rule.Param]); reason != "" {
+			return &policyError{code: errcodeInvalidParams, method: method, param: rule.Param, reason: reason}
+		}
+	}
+	return nil
+}
+
+// denies reports whether the method may not be called.
+func (p *Policy) denies(method string) bool {
+	for _, pattern := range p.deny {
+		if matchMethod(pattern, method) {
+			return true
+		}
+	}
+	if len(p.allow) == 0 {
+		return false
+	}
+	for _, pattern := range p.allow {
+		if matchMethod(pattern, method) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkParam verifies a parameter against a rule, returning the reason for the
+// violation if any.
+func (p *Policy) checkParam(rule *ParamRule, param json.RawMessage) string {
+	if rule.Forbidden {
+		return "parameter not allowed"
+	}
+	var filter struct {
+		BlockHash *common.Hash `json:"blockHash"`
+		FromBlock *BlockNumber `json:"fromBlock"`
+		ToBlock   *BlockNumber `json:"toBlock"`
+	}
+	if err := json.Unmarshal(param, &filter); err != nil || filter.BlockHash != nil {
+		return "" // Malformed filters are rejected by the method
+	}
+	from, ok := p.resolve(filter.FromBlock)
+	if !ok {
+		return ""
+	}
+	to, ok := p.resolve(filter.ToBlock)
+	if !ok || to < from {
+		return ""
+	}
+	if to-from+1 > rule.MaxBlockRange {
+		return fmt.Sprintf("block range %d exceeds limit %d", to-from+1, rule.MaxBlockRange)
+	}
+	return ""
+}
+
+// resolve converts a block number of a filter into an absolute one, treating
+// omitted numbers as the latest block.
+func (p *Policy) resolve(number *BlockNumber) (uint64, bool) {
+	if number != nil && *number >= 0 {
+		return uint64(*number), true
+	}
+	head := p.head.Load()
+	if head == nil {
+		return 0, false
+	}
+	return (*head)(), true
+}
+
+// policyError is returned for calls violating the policy of the server.
+type policyError struct {
+	code   int
+	method string
+	param  int
+	reason string
+}
+
+// policyErrorData is the error data of a call violating the server policy.
+type policyErrorData struct {
+	Method string `json:"method"`
+	Param  *int   `json:"param,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e *policyError) ErrorCode() int { return e.code }
+
+func (e *policyError) Error() string {
+	if e.code == errcodeMethodNotSupported {
+		return fmt.Sprintf("the method %s is not allowed by the server policy", e.method)
+	}
+	return fmt.Sprintf("parameter %d of %s not allowed by the server policy: %s", e.param, e.method, e.reason)
+}
+
+func (e *policyError) ErrorData() interface{} {
+	data := policyErrorData{Method: e.method, Reason: e.reason}
+	if e.code != errcodeMethodNotSupported {
+		data.Param = &e.param
+	}
+	return data
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type policyTestService struct{}
+
+func (policyTestService) Logs(filter json.RawMessage) string { return "ok" }
+
+// Tests that the methods denied by the policy are rejected.
+func TestPolicyMethods(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy(PolicyConfig{
+		Allow: []string{"test_*"},
+		Deny:  []string{"test_returnError"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	server.SetPolicy(policy)
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	for _, tt := range []struct {
+		method string
+		denied bool
+	}{
+		{"test_echo", false},
+		{"test_returnError", true},
+		{"rpc_modules", true},
+	} {
+		var result interface{}
+		err := client.Call(&result, tt.method, "x", 1)
+		var rpcErr Error
+		denied := errors.As(err, &rpcErr) && rpcErr.ErrorCode() == errcodeMethodNotSupported
+		if denied != tt.denied {
+			t.Errorf("%s: denial mismatch: have %v, want %v", tt.method, err, tt.denied)
+		}
+	}
+}
+
+// Tests that the parameters constrained by the policy are checked.
+func TestPolicyParams(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy(PolicyConfig{
+		Params: []ParamRule{
+			{Method: "test_echo", Param: 2, Forbidden: true},
+			{Method: "policy_logs", Param: 0, MaxBlockRange: 10},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	server.SetPolicy(policy)
+	server.RegisterName("policy", policyTestService{})
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	call := func(method string, args ...interface{}) error {
+		var result interface{}
+		return client.Call(&result, method, args...)
+	}
+	if err := call("test_echo", "x", 1); err != nil {
+		t.Errorf("call without forbidden parameter failed: %v", err)
+	}
+	if err := call("test_echo", "x", 1, nil); err != nil {
+		t.Errorf("call with null forbidden parameter failed: %v", err)
+	}
+	err = call("test_echo", "x", 1, echoArgs{S: "override"})
+	var dataErr DataError
+	if !errors.As(err, &dataErr) {
+		t.Fatalf("call with forbidden parameter not rejected: %v", err)
+	}
+	if data, ok := dataErr.ErrorData().(map[string]interface{}); !ok || data["method"] != "test_echo" || data["param"] != 2.0 {
+		t.Errorf("error data mismatch: %v", dataErr.ErrorData())
+	}
+	for _, tt := range []struct {
+		filter  map[string]interface{}
+		allowed bool
+	}{
+		{map[string]interface{}{"fromBlock": "0x1", "toBlock": "0xa"}, true},
+		{map[string]interface{}{"fromBlock": "0x1", "toBlock": "0xb"}, false},
+		{map[string]interface{}{"fromBlock": "earliest", "toBlock": "0x9"}, true},
+		{map[string]interface{}{"blockHash": "0x0000000000000000000000000000000000000000000000000000000000000001"}, true},
+		{map[string]interface{}{"fromBlock": "0x1"}, true}, // Unresolvable until the head is known
+	} {
+		if err := call("policy_logs", tt.filter); (err == nil) != tt.allowed {
+			t.Errorf("filter %v: have %v, want allowed %v", tt.filter, err, tt.allowed)
+		}
+	}
+	policy.SetHeadFunc(func() uint64 { return 100 })
+	if err := call("policy_logs", map[string]interface{}{"fromBlock": "0x1"}); err == nil {
+		t.Error("filter up to the head not rejected")
+	}
+	if err := call("policy_logs", map[string]interface{}{"fromBlock": "0x5b", "toBlock": "latest"}); err != nil {
+		t.Errorf("filter within range of the head rejected: %v", err)
+	}
+}
+
+// Tests that resubscriptions are checked as the subscriptions they resume.
+func TestPolicyResubscribe(t *testing.T) {
+	t.Parallel()
+
+	policy, err := NewPolicy(PolicyConfig{
+		Deny:   []string{"test_subscribe"},
+		Params: []ParamRule{{Method: "nftest_subscribe", Param: 3, Forbidden: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	server.SetPolicy(policy)
+	defer server.Stop()
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	code := func(err error) int {
+		var rpcErr Error
+		if errors.As(err, &rpcErr) {
+			return rpcErr.ErrorCode()
+		}
+		return 0
+	}
+	var result interface{}
+	err = client.Call(&result, "test_resubscribe", "cursor", "someSubscription", 1, 2)
+	if code(err) != errcodeMethodNotSupported {
+		t.Errorf("resubscription of denied subscription not rejected: %v", err)
+	}
+	// The cursor doesn't count towards the parameter positions.
+	if err := client.Call(&result, "nftest_resubscribe", "cursor", "someSubscription", 1, 2); err != nil {
+		t.Errorf("resubscription without forbidden parameter failed: %v", err)
+	}
+	err = client.Call(&result, "nftest_resubscribe", "cursor", "someSubscription", 1, 2, 3)
+	var dataErr DataError
+	if !errors.As(err, &dataErr) {
+		t.Fatalf("resubscription with forbidden parameter not rejected: %v", err)
+	}
+	if data, ok := dataErr.ErrorData().(map[string]interface{}); !ok || data["method"] != "nftest_subscribe" || data["param"] != 3.0 {
+		t.Errorf("error data mismatch: %v", dataErr.ErrorData())
+	}
+}
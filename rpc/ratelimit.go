@@ -0,0 +1,204 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"golang.org/x/time/rate"
+)
+
+// maxRateLimitBuckets is the maximum number of client token buckets tracked by
+// a rate limiter. The least recently used ones are dropped beyond this, which
+// refills them for the affected clients.
+const maxRateLimitBuckets = 65536
+
+// RateLimit is a token bucket limit applied separately for each client to the
+// calls of all the matching methods.
+type RateLimit struct {
+	// Method is the name of the limited method (e.g. "eth_getLogs"), or a prefix
+	// ending in a '*' wildcard (e.g. "debug_trace*" or "debug_*"). A lone '*'
+	// matches all methods. If multiple limits match a method, the exact one, or
+	// else the one with the longest prefix is applied.
+	Method string
+
+	Rate  float64 // Number of calls per second allowed in the long run
+	Burst int     // Maximum number of calls allowed at once
+}
+
+// match returns whether the limit applies to the given method, along with the
+// specificity of the match.
+func (l *RateLimit) match(method string) (int, bool) {
+	if prefix, ok := strings.CutSuffix(l.Method, "*"); ok {
+		if strings.HasPrefix(method, prefix) {
+			return len(prefix), true
+		}
+		return 0, false
+	}
+	if l.Method == method {
+		return math.MaxInt, true
+	}
+	return 0, false
+}
+
+// RateLimitConfig is the configuration of the per client rate limits.
+type RateLimitConfig struct {
+	Limits []RateLimit // Limits to apply to the called methods
+
+	// KeyHeader is the name of the HTTP header carrying the API key of a client.
+	// If set and a known key is present in a request, clients are identified by
+	// their key. Else they are identified by their authenticated JWT subject, if
+	// any, or finally by their IP address.
+	KeyHeader string
+
+	// Keys is the set of API keys accepted in the key header. Unknown keys are
+	// ignored, so clients can't obtain fresh buckets by making up new keys.
+	Keys []string
+}
+
+// RateLimiter throttles RPC method calls according to token bucket limits, kept
+// separately for each client. A rate limiter can be shared across servers to
+// enforce the same budget across multiple transports.
+type RateLimiter struct {
+	limits    []RateLimit
+	keyHeader string
+	keys      map[string]struct{}
+
+	buckets lru.BasicLRU[rateLimitKey, *rate.Limiter]
+	lock    sync.Mutex
+}
+
+// rateLimitKey identifies a token bucket of a client for a specific limit.
+type rateLimitKey struct {
+	client string
+	limit  int
+}
+
+// NewRateLimiter creates a rate limiter enforcing the given limits.
+func NewRateLimiter(config RateLimitConfig) (*RateLimiter, error) {
+	for _, limit := range config.Limits {
+		if limit.Method == "" {
+			return nil, errors.New("rate limit without method")
+		}
+		if limit.Rate < 0 || limit.Burst <= 0 {
+			return nil, fmt.Errorf("invalid rate limit for %s: rate %v, burst %d", limit.Method, limit.Rate, limit.Burst)
+		}
+	}
+	if config.KeyHeader != "" && len(config.Keys) == 0 {
+		return nil, errors.New("rate limit key header without accepted keys")
+	}
+	keys := make(map[string]struct{}, len(config.Keys))
+	for _, key := range config.Keys {
+		keys[key] = struct{}{}
+	}
+	return &RateLimiter{
+		limits:    config.Limits,
+		keyHeader: config.KeyHeader,
+		keys:      keys,
+		buckets:   lru.NewBasicLRU[rateLimitKey, *rate.Limiter](maxRateLimitBuckets),
+	}, nil
+}
+
+// clientID derives the identity of the client issuing an HTTP request from a
+// known API key or the authenticated JWT subject. An empty string is returned if
+// neither is available, in which case clients are told apart by IP address.
+func (l *RateLimiter) clientID(r *http.Request) string {
+	if l.keyHeader != "" {
+		if key := r.Header.Get(l.keyHeader); key != "" {
+			if _, ok := l.keys[key]; ok {
+				return "key:" + key
+			}
+		}
+	}
+	if subject, ok := r.Context().Value(authSubjectContextKey{}).(string); ok && subject != "" {
+		return "jwt:" + subject
+	}
+	return ""
+}
+
+// allow checks whether a client may call the given method, consuming a token if
+// so. Otherwise, the time after which the call would be permitted is returned,
+// or zero if the call will never be permitted.
+func (l *RateLimiter) allow(ctx context.Context, method string) (time.Duration, bool) {
+	// Find the most specific limit applicable to the method, if any
+	var (
+		index = -1
+		best  int
+	)
+	for i := range l.limits {
+		if specificity, ok := l.limits[i].match(method); ok && (index == -1 || specificity > best) {
+			index, best = i, specificity
+		}
+	}
+	if index == -1 {
+		return 0, true
+	}
+	key := rateLimitKey{client: rateLimitClient(ctx), limit: index}
+
+	// Retrieve the token bucket of the client and consume a token from it
+	l.lock.Lock()
+	bucket, ok := l.buckets.Get(key)
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(l.limits[index].Rate), l.limits[index].Burst)
+		l.buckets.Add(key, bucket)
+	}
+	rateLimitBucketsGauge.Update(int64(l.buckets.Len()))
+	l.lock.Unlock()
+
+	now := time.Now()
+	reservation := bucket.ReserveN(now, 1)
+	if !reservation.OK() {
+		return 0, false
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+	return 0, true
+}
+
+// rateLimitClient returns the identity of the client issuing a call, falling
+// back to the IP address if the server could not establish a stronger one.
+func rateLimitClient(ctx context.Context) string {
+	info := PeerInfoFromContext(ctx)
+	if info.ClientID != "" {
+		return info.ClientID
+	}
+	host, _, err := net.SplitHostPort(info.RemoteAddr)
+	if err != nil {
+		host = info.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+type authSubjectContextKey struct{}
+
+// NewContextWithAuthSubject creates a new context carrying the subject of the
+// authenticated client. HTTP middlewares verifying the identity of a client can
+// use it on the request context to have the server rate limit by subject.
+func NewContextWithAuthSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, authSubjectContextKey{}, subject)
+}
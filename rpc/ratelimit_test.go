@@ -0,0 +1,141 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests that rate limits are enforced per client and method, picking the most
+// specific limit, and that throttled calls report when to retry.
+func TestRateLimits(t *testing.T) {
+	t.Parallel()
+
+	limiter, err := NewRateLimiter(RateLimitConfig{
+		Limits: []RateLimit{
+			{Method: "*", Rate: 1000, Burst: 1000},
+			{Method: "test_*", Rate: 0.001, Burst: 3},
+			{Method: "test_null", Rate: 0.001, Burst: 1},
+		},
+		KeyHeader: "X-Api-Key",
+		Keys:      []string{"alice", "bob"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create rate limiter: %v", err)
+	}
+	server := newTestServer()
+	server.SetRateLimiter(limiter)
+	defer server.Stop()
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	dial := func(key string) *Client {
+		var opts []ClientOption
+		if key != "" {
+			opts = append(opts, WithHeader("X-Api-Key", key))
+		}
+		client, err := DialOptions(context.Background(), httpsrv.URL, opts...)
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		return client
+	}
+	call := func(client *Client, method string) error {
+		var result any
+		return client.Call(&result, method)
+	}
+	checkLimited := func(err error, method string) {
+		t.Helper()
+
+		var rpcErr Error
+		if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != errcodeLimitExceeded {
+			t.Fatalf("%s: expected rate limit error, got %v", method, err)
+		}
+		var dataErr DataError
+		if !errors.As(err, &dataErr) {
+			t.Fatalf("%s: missing error data: %v", method, err)
+		}
+		data, _ := dataErr.ErrorData().(map[string]interface{})
+		if retry, _ := data["retryAfter"].(float64); retry < 1 {
+			t.Errorf("%s: invalid retry-after data: %v", method, dataErr.ErrorData())
+		}
+	}
+	client := dial("alice")
+	defer client.Close()
+
+	// The exact limit should apply to test_null, the prefixed one to the rest
+	if err := call(client, "test_null"); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	checkLimited(call(client, "test_null"), "test_null")
+
+	for i := 0; i < 3; i++ {
+		if err := call(client, "test_rets"); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	checkLimited(call(client, "test_rets"), "test_rets")
+
+	// Methods outside of the namespace should only be subject to the global limit
+	if err := call(client, "rpc_modules"); err != nil {
+		t.Fatalf("unrelated call failed: %v", err)
+	}
+	// Other clients should have their own buckets, falling back to IP addresses
+	other := dial("bob")
+	defer other.Close()
+
+	if err := call(other, "test_null"); err != nil {
+		t.Fatalf("call from other client failed: %v", err)
+	}
+	anon := dial("")
+	defer anon.Close()
+
+	if err := call(anon, "test_null"); err != nil {
+		t.Fatalf("call from anonymous client failed: %v", err)
+	}
+	checkLimited(call(anon, "test_null"), "test_null")
+
+	// Unknown keys should not grant a fresh bucket
+	mallory := dial("mallory")
+	defer mallory.Close()
+
+	checkLimited(call(mallory, "test_null"), "test_null")
+}
+
+// Tests that invalid rate limits are rejected.
+func TestRateLimitsInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []RateLimit{
+		{Method: "", Rate: 1, Burst: 1},
+		{Method: "eth_call", Rate: -1, Burst: 1},
+		{Method: "eth_call", Rate: 1, Burst: 0},
+	}
+	for i, limit := range tests {
+		if _, err := NewRateLimiter(RateLimitConfig{Limits: []RateLimit{limit}}); err == nil {
+			t.Errorf("test %d: invalid limit accepted", i)
+		}
+	}
+	valid := []RateLimit{{Method: "eth_call", Rate: 1, Burst: 1}}
+	if _, err := NewRateLimiter(RateLimitConfig{Limits: valid, KeyHeader: "X-Api-Key"}); err == nil {
+		t.Errorf("key header without keys accepted")
+	}
+}
@@ -54,6 +54,10 @@ type Server struct {
 	batchItemLimit     int
 	batchResponseLimit int
 	httpBodyLimit      int
+	rateLimiter        *RateLimiter
+	responseCache      *ResponseCache
+	policy             *Policy
+	auditor            CallAuditor
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -89,6 +93,42 @@ func (s *Server) SetHTTPBodyLimit(limit int) {
 	s.httpBodyLimit = limit
 }
 
+// SetRateLimiter sets the rate limiter throttling the method calls of clients. The
+// same limiter may be set on multiple servers to share the limits across them.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetRateLimiter(limiter *RateLimiter) {
+	s.rateLimiter = limiter
+}
+
+// SetResponseCache sets the cache storing the results of method calls which opted
+// into caching. The same cache may be set on multiple servers to share it.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetResponseCache(cache *ResponseCache) {
+	s.responseCache = cache
+}
+
+// SetPolicy sets the policy restricting the methods which may be called, and the
+// parameters they may be called with.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetPolicy(policy *Policy) {
+	s.policy = policy
+}
+
+// SetCallAuditor sets the auditor keeping a record of the calls served. The same
+// auditor may be set on multiple servers to keep a single record across them.
+//
+// This method should be called before processing any requests via ServeCodec, ServeHTTP,
+// ServeListener etc.
+func (s *Server) SetCallAuditor(auditor CallAuditor) {
+	s.auditor = auditor
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either an RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -114,6 +154,10 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 		idgen:              s.idgen,
 		batchItemLimit:     s.batchItemLimit,
 		batchResponseLimit: s.batchResponseLimit,
+		rateLimiter:        s.rateLimiter,
+		responseCache:      s.responseCache,
+		policy:             s.policy,
+		auditor:            s.auditor,
 	}
 	c := initClient(codec, &s.services, cfg)
 	<-codec.closed()
@@ -147,7 +191,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 		return
 	}
 
-	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit)
+	h := newHandler(ctx, codec, s.idgen, &s.services, s.batchItemLimit, s.batchResponseLimit, s.rateLimiter, s.responseCache, s.policy, s.auditor)
 	h.allowSubscribe = false
 	defer h.close(io.EOF, nil)
 
@@ -226,6 +270,14 @@ type PeerInfo struct {
 	// Address of client. This will usually contain the IP address and port.
 	RemoteAddr string
 
+	// Identity of the client for rate limiting, established from the configured
+	// API key header or the authenticated JWT subject. Empty if neither is known.
+	ClientID string
+
+	// Claims of the token the client authenticated with, for example the JWT of
+	// the authenticated endpoints. Nil if the client is not authenticated.
+	AuthClaims map[string]interface{}
+
 	// Additional information for HTTP and WebSocket connections.
 	HTTP struct {
 		// Protocol version, i.e. "HTTP/1.1". This is not set for WebSocket.
@@ -100,16 +100,33 @@ func NotifierFromContext(ctx context.Context) (*Notifier, bool) {
 // Notifier is tied to an RPC connection that supports subscriptions.
 // Server callbacks use the notifier to send notifications.
 type Notifier struct {
-	h         *handler
-	namespace string
+	h            *handler
+	namespace    string
+	resumeCursor string
 
 	mu           sync.Mutex
 	sub          *Subscription
-	buffer       []any
+	buffer       []notification
 	callReturned bool
 	activated    bool
 }
 
+// notification is a notification buffered until the subscription is activated.
+type notification struct {
+	data   any
+	cursor string
+}
+
+// ResumeCursor returns the cursor the client resumes the subscription from, or the
+// empty string for new subscriptions. Clients resume subscriptions after losing
+// their connection, passing the cursor of the last notification received.
+//
+// Subscriptions supporting resumption should first send the notifications missed
+// by the client since the cursor, or fail the subscribe call if they cannot.
+func (n *Notifier) ResumeCursor() string {
+	return n.resumeCursor
+}
+
 // CreateSubscription returns a new subscription that is coupled to the
 // RPC connection. By default subscriptions are inactive and notifications
 // are dropped until the subscription is marked as active. This is done
@@ -130,6 +147,13 @@ func (n *Notifier) CreateSubscription() *Subscription {
 // Notify sends a notification to the client with the given data as payload.
 // If an error occurs the RPC connection is closed and the error is returned.
 func (n *Notifier) Notify(id ID, data any) error {
+	return n.NotifyWithCursor(id, data, "")
+}
+
+// NotifyWithCursor sends a notification to the client like Notify, along with
+// the cursor the client may resume the subscription from after it. An empty
+// cursor leaves the position of the client unchanged.
+func (n *Notifier) NotifyWithCursor(id ID, data any, cursor string) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
@@ -139,9 +163,9 @@ func (n *Notifier) Notify(id ID, data any) error {
 		panic("Notify with wrong ID")
 	}
 	if n.activated {
-		return n.send(n.sub, data)
+		return n.send(n.sub, data, cursor)
 	}
-	n.buffer = append(n.buffer, data)
+	n.buffer = append(n.buffer, notification{data, cursor})
 	return nil
 }
 
@@ -161,8 +185,8 @@ func (n *Notifier) activate() error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	for _, data := range n.buffer {
-		if err := n.send(n.sub, data); err != nil {
+	for _, notif := range n.buffer {
+		if err := n.send(n.sub, notif.data, notif.cursor); err != nil {
 			return err
 		}
 	}
@@ -170,13 +194,14 @@ func (n *Notifier) activate() error {
 	return nil
 }
 
-func (n *Notifier) send(sub *Subscription, data any) error {
+func (n *Notifier) send(sub *Subscription, data any, cursor string) error {
 	msg := jsonrpcSubscriptionNotification{
 		Version: vsn,
 		Method:  n.namespace + notificationMethodSuffix,
 		Params: subscriptionResultEnc{
 			ID:     string(sub.ID),
 			Result: data,
+			Cursor: cursor,
 		},
 	}
 	return n.h.conn.writeJSON(context.Background(), &msg, false)
@@ -207,10 +232,12 @@ type ClientSubscription struct {
 	etype     reflect.Type
 	channel   reflect.Value
 	namespace string
+	args      []interface{} // subscription arguments, for resuming
+	idLock    sync.Mutex
 	subid     string
 
 	// The in channel receives notification values from client dispatcher.
-	in chan json.RawMessage
+	in chan *subscriptionResult
 
 	// The error channel receives the error from the forwarding loop.
 	// It is closed by Unsubscribe.
@@ -228,13 +255,14 @@ type ClientSubscription struct {
 // This is the sentinel value sent on sub.quit when Unsubscribe is called.
 var errUnsubscribed = errors.New("unsubscribed")
 
-func newClientSubscription(c *Client, namespace string, channel reflect.Value) *ClientSubscription {
+func newClientSubscription(c *Client, namespace string, channel reflect.Value, args []interface{}) *ClientSubscription {
 	sub := &ClientSubscription{
 		client:      c,
 		namespace:   namespace,
+		args:        args,
 		etype:       channel.Type().Elem(),
 		channel:     channel,
-		in:          make(chan json.RawMessage),
+		in:          make(chan *subscriptionResult),
 		quit:        make(chan error),
 		forwardDone: make(chan struct{}),
 		unsubDone:   make(chan struct{}),
@@ -244,7 +272,9 @@ func newClientSubscription(c *Client, namespace string, channel reflect.Value) *
 }
 
 // Err returns the subscription error channel. The intended use of Err is to schedule
-// resubscription when the client connection is closed unexpectedly.
+// resubscription when the client connection is closed unexpectedly. Subscriptions
+// of clients created with WithSubscriptionResumption only report connection errors
+// if they could not be resumed.
 //
 // The error channel receives a value when the subscription has ended due to an error. The
 // received error is nil if Close has been called on the underlying client and no other
@@ -268,8 +298,15 @@ func (sub *ClientSubscription) Unsubscribe() {
 	})
 }
 
+// setID sets the ID of the subscription on the server.
+func (sub *ClientSubscription) setID(id string) {
+	sub.idLock.Lock()
+	defer sub.idLock.Unlock()
+	sub.subid = id
+}
+
 // deliver is called by the client's message dispatcher to send a notification value.
-func (sub *ClientSubscription) deliver(result json.RawMessage) (ok bool) {
+func (sub *ClientSubscription) deliver(result *subscriptionResult) (ok bool) {
 	select {
 	case sub.in <- result:
 		return true
@@ -316,9 +353,17 @@ func (sub *ClientSubscription) run() {
 // forward is the forwarding loop. It takes in RPC notifications and sends them
 // on the subscription channel.
 func (sub *ClientSubscription) forward() (unsubscribeServer bool, err error) {
+	var (
+		cursor       string     // cursor of the last notification received
+		resumed      chan error // receives the result of resuming, nil if not resuming
+		cancelResume = func() {}
+	)
+	defer func() { cancelResume() }()
+
 	cases := []reflect.SelectCase{
 		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.quit)},
 		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(sub.in)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(resumed)},
 		{Dir: reflect.SelectSend, Chan: sub.channel},
 	}
 	buffer := list.New()
@@ -328,10 +373,10 @@ func (sub *ClientSubscription) forward() (unsubscribeServer bool, err error) {
 		var recv reflect.Value
 		if buffer.Len() == 0 {
 			// Idle, omit send case.
-			chosen, recv, _ = reflect.Select(cases[:2])
+			chosen, recv, _ = reflect.Select(cases[:3])
 		} else {
 			// Non-empty buffer, send the first queued item.
-			cases[2].Send = reflect.ValueOf(buffer.Front().Value)
+			cases[3].Send = reflect.ValueOf(buffer.Front().Value)
 			chosen, recv, _ = reflect.Select(cases)
 		}
 
@@ -344,10 +389,23 @@ func (sub *ClientSubscription) forward() (unsubscribeServer bool, err error) {
 				// Exiting because Unsubscribe was called, unsubscribe on server.
 				return true, nil
 			}
+			if sub.resumable(cursor, err) {
+				// The connection was lost, keep forwarding the buffered notifications
+				// while resuming the subscription on a new one. The connection may
+				// also be lost while resuming, in which case it is restarted.
+				cancelResume()
+				ctx, cancel := context.WithTimeout(context.Background(), sub.client.resumeTimeout)
+				cancelResume = cancel
+				resumed = make(chan error, 1)
+				cases[2].Chan = reflect.ValueOf(resumed)
+				go sub.resume(ctx, cursor, resumed)
+				continue
+			}
 			return false, err
 
 		case 1: // <-sub.in
-			val, err := sub.unmarshal(recv.Interface().(json.RawMessage))
+			result := recv.Interface().(*subscriptionResult)
+			val, err := sub.unmarshal(result.Result)
 			if err != nil {
 				return true, err
 			}
@@ -355,14 +413,54 @@ func (sub *ClientSubscription) forward() (unsubscribeServer bool, err error) {
 				return true, ErrSubscriptionQueueOverflow
 			}
 			buffer.PushBack(val)
+			if result.Cursor != "" {
+				cursor = result.Cursor
+			}
 
-		case 2: // sub.channel<-
-			cases[2].Send = reflect.Value{} // Don't hold onto the value.
+		case 2: // <-resumed
+			if err, _ := recv.Interface().(error); err != nil {
+				return false, err
+			}
+			cancelResume()
+			resumed = nil
+			cases[2].Chan = reflect.ValueOf(resumed)
+
+		case 3: // sub.channel<-
+			cases[3].Send = reflect.Value{} // Don't hold onto the value.
 			buffer.Remove(buffer.Front())
 		}
 	}
 }
 
+// resumable reports whether the subscription can be resumed after it was closed
+// by the client with the given error.
+func (sub *ClientSubscription) resumable(cursor string, err error) bool {
+	if sub.client.resumeTimeout == 0 || cursor == "" {
+		return false
+	}
+	return err != nil && err != ErrClientQuit
+}
+
+// resume re-establishes the subscription from the cursor, reconnecting with backoff
+// until the context is canceled. Errors returned by the server end resuming.
+func (sub *ClientSubscription) resume(ctx context.Context, cursor string, result chan<- error) {
+	backoff := resumeMinBackoff
+	for {
+		err := sub.client.resubscribe(ctx, sub, cursor)
+		if err == nil || errors.As(err, new(Error)) || errors.Is(err, ErrClientQuit) {
+			result <- err
+			return
+		}
+		select {
+		case <-time.After(backoff):
+			backoff = min(2*backoff, resumeMaxBackoff)
+		case <-ctx.Done():
+			result <- err
+			return
+		}
+	}
+}
+
 func (sub *ClientSubscription) unmarshal(result json.RawMessage) (interface{}, error) {
 	val := reflect.New(sub.etype)
 	err := json.Unmarshal(result, val.Interface())
@@ -370,9 +468,13 @@ func (sub *ClientSubscription) unmarshal(result json.RawMessage) (interface{}, e
 }
 
 func (sub *ClientSubscription) requestUnsubscribe() error {
+	sub.idLock.Lock()
+	subid := sub.subid
+	sub.idLock.Unlock()
+
 	var result interface{}
 	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
 	defer cancel()
-	err := sub.client.CallContext(ctx, &result, sub.namespace+unsubscribeMethodSuffix, sub.subid)
+	err := sub.client.CallContext(ctx, &result, sub.namespace+unsubscribeMethodSuffix, subid)
 	return err
 }
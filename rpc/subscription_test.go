@@ -20,11 +20,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
 	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -274,3 +279,97 @@ func TestNotify(t *testing.T) {
 		t.Errorf("have:\n%v\nwant:\n%v\n", have, want)
 	}
 }
+
+// resumeTestService sends a few increasing numbers on every subscription, with
+// the number as cursor. Resumed subscriptions continue after the cursor.
+type resumeTestService struct {
+	refuse bool // whether to refuse resuming
+}
+
+func (s *resumeTestService) Counter(ctx context.Context) (*Subscription, error) {
+	notifier, _ := NotifierFromContext(ctx)
+	next := 0
+	if cursor := notifier.ResumeCursor(); cursor != "" {
+		if s.refuse {
+			return nil, errors.New("cursor too old")
+		}
+		last, err := strconv.Atoi(cursor)
+		if err != nil {
+			return nil, err
+		}
+		next = last + 1
+	}
+	sub := notifier.CreateSubscription()
+	for i := next; i < next+3; i++ {
+		notifier.NotifyWithCursor(sub.ID, i, strconv.Itoa(i))
+	}
+	return sub, nil
+}
+
+// Tests that subscriptions are resumed from the last cursor after the connection
+// was lost, and that they fail if the server cannot resume them.
+func TestSubscriptionResumption(t *testing.T) {
+	t.Parallel()
+
+	var current atomic.Pointer[Server]
+	start := func(service *resumeTestService) {
+		server := NewServer()
+		server.RegisterName("resume", service)
+		if old := current.Swap(server); old != nil {
+			old.Stop() // Drops all connections
+		}
+	}
+	start(new(resumeTestService))
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current.Load().WebsocketHandler([]string{"*"}).ServeHTTP(w, r)
+	}))
+	defer httpsrv.Close()
+	defer func() { current.Load().Stop() }()
+
+	client, err := DialOptions(context.Background(), "ws"+strings.TrimPrefix(httpsrv.URL, "http"), WithSubscriptionResumption(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ch := make(chan int)
+	sub, err := client.Subscribe(context.Background(), "resume", ch, "counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+
+	expect := func(from, to int) {
+		t.Helper()
+		for want := from; want <= to; want++ {
+			select {
+			case have := <-ch:
+				if have != want {
+					t.Fatalf("notification mismatch: have %d, want %d", have, want)
+				}
+			case err := <-sub.Err():
+				t.Fatalf("subscription failed: %v", err)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout waiting for notification %d", want)
+			}
+		}
+	}
+	expect(0, 2)
+
+	// Drop the connection, the subscription must continue after the cursor
+	start(new(resumeTestService))
+	expect(3, 5)
+
+	// Drop it again with a server refusing to resume, the subscription must fail
+	start(&resumeTestService{refuse: true})
+	select {
+	case err := <-sub.Err():
+		if err == nil || !strings.Contains(err.Error(), "cursor too old") {
+			t.Fatalf("subscription error mismatch: %v", err)
+		}
+	case v := <-ch:
+		t.Fatalf("unexpected notification %d", v)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not failed")
+	}
+}